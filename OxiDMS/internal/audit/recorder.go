@@ -0,0 +1,58 @@
+// Package audit records mutating actions (form/submission/document CRUD,
+// login/register, admin compact/reindex) to a persistent trail so they can
+// be reviewed later via the admin audit log and per-user activity feed.
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/middleware"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// Recorder writes one models.AuditEntry per call to the audit collection.
+// Recording is best-effort from the caller's point of view: a failure is
+// returned so the handler can log it, but — matching how this codebase
+// treats other auxiliary side effects like search indexing — it should
+// never fail the request the audit entry describes.
+type Recorder struct {
+	repo *repository.AuditRepo
+}
+
+func NewRecorder(repo *repository.AuditRepo) *Recorder {
+	return &Recorder{repo: repo}
+}
+
+// Record captures one audit event for the in-flight request r. Actor, IP,
+// user agent, and request ID all come from r; before/after are the
+// resource's state immediately before/after the action (nil for whichever
+// side doesn't apply).
+func (rec *Recorder) Record(r *http.Request, action, resource, resourceID string, before, after any) error {
+	actor := "-"
+	if claims := auth.GetUser(r.Context()); claims != nil {
+		actor = claims.UserID
+	}
+	return rec.RecordAs(r, actor, action, resource, resourceID, before, after)
+}
+
+// RecordAs is Record with an explicit actor, for actions like login and
+// registration where the subject of the action isn't yet (or no longer)
+// the request's authenticated Claims.
+func (rec *Recorder) RecordAs(r *http.Request, actor, action, resource, resourceID string, before, after any) error {
+	entry := &models.AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Before:     before,
+		After:      after,
+		IP:         middleware.ClientIP(r),
+		UA:         r.Header.Get("User-Agent"),
+		RequestID:  middleware.RequestIDFromContext(r.Context()),
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+	}
+	return rec.repo.Create(entry)
+}
@@ -1,34 +1,90 @@
 package auth
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenTTL is how long a JWT minted by GenerateToken is valid for.
+// Short on purpose: a compromised access token is only useful for this
+// long, with RefreshTokenTTL (see internal/auth's SessionManager) covering
+// the rest of a login session via POST /auth/refresh.
+const AccessTokenTTL = 15 * time.Minute
+
+// KeySet is the set of HS256 secrets ValidateToken accepts, keyed by kid,
+// and the kid GenerateToken signs new tokens with. Rotating the signing
+// secret means adding a new kid as Active while leaving the old one in
+// Keys: tokens already issued under it keep validating until they expire
+// naturally, instead of logging out every signed-in user at once.
+type KeySet struct {
+	Active string
+	Keys   map[string]string
+}
+
 type Claims struct {
 	UserID string `json:"userId"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// Scope is space-separated OAuth2 scopes for claims resolved from an
+	// opaque access token (see TokenLookup); empty for ordinary JWTs issued
+	// by GenerateToken, which carry the user's full role-based access.
+	Scope string `json:"scope,omitempty"`
+	// Permissions is the explicit authz permission list for claims resolved
+	// from a scoped API token (see internal/authz); empty for ordinary JWTs
+	// and OAuth2 tokens, which are authorized by Role instead (see
+	// internal/authz.Require).
+	Permissions []string `json:"permissions,omitempty"`
+	// SessionID names the _dms_sessions row (see repository.SessionRepo)
+	// this access token was minted alongside, so Middleware can reject it
+	// early via SessionManager.IsRevoked if that session has since been
+	// logged out. Set by every token SessionManager.Issue mints; kept
+	// optional rather than required for tokens minted some other way.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(secret, userID, email, role string) (string, error) {
+// GenerateToken signs a Claims-bearing JWT valid for ttl under keys'
+// active kid, stamped in the token header so ValidateToken (possibly
+// running with a newer KeySet after a rotation) knows which secret to
+// check it against. sessionID may be empty if the token isn't tied to a
+// revocable session.
+func GenerateToken(keys KeySet, ttl time.Duration, userID, email, role, sessionID string) (string, error) {
+	secret, ok := keys.Keys[keys.Active]
+	if !ok {
+		return "", fmt.Errorf("auth: no signing secret for active kid %q", keys.Active)
+	}
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = keys.Active
 	return token.SignedString([]byte(secret))
 }
 
-func ValidateToken(secret, tokenStr string) (*Claims, error) {
+// ValidateToken verifies tokenStr against the secret named by its "kid"
+// header in keys, so a token signed before a key rotation still validates
+// against its original secret rather than whatever is Active now. A token
+// with no kid header (shouldn't happen for anything GenerateToken issued)
+// is checked against keys.Active.
+func ValidateToken(keys KeySet, tokenStr string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (any, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok || kid == "" {
+			kid = keys.Active
+		}
+		secret, ok := keys.Keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("auth: unknown signing kid %q", kid)
+		}
 		return []byte(secret), nil
 	})
 	if err != nil {
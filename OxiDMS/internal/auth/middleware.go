@@ -4,33 +4,125 @@ import (
 	"context"
 	"net/http"
 	"strings"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/logging"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/middleware"
 )
 
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
-func Middleware(secret string) func(http.Handler) http.Handler {
+// TokenLookup resolves an opaque OAuth2 access token to the Claims it
+// represents, letting Middleware accept tokens issued by the oauth package
+// alongside HS256 JWTs from GenerateToken. ok is false if the token is
+// unknown, expired, or revoked.
+type TokenLookup interface {
+	LookupAccessToken(token string) (*Claims, bool)
+}
+
+// RevocationChecker reports whether a SessionID (see Claims.SessionID) has
+// been logged out, implemented by SessionManager. A nil RevocationChecker
+// passed to Middleware means nothing is ever treated as revoked, which is
+// never appropriate in production but keeps the middleware usable without
+// a SessionManager wired up (e.g. in isolation).
+type RevocationChecker interface {
+	IsRevoked(sessionID string) bool
+}
+
+// Middleware validates the Authorization header's Bearer token. It first
+// tries it as an HS256 JWT signed under keys, rejecting one whose
+// SessionID revoked reports revoked, then, if tokens is non-nil, as an
+// opaque OAuth2 access token resolved via tokens.
+func Middleware(keys KeySet, tokens TokenLookup, revoked RevocationChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			header := r.Header.Get("Authorization")
 			if header == "" || !strings.HasPrefix(header, "Bearer ") {
+				logging.FromContext(r.Context()).Warn("auth: missing bearer token", "path", r.URL.Path)
 				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 				return
 			}
 			tokenStr := strings.TrimPrefix(header, "Bearer ")
-			claims, err := ValidateToken(secret, tokenStr)
-			if err != nil {
-				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+
+			if claims, err := ValidateToken(keys, tokenStr); err == nil {
+				if claims.SessionID != "" && revoked != nil && revoked.IsRevoked(claims.SessionID) {
+					logging.FromContext(r.Context()).Warn("auth: rejected revoked session", "user_id", claims.UserID)
+					http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+					return
+				}
+				ctx := context.WithValue(r.Context(), UserContextKey, claims)
+				middleware.TagUser(ctx, claims.UserID, claims.Email)
+				logging.Tag(ctx, "user_id", claims.UserID)
+				ctx = gelf.WithFields(ctx, "user_id", claims.UserID, "user_email", claims.Email)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
-			ctx := context.WithValue(r.Context(), UserContextKey, claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+
+			if tokens != nil {
+				if claims, ok := tokens.LookupAccessToken(tokenStr); ok {
+					ctx := context.WithValue(r.Context(), UserContextKey, claims)
+					middleware.TagUser(ctx, claims.UserID, claims.Email)
+					logging.Tag(ctx, "user_id", claims.UserID)
+					ctx = gelf.WithFields(ctx, "user_id", claims.UserID, "user_email", claims.Email)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
+			logging.FromContext(r.Context()).Warn("auth: rejected invalid bearer token", "path", r.URL.Path)
+			http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
 		})
 	}
 }
 
+// ChainLookup combines multiple TokenLookups into one, trying each in
+// order and returning the first match. Used to let Middleware accept both
+// OAuth2 access tokens and scoped API tokens, which are stored in separate
+// collections by separate issuers.
+type ChainLookup []TokenLookup
+
+func (c ChainLookup) LookupAccessToken(token string) (*Claims, bool) {
+	for _, lookup := range c {
+		if claims, ok := lookup.LookupAccessToken(token); ok {
+			return claims, true
+		}
+	}
+	return nil, false
+}
+
 func GetUser(ctx context.Context) *Claims {
 	claims, _ := ctx.Value(UserContextKey).(*Claims)
 	return claims
 }
+
+// RequireScope rejects requests whose Claims carry a non-empty Scope that
+// doesn't include required. Claims from an ordinary JWT (Scope == "") pass
+// through unchecked, since those represent the user's own full access
+// rather than a scoped OAuth2 grant.
+func RequireScope(required string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUser(r.Context())
+			if claims == nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if claims.Scope != "" && !hasScope(claims.Scope, required) {
+				http.Error(w, `{"error":"insufficient scope"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasScope(scopeList, required string) bool {
+	for _, s := range strings.Fields(scopeList) {
+		if s == required || s == "admin" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// RefreshTokenTTL is how long a refresh token stays valid, renewed on
+// every POST /auth/refresh rotation, so a signed-in user doesn't have to
+// log in again for a month of continued use even though AccessTokenTTL is
+// only 15 minutes.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// revocationReloadInterval bounds how stale SessionManager's in-memory
+// revocation cache can be: a request landing within one interval of a
+// revocation (from another process, or before Revoke's own immediate
+// reload lands) may still carry a not-yet-rejected access token. The same
+// freshness/load trade-off db.Pool's circuit breaker makes.
+const revocationReloadInterval = 10 * time.Second
+
+var ErrInvalidRefreshToken = errors.New("auth: invalid or expired refresh token")
+
+// IssuedTokens is the access/refresh pair handed back by Issue and Rotate.
+type IssuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// SessionManager issues and rotates the access/refresh token pairs
+// AuthService hands out in place of a single long-lived JWT: a short
+// AccessTokenTTL JWT carrying the sid of a RefreshTokenTTL refresh token
+// stored hashed in _dms_sessions (see repository.SessionRepo). It also
+// maintains the in-memory revocation cache Middleware consults, reloaded
+// from SessionRepo.ActiveRevocations every revocationReloadInterval and
+// immediately after Revoke/RevokeAll.
+type SessionManager struct {
+	sessions *repository.SessionRepo
+	keys     KeySet
+
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+func NewSessionManager(sessions *repository.SessionRepo, keys KeySet) *SessionManager {
+	mgr := &SessionManager{sessions: sessions, keys: keys, revoked: map[string]bool{}}
+	mgr.reloadRevocations()
+	go mgr.reloadLoop()
+	return mgr
+}
+
+func (m *SessionManager) reloadLoop() {
+	ticker := time.NewTicker(revocationReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.reloadRevocations()
+	}
+}
+
+func (m *SessionManager) reloadRevocations() {
+	sessions, err := m.sessions.ActiveRevocations()
+	if err != nil {
+		// Keep serving the previous snapshot rather than wiping it on a
+		// transient read failure; the next tick tries again.
+		return
+	}
+	next := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		next[s.ID] = true
+	}
+	m.mu.Lock()
+	m.revoked = next
+	m.mu.Unlock()
+}
+
+// IsRevoked implements RevocationChecker for Middleware.
+func (m *SessionManager) IsRevoked(sessionID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.revoked[sessionID]
+}
+
+// Issue mints a fresh access/refresh pair for userID, recording a new
+// session row. userAgent and ip are whatever the client sent on this
+// request; both may be empty.
+func (m *SessionManager) Issue(userID, email, role, userAgent, ip string) (*IssuedTokens, error) {
+	refresh, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	sess := &models.Session{
+		UserID:    userID,
+		TokenHash: hashToken(refresh),
+		IssuedAt:  time.Now().UTC().Format(time.RFC3339),
+		ExpiresAt: time.Now().UTC().Add(RefreshTokenTTL).Format(time.RFC3339),
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+	if err := m.sessions.Create(sess); err != nil {
+		return nil, err
+	}
+	access, err := GenerateToken(m.keys, AccessTokenTTL, userID, email, role, sess.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedTokens{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// Rotate validates refreshToken against its session and revokes it,
+// reporting the session's owning user so the caller (AuthService.Refresh)
+// can look up their current email/role and mint a replacement pair via
+// Issue. Kept separate from Issue so an unknown, already-revoked, or
+// expired refresh token never gets a new pair (RFC 6749 rotation: reusing
+// a consumed refresh token is a sign of theft, not just reports it).
+//
+// The revoke itself goes through RevokeIfActive rather than a plain
+// Revoke so that two concurrent refreshes of the same token (a client
+// retry racing the original request) can't both pass the check above and
+// each walk away with a new pair: only the request whose update actually
+// flips revokedAt wins.
+func (m *SessionManager) Rotate(refreshToken string) (userID string, err error) {
+	sess, err := m.sessions.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		return "", err
+	}
+	if sess == nil || sess.RevokedAt != "" || expired(sess.ExpiresAt) {
+		return "", ErrInvalidRefreshToken
+	}
+	won, err := m.sessions.RevokeIfActive(sess.TokenHash)
+	if err != nil {
+		return "", err
+	}
+	if !won {
+		return "", ErrInvalidRefreshToken
+	}
+	m.reloadRevocations()
+	return sess.UserID, nil
+}
+
+// Revoke ends the session refreshToken belongs to (POST /auth/logout),
+// reloading the revocation cache immediately so the session's access
+// token is rejected right away rather than waiting for the next periodic
+// reload.
+func (m *SessionManager) Revoke(refreshToken string) error {
+	if err := m.sessions.Revoke(hashToken(refreshToken)); err != nil {
+		return err
+	}
+	m.reloadRevocations()
+	return nil
+}
+
+// RevokeAll ends every session belonging to userID (POST /auth/logout-all).
+func (m *SessionManager) RevokeAll(userID string) error {
+	if err := m.sessions.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	m.reloadRevocations()
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// expired reports whether rfc3339 has passed; an empty string (no expiry
+// set) never expires.
+func expired(rfc3339 string) bool {
+	if rfc3339 == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return true
+	}
+	return time.Now().UTC().After(t)
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,141 @@
+// Package authz implements OxiDMS's role-based access control: named roles
+// with default permissions (overridable by a policy file, see
+// LoadPolicyFile), per-form ACL entries granting users or groups additional
+// access, and the Require/RequireRole/RequirePolicy/RequireOwner middleware
+// factories that gate routes in router.New.
+package authz
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// Permission is a fine-grained capability string of the form
+// "resource:action", or "resource:*" for every action on resource.
+type Permission string
+
+const (
+	PermFormCreate    Permission = "form:create"
+	PermFormRead      Permission = "form:read"
+	PermFormUpdate    Permission = "form:update"
+	PermFormDelete    Permission = "form:delete"
+	PermSubmissionAll Permission = "submission:*"
+	PermDocumentAll   Permission = "document:*"
+	PermAdminAll      Permission = "admin:*"
+)
+
+// Role is a named bundle of permissions granted to every principal with
+// that role, independent of any per-form ACL grant.
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
+
+// rolePermissions mirrors models.User.Role: "admin" gets the admin:*
+// wildcard (see hasPermission), "user" gets just enough to browse forms,
+// create their own, and work with documents; everything else (changing
+// someone else's form, or reading or changing its submissions or its ACL)
+// has to come from a FormACL grant.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {PermAdminAll},
+	RoleUser:  {PermFormCreate, PermFormRead, PermDocumentAll},
+}
+
+// Require builds middleware that grants access if the caller's Claims carry
+// every permission in perms, either via their role's default permissions
+// (or, for a scoped API token, its explicit Permissions list in place of
+// the role) or, for routes with a {formId} URL param, a FormACL grant for
+// that form. acls may be nil, in which case only role permissions apply.
+func Require(acls *repository.FormACLRepo, perms ...Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := auth.GetUser(r.Context())
+			if claims == nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			if hasAll(rolePermissionsFor(claims), perms) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if acls != nil {
+				if formID := chi.URLParam(r, "formId"); formID != "" {
+					entry, err := acls.FindGrant(formID, claims.UserID)
+					if err == nil && entry != nil && hasAll(toPermissions(entry.Permissions), perms) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			writeForbidden(w, firstMissing(rolePermissionsFor(claims), perms))
+		})
+	}
+}
+
+// rolePermissionsFor returns the permissions a request's Claims carry: for
+// an ordinary JWT or OAuth2 token (Permissions == nil), that's the active
+// policy's grants for the user's role (see LoadPolicyFile/ReloadPolicyFile);
+// for a scoped API token, it's the narrower explicit list minted for that
+// token, which stands in place of the role entirely.
+func rolePermissionsFor(claims *auth.Claims) []Permission {
+	if claims.Permissions != nil {
+		return toPermissions(claims.Permissions)
+	}
+	return activePolicies.permissionsFor(Role(claims.Role))
+}
+
+// firstMissing returns the first permission in required that granted
+// doesn't satisfy, for reporting in a 403's missingScope field. Returns ""
+// if granted already satisfies everything, which shouldn't happen given
+// Require only calls this after hasAll(granted, required) fails.
+func firstMissing(granted, required []Permission) Permission {
+	for _, req := range required {
+		if !hasPermission(granted, req) {
+			return req
+		}
+	}
+	return ""
+}
+
+func toPermissions(raw []string) []Permission {
+	perms := make([]Permission, len(raw))
+	for i, p := range raw {
+		perms[i] = Permission(p)
+	}
+	return perms
+}
+
+func hasAll(granted, required []Permission) bool {
+	for _, req := range required {
+		if !hasPermission(granted, req) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPermission reports whether granted satisfies req, either exactly, via
+// its resource's wildcard ("form:*" satisfies "form:update"), or via the
+// global admin:* wildcard.
+func hasPermission(granted []Permission, req Permission) bool {
+	resource := string(req)
+	if i := strings.IndexByte(resource, ':'); i >= 0 {
+		resource = resource[:i]
+	}
+	wildcard := Permission(resource + ":*")
+	for _, g := range granted {
+		if g == req || g == wildcard || g == PermAdminAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package authz
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/oauth"
+)
+
+// writeForbidden is the structured 403 body for every guard in this file
+// (and for Require): {error, policy, missingScope}, so a client can tell
+// apart "you're signed in but lack X" from a bare unauthorized.
+func writeForbidden(w http.ResponseWriter, missing Permission) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":        "forbidden",
+		"policy":       "rbac",
+		"missingScope": string(missing),
+	})
+}
+
+// RequireRole builds middleware that admits only callers whose role is
+// exactly role, or an admin (admin:* already implies every other role's
+// access). Prefer Require for anything that's really permission-shaped;
+// reach for this only when a route is gated on role identity itself with
+// no finer-grained permission to name, e.g. the policy hot-reload endpoint.
+func RequireRole(role Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := auth.GetUser(r.Context())
+			if claims == nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if (Role(claims.Role) == role || Role(claims.Role) == RoleAdmin) && claimsCarryRole(claims, role) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeForbidden(w, Permission(string(role)+":*"))
+		})
+	}
+}
+
+// claimsCarryRole reports whether claims actually carry the access role
+// implies, beyond Role matching: LookupAccessToken (both APITokens' and
+// oauth.Server's) copies the owning user's full Role onto a token's Claims
+// unconditionally, even one scoped far narrower via its own Permissions
+// list or OAuth2 Scope, so Role alone can't be trusted for a check this
+// consequential. A scoped API token's Permissions must actually grant
+// role's permissions; an OAuth2 token's Scope must carry oauth.ScopeAdmin.
+// An ordinary JWT (Permissions nil, Scope empty) represents the user's own
+// full access, so it passes unchecked, same as it always has.
+func claimsCarryRole(claims *auth.Claims, role Role) bool {
+	if claims.Permissions != nil {
+		return hasAll(toPermissions(claims.Permissions), rolePermissions[role])
+	}
+	if claims.Scope != "" {
+		return oauthScopeGrantsRole(claims.Scope)
+	}
+	return true
+}
+
+// oauthScopeGrantsRole reports whether scope (a claims.Scope string, e.g.
+// "forms:read submissions:write") was minted with oauth.ScopeAdmin, the
+// only OAuth2 grant broad enough to stand in for a role check.
+func oauthScopeGrantsRole(scope string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == oauth.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePolicy is Require for a single permission with no per-form ACL
+// fallback, for routes that aren't scoped to a {formId}.
+func RequirePolicy(perm Permission) func(http.Handler) http.Handler {
+	return Require(nil, perm)
+}
+
+// RequireOwner builds middleware that admits a caller holding admin:*, or
+// one for whom ownerID(r) returns their own Claims.UserID — for routes
+// whose resource isn't modeled with a FormACL (only forms have those) but
+// where "you can only touch what you created" is still the rule. ownerID
+// returning an error denies with 404 rather than 403, since that usually
+// means the resource itself doesn't exist.
+//
+// Forms don't use this: FormService.Create already auto-grants the
+// creator a form:update ACL entry, which both implies ownership and lets
+// the creator share access with collaborators, so layering RequireOwner on
+// top would reject those collaborators' legitimately-granted access.
+func RequireOwner(ownerID func(r *http.Request) (string, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := auth.GetUser(r.Context())
+			if claims == nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if hasPermission(rolePermissionsFor(claims), PermAdminAll) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			owner, err := ownerID(r)
+			if err != nil {
+				http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+				return
+			}
+			if owner != claims.UserID {
+				writeForbidden(w, "owner")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// APITokens mints and resolves scoped API tokens: opaque bearer
+// credentials a user can hand to an external script without sharing their
+// password, limited to an explicit permission list and an optional expiry.
+// It implements auth.TokenLookup so auth.Middleware accepts these tokens
+// alongside HS256 JWTs and OAuth2 access tokens.
+type APITokens struct {
+	tokens *repository.APITokenRepo
+	users  *repository.UserRepo
+}
+
+func NewAPITokens(tokens *repository.APITokenRepo, users *repository.UserRepo) *APITokens {
+	return &APITokens{tokens: tokens, users: users}
+}
+
+// Mint issues a new token for userID named name, scoped to perms. ttl of
+// zero means the token never expires. The returned string is the only time
+// the cleartext bearer value is available; only its hash is stored.
+func (a *APITokens) Mint(userID, name string, perms []string, ttl time.Duration) (string, *models.APIToken, error) {
+	if len(perms) == 0 {
+		return "", nil, errors.New("at least one scope is required")
+	}
+	raw, err := randomToken(24)
+	if err != nil {
+		return "", nil, err
+	}
+	rec := &models.APIToken{
+		Name:        name,
+		UserID:      userID,
+		TokenHash:   hashToken(raw),
+		Permissions: perms,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if ttl > 0 {
+		rec.ExpiresAt = time.Now().UTC().Add(ttl).Format(time.RFC3339)
+	}
+	if err := a.tokens.Create(rec); err != nil {
+		return "", nil, err
+	}
+	return raw, rec, nil
+}
+
+// LookupAccessToken implements auth.TokenLookup.
+func (a *APITokens) LookupAccessToken(token string) (*auth.Claims, bool) {
+	rec, err := a.tokens.FindByHash(hashToken(token))
+	if err != nil || rec == nil || rec.Revoked || expired(rec.ExpiresAt) {
+		return nil, false
+	}
+	user, err := a.users.FindByID(rec.UserID)
+	if err != nil || user == nil {
+		return nil, false
+	}
+	return &auth.Claims{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Role:        user.Role,
+		Permissions: rec.Permissions,
+	}, true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// expired reports whether rfc3339 has passed; an empty string (no expiry
+// set) never expires.
+func expired(rfc3339 string) bool {
+	if rfc3339 == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return true
+	}
+	return time.Now().UTC().After(t)
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,32 @@
+// Package blobstore abstracts where DocumentService's object bytes actually
+// live, behind a single Backend interface. DocumentRepo used to call
+// oxidb.Client.PutObject/GetObject/DeleteObject directly; those calls now
+// live in the oxidb driver below, alongside filesystem and S3-compatible
+// drivers selected at startup via Config (see New).
+package blobstore
+
+import "io"
+
+// Backend stores and retrieves blob content addressed by an opaque key.
+// DocumentService keys blobs by the SHA-256 of their content, so Put is
+// only ever called once per distinct key.
+type Backend interface {
+	// Put stores the content read from r under key. Implementations must
+	// read r to completion.
+	Put(key string, r io.Reader, contentType string) error
+	// Get returns a reader for the object stored under key. The caller
+	// must Close it. If the returned reader also implements io.Seeker,
+	// callers can serve Range requests from it directly.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a key that doesn't exist is not
+	// an error.
+	Delete(key string) error
+}
+
+// BucketEnsurer is implemented by backends that need an explicit
+// provisioning step before first use (the OxiDB driver's bucket must exist
+// before PutObject will succeed). Filesystem and S3 backends need no such
+// step and don't implement it.
+type BucketEnsurer interface {
+	EnsureBucket() error
+}
@@ -0,0 +1,41 @@
+package blobstore
+
+import (
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+)
+
+// Config selects and configures a Backend; see config.Config for the
+// DMS_BLOB_BACKEND / DMS_BLOB_FS_DIR / DMS_S3_* env vars it's built from.
+type Config struct {
+	Backend     string // "oxidb" (default), "fs", or "s3"
+	OxiDBBucket string
+	FSDir       string
+	S3          S3Config
+}
+
+// New builds the Backend selected by cfg.Backend. pool is only used by the
+// "oxidb" backend.
+func New(cfg Config, pool *db.Pool) (Backend, error) {
+	switch cfg.Backend {
+	case "", "oxidb":
+		bucket := cfg.OxiDBBucket
+		if bucket == "" {
+			bucket = DefaultOxiDBBucket
+		}
+		return NewOxiDBBackend(pool, bucket), nil
+	case "fs":
+		if cfg.FSDir == "" {
+			return nil, fmt.Errorf("blobstore: fs backend requires DMS_BLOB_FS_DIR")
+		}
+		return NewFSBackend(cfg.FSDir), nil
+	case "s3":
+		if cfg.S3.Endpoint == "" || cfg.S3.Bucket == "" {
+			return nil, fmt.Errorf("blobstore: s3 backend requires DMS_S3_ENDPOINT and DMS_S3_BUCKET")
+		}
+		return NewS3Backend(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("blobstore: unknown backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,57 @@
+package blobstore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FSBackend stores each blob as a file named by its key under Dir. It's
+// meant for single-node deployments; Dir must be on a filesystem every
+// OxiDMS instance reading the blobs can see.
+type FSBackend struct {
+	Dir string
+}
+
+// NewFSBackend builds an FSBackend rooted at dir.
+func NewFSBackend(dir string) *FSBackend {
+	return &FSBackend{Dir: dir}
+}
+
+func (b *FSBackend) path(key string) string {
+	return filepath.Join(b.Dir, key)
+}
+
+func (b *FSBackend) Put(key string, r io.Reader, contentType string) error {
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temp file first and rename into place so a reader that
+	// opens the key mid-upload never sees a partial object.
+	tmp, err := os.CreateTemp(b.Dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path(key))
+}
+
+func (b *FSBackend) Get(key string) (io.ReadCloser, error) {
+	return os.Open(b.path(key))
+}
+
+func (b *FSBackend) Delete(key string) error {
+	err := os.Remove(b.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
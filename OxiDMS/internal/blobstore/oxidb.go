@@ -0,0 +1,64 @@
+package blobstore
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+)
+
+// DefaultOxiDBBucket is the blob bucket OxiDMS has always used; kept as the
+// default so existing deployments that don't set DMS_BLOB_BACKEND keep
+// reading the objects they already wrote.
+const DefaultOxiDBBucket = "dms_files"
+
+// OxiDBBackend stores blobs as objects in an OxiDB blob bucket. PutObject
+// base64-encodes the whole payload into a single JSON command, so Put has
+// to read r into memory first; there's no way to stream through the wire
+// protocol as it exists today.
+type OxiDBBackend struct {
+	pool   *db.Pool
+	bucket string
+}
+
+// NewOxiDBBackend builds an OxiDBBackend writing to bucket over pool.
+func NewOxiDBBackend(pool *db.Pool, bucket string) *OxiDBBackend {
+	return &OxiDBBackend{pool: pool, bucket: bucket}
+}
+
+// EnsureBucket implements BucketEnsurer.
+func (b *OxiDBBackend) EnsureBucket() error {
+	return b.pool.Get().CreateBucket(b.bucket)
+}
+
+func (b *OxiDBBackend) Put(key string, r io.Reader, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = b.pool.Get().PutObject(b.bucket, key, data, contentType, nil)
+	return err
+}
+
+func (b *OxiDBBackend) Get(key string) (io.ReadCloser, error) {
+	data, _, err := b.pool.Get().GetObject(b.bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	// seekableBuffer also implements io.Seeker (io.NopCloser would strip
+	// it), so callers get Range support for free even though the bytes
+	// were fully buffered to get here.
+	return seekableBuffer{bytes.NewReader(data)}, nil
+}
+
+func (b *OxiDBBackend) Delete(key string) error {
+	return b.pool.Get().DeleteObject(b.bucket, key)
+}
+
+// seekableBuffer adapts a *bytes.Reader into an io.ReadCloser that's also
+// an io.Seeker.
+type seekableBuffer struct {
+	*bytes.Reader
+}
+
+func (seekableBuffer) Close() error { return nil }
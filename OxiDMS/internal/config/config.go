@@ -1,26 +1,231 @@
+// Package config loads OxiDMS's runtime configuration: a built-in default,
+// optionally overlaid by a YAML or TOML file (--config/DMS_CONFIG_FILE),
+// optionally overlaid by environment variables — flag > env > file >
+// default, flag only deciding which file env/file values then layer onto.
+// See Validate for the startup checks that refuse to run with an insecure
+// production configuration, and Watcher for SIGHUP-driven reload of the
+// handful of fields that are safe to change without a restart.
 package config
 
-import "os"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Insecure defaults Validate refuses to run with once they matter: an
+// unreachable dev box is fine, anything else isn't.
+const (
+	defaultJWTSecret = "oxidms-dev-secret-change-me"
+	defaultAdminPass = "admin123"
+)
 
 type Config struct {
-	HTTPAddr     string
-	OxiDBHost    string
-	OxiDBPort    int
-	PoolSize     int
-	JWTSecret    string
-	AdminEmail   string
-	AdminPass    string
+	HTTPAddr    string
+	OxiDBHost   string
+	OxiDBPort   int
+	PoolSize    int
+	AdminEmail  string
+	AdminPass   string
+	HandoffDir  string
+	OAuthIssuer string
+
+	// SiteBaseURL is the scheme+host sitemap.xml and robots.txt render
+	// absolute URLs against (e.g. "https://forms.example.com"). Empty
+	// means handler.SitemapHandler falls back to deriving it from the
+	// request's Host header, the same way handler.SearchHandler's
+	// OpenSearch description does when it has no SiteBaseURL to use
+	// either.
+	SiteBaseURL string
+
+	// JWTKeys maps kid -> HS256 secret for auth.KeySet, letting a secret
+	// rotation add a new kid without invalidating tokens signed under the
+	// old one (see auth.ValidateToken). Always carries at least one entry,
+	// defaulting to {JWTActiveKID: defaultJWTSecret} when nothing else sets
+	// it. JWTActiveKID is the kid new tokens are signed with
+	// (auth.GenerateToken).
+	JWTKeys      map[string]string
+	JWTActiveKID string
+
+	// PolicyFile, if set, overrides authz's built-in role->permission
+	// defaults with the rules in this JSON file at startup; see
+	// authz.LoadPolicyFile. Empty means keep the defaults.
+	PolicyFile string
+
+	// Blob storage backend for DocumentService; see blobstore.Config.
+	BlobBackend string
+	BlobFSDir   string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// SearchStemming toggles the light English stemmer in internal/search's
+	// analyzer. Flipping it changes how existing text tokenizes, so it
+	// requires a Reindex (AdminHandler.Reindex) to take full effect.
+	SearchStemming bool
+
+	// Logging and Search group the knobs specific to those subsystems,
+	// rather than flattening them alongside the rest, the same way
+	// gelf.Writer's own options and search.Highlight group theirs.
+	Logging LoggingConfig
+	Search  SearchConfig
+
+	// ConfigFile is the path Load read its file overlay from (--config or
+	// DMS_CONFIG_FILE), or "" if neither was given. Watcher re-reads this
+	// same path on SIGHUP.
+	ConfigFile string
+}
+
+// LoggingConfig configures gelf.Writer and internal/logging.
+type LoggingConfig struct {
+	GelfAddr string
+	// Compression is "gzip", "zlib", or "" (none); see gelf.Compression.
+	Compression string
+	ChunkSize   int
+	// Level filters which log/slog records internal/logging ships: one of
+	// "debug", "info", "warn", "error".
+	Level string
 }
 
-func Load() *Config {
+// SearchConfig configures internal/search and its handler/service layers.
+type SearchConfig struct {
+	// SuggestLimit bounds how many terms SearchHandler.Suggest returns when
+	// the caller doesn't ask for fewer.
+	SuggestLimit int
+	// SnippetSize is the default search.Highlight.FragmentSize applied when
+	// a search request asks for highlighting without specifying one.
+	SnippetSize int
+}
+
+func defaultConfig() *Config {
 	return &Config{
-		HTTPAddr:   getEnv("DMS_ADDR", ":8080"),
-		OxiDBHost:  getEnv("OXIDB_HOST", "127.0.0.1"),
-		OxiDBPort:  getEnvInt("OXIDB_PORT", 4444),
-		PoolSize:   getEnvInt("DMS_POOL_SIZE", 3),
-		JWTSecret:  getEnv("DMS_JWT_SECRET", "oxidms-dev-secret-change-me"),
-		AdminEmail: getEnv("DMS_ADMIN_EMAIL", "admin@oxidms.local"),
-		AdminPass:  getEnv("DMS_ADMIN_PASS", "admin123"),
+		HTTPAddr:     ":8080",
+		OxiDBHost:    "127.0.0.1",
+		OxiDBPort:    4444,
+		PoolSize:     3,
+		AdminEmail:   "admin@oxidms.local",
+		AdminPass:    defaultAdminPass,
+		OAuthIssuer:  "http://localhost:8080",
+		JWTActiveKID: "default",
+		JWTKeys:      map[string]string{},
+		S3Region:     "us-east-1",
+		BlobBackend:  "oxidb",
+
+		SearchStemming: true,
+
+		Logging: LoggingConfig{ChunkSize: 8192},
+		Search:  SearchConfig{SuggestLimit: 10, SnippetSize: 200},
+	}
+}
+
+// Load builds a Config: defaults, overlaid by --config's file (YAML or
+// TOML, chosen by extension) if one was given, overlaid by environment
+// variables. A malformed config file is a hard error; a missing
+// --config/DMS_CONFIG_FILE is not — it just leaves the file layer empty.
+func Load() (*Config, error) {
+	configFile := flag.String("config", getEnv("DMS_CONFIG_FILE", ""), "path to a YAML or TOML config file overlaying the built-in defaults")
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	cfg := defaultConfig()
+	cfg.ConfigFile = *configFile
+	if cfg.ConfigFile != "" {
+		if err := loadFile(cfg.ConfigFile, cfg); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	}
+	applyEnv(cfg)
+	return cfg, nil
+}
+
+// applyEnv overlays environment variables onto cfg, each falling back to
+// cfg's current value (already either a default or a file-loaded value) so
+// env only overrides what it actually sets.
+func applyEnv(cfg *Config) {
+	cfg.HTTPAddr = getEnv("DMS_ADDR", cfg.HTTPAddr)
+	cfg.OxiDBHost = getEnv("OXIDB_HOST", cfg.OxiDBHost)
+	cfg.OxiDBPort = getEnvInt("OXIDB_PORT", cfg.OxiDBPort)
+	cfg.PoolSize = getEnvInt("DMS_POOL_SIZE", cfg.PoolSize)
+	cfg.AdminEmail = getEnv("DMS_ADMIN_EMAIL", cfg.AdminEmail)
+	cfg.AdminPass = getEnv("DMS_ADMIN_PASS", cfg.AdminPass)
+	cfg.HandoffDir = getEnv("DMS_HANDOFF_DIR", cfg.HandoffDir)
+	cfg.OAuthIssuer = getEnv("DMS_OAUTH_ISSUER", cfg.OAuthIssuer)
+	cfg.PolicyFile = getEnv("DMS_POLICY_FILE", cfg.PolicyFile)
+	cfg.SiteBaseURL = getEnv("DMS_SITE_BASE_URL", cfg.SiteBaseURL)
+
+	cfg.BlobBackend = getEnv("DMS_BLOB_BACKEND", cfg.BlobBackend)
+	cfg.BlobFSDir = getEnv("DMS_BLOB_FS_DIR", cfg.BlobFSDir)
+	cfg.S3Endpoint = getEnv("DMS_S3_ENDPOINT", cfg.S3Endpoint)
+	cfg.S3Bucket = getEnv("DMS_S3_BUCKET", cfg.S3Bucket)
+	cfg.S3Region = getEnv("DMS_S3_REGION", cfg.S3Region)
+	cfg.S3AccessKey = getEnv("DMS_S3_ACCESS_KEY", cfg.S3AccessKey)
+	cfg.S3SecretKey = getEnv("DMS_S3_SECRET_KEY", cfg.S3SecretKey)
+
+	cfg.SearchStemming = getEnvBool("DMS_SEARCH_STEMMING", cfg.SearchStemming)
+	cfg.Search.SuggestLimit = getEnvInt("DMS_SEARCH_SUGGEST_LIMIT", cfg.Search.SuggestLimit)
+	cfg.Search.SnippetSize = getEnvInt("DMS_SEARCH_SNIPPET_SIZE", cfg.Search.SnippetSize)
+
+	cfg.Logging.GelfAddr = getEnv("DMS_GELF_ADDR", cfg.Logging.GelfAddr)
+	cfg.Logging.Compression = getEnv("DMS_GELF_COMPRESSION", cfg.Logging.Compression)
+	cfg.Logging.ChunkSize = getEnvInt("DMS_GELF_CHUNK_SIZE", cfg.Logging.ChunkSize)
+	cfg.Logging.Level = getEnv("DMS_LOG_LEVEL", cfg.Logging.Level)
+
+	jwtActiveKID := getEnv("DMS_JWT_KID", cfg.JWTActiveKID)
+	if envKeys := getEnvKeyMap("DMS_JWT_KEYS"); len(envKeys) > 0 {
+		cfg.JWTKeys = envKeys
+	}
+	if cfg.JWTKeys == nil {
+		cfg.JWTKeys = map[string]string{}
+	}
+	if secret := os.Getenv("DMS_JWT_SECRET"); secret != "" {
+		cfg.JWTKeys[jwtActiveKID] = secret
+	}
+	if _, ok := cfg.JWTKeys[jwtActiveKID]; !ok {
+		cfg.JWTKeys[jwtActiveKID] = defaultJWTSecret
+	}
+	cfg.JWTActiveKID = jwtActiveKID
+}
+
+// Validate refuses an insecure production configuration: the dev JWT
+// secret on anything but loopback, the dev admin password unconditionally
+// (there's no deployment where shipping it is fine), and a pool size that
+// can never hand out a connection. Every failing check is reported at
+// once via errors.Join, rather than stopping at the first, so an operator
+// fixing the config doesn't have to restart the server once per mistake.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.JWTKeys[c.JWTActiveKID] == defaultJWTSecret && !isLoopback(c.HTTPAddr) {
+		errs = append(errs, fmt.Errorf("config: JWT secret is the insecure default but %s is not bound to loopback", c.HTTPAddr))
+	}
+	if c.AdminPass == defaultAdminPass {
+		errs = append(errs, errors.New("config: admin password is the insecure default; set DMS_ADMIN_PASS or the config file's admin_pass"))
+	}
+	if c.PoolSize < 1 {
+		errs = append(errs, fmt.Errorf("config: pool size must be at least 1, got %d", c.PoolSize))
+	}
+	return errors.Join(errs...)
+}
+
+// isLoopback reports whether addr (an HTTPAddr like ":8080" or
+// "127.0.0.1:8080") binds only to the local machine. An empty host (the
+// ":8080" form) binds every interface, so it isn't loopback.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	switch host {
+	case "127.0.0.1", "::1", "localhost":
+		return true
+	default:
+		return false
 	}
 }
 
@@ -36,12 +241,36 @@ func getEnvInt(key string, fallback int) int {
 	if v == "" {
 		return fallback
 	}
-	n := 0
-	for _, c := range v {
-		if c < '0' || c > '9' {
-			return fallback
-		}
-		n = n*10 + int(c-'0')
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
 	}
 	return n
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	return v == "1" || v == "true" || v == "TRUE"
+}
+
+// getEnvKeyMap parses a comma-separated "kid=secret,kid=secret" list (e.g.
+// DMS_JWT_KEYS) into a map, skipping any entry missing its "=" or with an
+// empty kid or secret. Returns an empty, non-nil map if key is unset.
+func getEnvKeyMap(key string) map[string]string {
+	m := map[string]string{}
+	v := os.Getenv(key)
+	if v == "" {
+		return m
+	}
+	for _, pair := range strings.Split(v, ",") {
+		kid, secret, ok := strings.Cut(pair, "=")
+		if !ok || kid == "" || secret == "" {
+			continue
+		}
+		m[kid] = secret
+	}
+	return m
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig mirrors Config's field names in snake_case for YAML/TOML
+// decoding, rather than tagging Config itself, so the on-disk schema can
+// diverge from Go field names (e.g. grouping gelf_addr/compression
+// under "logging") without constraining the struct applyEnv and the rest
+// of the package already use.
+type fileConfig struct {
+	HTTPAddr    string `yaml:"http_addr" toml:"http_addr"`
+	OxiDBHost   string `yaml:"oxidb_host" toml:"oxidb_host"`
+	OxiDBPort   int    `yaml:"oxidb_port" toml:"oxidb_port"`
+	PoolSize    int    `yaml:"pool_size" toml:"pool_size"`
+	AdminEmail  string `yaml:"admin_email" toml:"admin_email"`
+	AdminPass   string `yaml:"admin_pass" toml:"admin_pass"`
+	HandoffDir  string `yaml:"handoff_dir" toml:"handoff_dir"`
+	OAuthIssuer string `yaml:"oauth_issuer" toml:"oauth_issuer"`
+	PolicyFile  string `yaml:"policy_file" toml:"policy_file"`
+	SiteBaseURL string `yaml:"site_base_url" toml:"site_base_url"`
+
+	JWTActiveKID string            `yaml:"jwt_kid" toml:"jwt_kid"`
+	JWTKeys      map[string]string `yaml:"jwt_keys" toml:"jwt_keys"`
+
+	BlobBackend string `yaml:"blob_backend" toml:"blob_backend"`
+	BlobFSDir   string `yaml:"blob_fs_dir" toml:"blob_fs_dir"`
+	S3Endpoint  string `yaml:"s3_endpoint" toml:"s3_endpoint"`
+	S3Bucket    string `yaml:"s3_bucket" toml:"s3_bucket"`
+	S3Region    string `yaml:"s3_region" toml:"s3_region"`
+	S3AccessKey string `yaml:"s3_access_key" toml:"s3_access_key"`
+	S3SecretKey string `yaml:"s3_secret_key" toml:"s3_secret_key"`
+
+	SearchStemming bool `yaml:"search_stemming" toml:"search_stemming"`
+
+	Logging struct {
+		GelfAddr    string `yaml:"gelf_addr" toml:"gelf_addr"`
+		Compression string `yaml:"compression" toml:"compression"`
+		ChunkSize   int    `yaml:"chunk_size" toml:"chunk_size"`
+		Level       string `yaml:"level" toml:"level"`
+	} `yaml:"logging" toml:"logging"`
+
+	Search struct {
+		SuggestLimit int `yaml:"suggest_limit" toml:"suggest_limit"`
+		SnippetSize  int `yaml:"snippet_size" toml:"snippet_size"`
+	} `yaml:"search" toml:"search"`
+}
+
+// loadFile decodes path (YAML for .yaml/.yml, TOML for .toml) and overlays
+// every field it sets onto cfg. A field absent from the file (the zero
+// value in fc) leaves cfg's existing default untouched, so a file only
+// needs to mention what it's overriding.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var fc fileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &fc); err != nil {
+			return fmt.Errorf("parse %s as YAML: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(data), &fc); err != nil {
+			return fmt.Errorf("parse %s as TOML: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unrecognized config file extension %q (want .yaml, .yml, or .toml)", ext)
+	}
+
+	applyFileConfig(&fc, cfg)
+	return nil
+}
+
+func applyFileConfig(fc *fileConfig, cfg *Config) {
+	overlayString(&cfg.HTTPAddr, fc.HTTPAddr)
+	overlayString(&cfg.OxiDBHost, fc.OxiDBHost)
+	overlayInt(&cfg.OxiDBPort, fc.OxiDBPort)
+	overlayInt(&cfg.PoolSize, fc.PoolSize)
+	overlayString(&cfg.AdminEmail, fc.AdminEmail)
+	overlayString(&cfg.AdminPass, fc.AdminPass)
+	overlayString(&cfg.HandoffDir, fc.HandoffDir)
+	overlayString(&cfg.OAuthIssuer, fc.OAuthIssuer)
+	overlayString(&cfg.PolicyFile, fc.PolicyFile)
+	overlayString(&cfg.SiteBaseURL, fc.SiteBaseURL)
+
+	overlayString(&cfg.JWTActiveKID, fc.JWTActiveKID)
+	for kid, secret := range fc.JWTKeys {
+		cfg.JWTKeys[kid] = secret
+	}
+
+	overlayString(&cfg.BlobBackend, fc.BlobBackend)
+	overlayString(&cfg.BlobFSDir, fc.BlobFSDir)
+	overlayString(&cfg.S3Endpoint, fc.S3Endpoint)
+	overlayString(&cfg.S3Bucket, fc.S3Bucket)
+	overlayString(&cfg.S3Region, fc.S3Region)
+	overlayString(&cfg.S3AccessKey, fc.S3AccessKey)
+	overlayString(&cfg.S3SecretKey, fc.S3SecretKey)
+
+	if fc.SearchStemming {
+		cfg.SearchStemming = true
+	}
+
+	overlayString(&cfg.Logging.GelfAddr, fc.Logging.GelfAddr)
+	overlayString(&cfg.Logging.Compression, fc.Logging.Compression)
+	overlayInt(&cfg.Logging.ChunkSize, fc.Logging.ChunkSize)
+	overlayString(&cfg.Logging.Level, fc.Logging.Level)
+
+	overlayInt(&cfg.Search.SuggestLimit, fc.Search.SuggestLimit)
+	overlayInt(&cfg.Search.SnippetSize, fc.Search.SnippetSize)
+}
+
+func overlayString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func overlayInt(dst *int, v int) {
+	if v != 0 {
+		*dst = v
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reloadableFields are the values safe to change without restarting the
+// process: nothing that would orphan an open connection, invalidate an
+// issued token, or change how data already on disk is interpreted. Of
+// these, only Logging.Level and Search.SuggestLimit currently have a live
+// consumer (see Watcher.Changes and Watcher.SuggestLimit); the rest of
+// LoggingConfig is re-read here so it still shows up in the reload log
+// line, but nothing redials the GELF writer on it yet, so changing
+// GelfAddr/Compression/ChunkSize still needs a restart to take effect.
+// Pool size, JWT keys, the oxidb connection target, and search stemming
+// are deliberately excluded — see Watcher.reload.
+type reloadableFields struct {
+	Logging LoggingConfig
+	Search  SearchConfig
+}
+
+// Watcher re-reads Config.ConfigFile on SIGHUP and publishes the fields in
+// reloadableFields on Changes for callers to pick up, without restarting
+// the process. Callers that want live log-level changes should range over
+// Changes in a background goroutine and apply LoggingConfig.Level to a
+// shared *slog.LevelVar; callers that want a live Search.SuggestLimit
+// should call SuggestLimit instead of capturing it at construction.
+// reload writes the re-read Logging and Search sections back into the
+// *Config the Watcher was built from, under the Watcher's own lock; every
+// other field of that Config is left as it was.
+type Watcher struct {
+	mu      sync.Mutex
+	cfg     *Config
+	Changes chan LoggingConfig
+}
+
+// NewWatcher starts listening for SIGHUP and returns a Watcher that
+// reloads cfg.ConfigFile on each one. If cfg.ConfigFile is empty there is
+// nothing to reload from, so the watcher is a no-op that never sends on
+// Changes.
+func NewWatcher(cfg *Config) *Watcher {
+	w := &Watcher{cfg: cfg, Changes: make(chan LoggingConfig, 1)}
+	if cfg.ConfigFile == "" {
+		return w
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			w.reload()
+		}
+	}()
+	return w
+}
+
+// reload re-reads cfg.ConfigFile and swaps in its Logging and Search
+// sections only; everything else (pool size, oxidb host, JWT keys, blob
+// backend, search stemming) requires a restart, since changing them live
+// would mean re-dialing connections or re-tokenizing an index mid-query.
+func (w *Watcher) reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fresh := defaultConfig()
+	if err := loadFile(w.cfg.ConfigFile, fresh); err != nil {
+		log.Printf("config: SIGHUP reload of %s failed, keeping current config: %v", w.cfg.ConfigFile, err)
+		return
+	}
+	applyEnv(fresh)
+
+	w.cfg.Logging = fresh.Logging
+	w.cfg.Search = fresh.Search
+	log.Printf("config: reloaded logging/search settings from %s", w.cfg.ConfigFile)
+
+	select {
+	case w.Changes <- w.cfg.Logging:
+	default:
+	}
+}
+
+// SuggestLimit returns the current Search.SuggestLimit, safe to call
+// concurrently with reload. Callers like SearchHandler that want a SIGHUP
+// reload to take effect without a restart should call this per request
+// instead of capturing cfg.Search.SuggestLimit once at construction.
+func (w *Watcher) SuggestLimit() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cfg.Search.SuggestLimit
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// breakerState is the circuit-breaker state of a single pooled client.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// PoolConfig tunes the per-client circuit breaker that backs Pool.Get.
+type PoolConfig struct {
+	// FailThreshold is the number of consecutive failures on a closed
+	// client before its breaker opens.
+	FailThreshold int
+	// OpenDuration is how long a breaker stays open before keepalive's
+	// probe is allowed to test it again.
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of consecutive successful calls a
+	// half-open client needs before its breaker fully closes.
+	HalfOpenProbes int
+}
+
+// DefaultPoolConfig returns the breaker thresholds used when NewPool is
+// called with a nil *PoolConfig.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		FailThreshold:  3,
+		OpenDuration:   10 * time.Second,
+		HalfOpenProbes: 2,
+	}
+}
+
+// clientHealth is the circuit-breaker and latency state tracked per pooled
+// client. It is guarded by the Pool's mu[i] for that client's index.
+type clientHealth struct {
+	state       breakerState
+	consecFails int
+	ewmaMillis  float64
+	openedAt    time.Time
+	halfOpenOK  int
+}
+
+// ewmaAlpha weights the most recent sample against the running EWMA.
+const ewmaAlpha = 0.2
+
+// wrap calls fn with the client at index i, timing the call and updating
+// that client's breaker state and latency EWMA based on the outcome. Every
+// RPC made through the pool should go through wrap so the breaker sees a
+// representative picture of each client's health.
+func (p *Pool) wrap(i int, fn func(*oxidb.Client) error) error {
+	p.mu[i].Lock()
+	c := p.clients[i]
+	p.mu[i].Unlock()
+
+	start := time.Now()
+	err := fn(c)
+	elapsedMillis := float64(time.Since(start)) / float64(time.Millisecond)
+
+	p.mu[i].Lock()
+	defer p.mu[i].Unlock()
+	h := &p.health[i]
+
+	if h.ewmaMillis == 0 {
+		h.ewmaMillis = elapsedMillis
+	} else {
+		h.ewmaMillis = ewmaAlpha*elapsedMillis + (1-ewmaAlpha)*h.ewmaMillis
+	}
+
+	if err != nil {
+		h.consecFails++
+		switch h.state {
+		case breakerHalfOpen:
+			h.state = breakerOpen
+			h.openedAt = time.Now()
+			h.halfOpenOK = 0
+		case breakerClosed:
+			if h.consecFails >= p.cfg.FailThreshold {
+				h.state = breakerOpen
+				h.openedAt = time.Now()
+			}
+		}
+		return err
+	}
+
+	h.consecFails = 0
+	switch h.state {
+	case breakerOpen:
+		h.state = breakerHalfOpen
+		h.halfOpenOK = 1
+	case breakerHalfOpen:
+		h.halfOpenOK++
+		if h.halfOpenOK >= p.cfg.HalfOpenProbes {
+			h.state = breakerClosed
+			h.halfOpenOK = 0
+		}
+	}
+	return nil
+}
+
+// pick chooses the index of a healthy client to hand out, skipping any with
+// an open breaker and preferring the lowest-EWMA-latency client among the
+// rest, jittered across the best few to avoid every caller piling onto a
+// single "fastest" client.
+func (p *Pool) pick() int {
+	type candidate struct {
+		idx  int
+		ewma float64
+	}
+	candidates := make([]candidate, 0, len(p.clients))
+	for i := range p.clients {
+		p.mu[i].Lock()
+		state := p.health[i].state
+		ewma := p.health[i].ewmaMillis
+		p.mu[i].Unlock()
+		if state == breakerOpen {
+			continue
+		}
+		candidates = append(candidates, candidate{idx: i, ewma: ewma})
+	}
+
+	if len(candidates) == 0 {
+		// Every breaker is open; fall back to round robin so the pool
+		// still makes forward progress instead of wedging entirely.
+		n := atomic.AddUint64(&p.idx, 1)
+		return int(n % uint64(len(p.clients)))
+	}
+
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].ewma < candidates[b].ewma })
+	top := candidates
+	if len(top) > 3 {
+		top = top[:3]
+	}
+	return top[rand.Intn(len(top))].idx
+}
+
+// ClientHealth is a snapshot of one pooled client's breaker and latency
+// state, returned by Pool.Inspect for tests and metrics.
+type ClientHealth struct {
+	Index       int
+	State       string
+	ConsecFails int
+	EWMALatency time.Duration
+}
+
+// Inspect returns a snapshot of every pooled client's circuit-breaker and
+// latency state.
+func (p *Pool) Inspect() []ClientHealth {
+	out := make([]ClientHealth, len(p.clients))
+	for i := range p.clients {
+		p.mu[i].Lock()
+		h := p.health[i]
+		p.mu[i].Unlock()
+		out[i] = ClientHealth{
+			Index:       i,
+			State:       h.state.String(),
+			ConsecFails: h.consecFails,
+			EWMALatency: time.Duration(h.ewmaMillis * float64(time.Millisecond)),
+		}
+	}
+	return out
+}
+
+// HealthyCount returns how many pooled clients currently have a closed (or
+// half-open, i.e. recovering) breaker and are therefore eligible for
+// Pool.Get to hand out. Meant for a gauge like
+// oxidms_oxidb_pool_in_use, where "in use" means "in rotation" rather
+// than "checked out" — Pool has no checkout/return concept, every client
+// stays permanently assigned to its index.
+func (p *Pool) HealthyCount() int64 {
+	var n int64
+	for i := range p.clients {
+		p.mu[i].Lock()
+		state := p.health[i].state
+		p.mu[i].Unlock()
+		if state != breakerOpen {
+			n++
+		}
+	}
+	return n
+}
@@ -1,32 +1,47 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
-// Pool is a round-robin connection pool for OxiDB with auto-reconnect.
+// Pool is a connection pool for OxiDB with auto-reconnect and a per-client
+// circuit breaker. Get hands out the lowest-latency healthy client instead
+// of plain round robin; see breaker.go.
 type Pool struct {
 	host    string
 	port    int
 	clients []*oxidb.Client
 	mu      []sync.Mutex
+	health  []clientHealth
+	cfg     PoolConfig
 	idx     uint64
 	stop    chan struct{}
+
+	handoff *handoffQueue
 }
 
-// NewPool creates a pool of n OxiDB connections.
-func NewPool(host string, port, size int) (*Pool, error) {
+// NewPool creates a pool of n OxiDB connections. handoffDir is the directory
+// used to durably buffer writes made via EnqueueWrite while the backend is
+// unreachable (see handoff.go); pass "" to use the OS temp directory. cfg
+// tunes the per-client circuit breaker; pass nil to use DefaultPoolConfig.
+func NewPool(host string, port, size int, handoffDir string, cfg *PoolConfig) (*Pool, error) {
+	resolvedCfg := DefaultPoolConfig()
+	if cfg != nil {
+		resolvedCfg = *cfg
+	}
 	p := &Pool{
 		host:    host,
 		port:    port,
 		clients: make([]*oxidb.Client, size),
 		mu:      make([]sync.Mutex, size),
+		health:  make([]clientHealth, size),
+		cfg:     resolvedCfg,
 		stop:    make(chan struct{}),
 	}
 	for i := 0; i < size; i++ {
@@ -37,19 +52,54 @@ func NewPool(host string, port, size int) (*Pool, error) {
 		}
 		p.clients[i] = c
 	}
+
+	hq, err := newHandoffQueue(handoffDir, host, port)
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("pool: init handoff queue: %w", err)
+	}
+	p.handoff = hq
+
 	// Start keepalive pings every 10 seconds to prevent idle timeout
 	go p.keepalive()
+	// Start the hinted-handoff drainer alongside keepalive
+	go p.drainHandoff()
 	return p, nil
 }
 
-// Get returns the next client in round-robin order, reconnecting if needed.
+// Get returns a healthy client, preferring the lowest-latency one and
+// skipping any whose circuit breaker is open (see breaker.go).
 func (p *Pool) Get() *oxidb.Client {
-	n := atomic.AddUint64(&p.idx, 1)
-	i := n % uint64(len(p.clients))
-	return p.clients[i]
+	i := p.pick()
+	p.mu[i].Lock()
+	c := p.clients[i]
+	p.mu[i].Unlock()
+	return c
 }
 
-// Reconnect replaces a broken client at index i.
+// GetContext is Get for call sites that want to enforce ctx on the calls
+// they make with the returned client: pass this same ctx to the Context
+// variant of whichever oxidb.Client methods are used (InsertContext,
+// FindContext, AggregateContext, ...) to get per-operation deadlines and
+// cancellation. ctx itself isn't consulted by client selection — a pooled
+// client's pick doesn't block — it's threaded through purely so DMS
+// service-layer code has one place (the Pool call) to reach for ctx
+// instead of plumbing it separately.
+func (p *Pool) GetContext(ctx context.Context) *oxidb.Client {
+	return p.Get()
+}
+
+// Do runs fn against a pooled client, the same client Get would hand out,
+// routed through wrap so the call's latency and success/failure update that
+// client's breaker and EWMA. Repository code that issues one or more oxidb
+// calls per method should go through Do rather than Get so the breaker sees
+// real traffic instead of only keepalive's synthetic pings.
+func (p *Pool) Do(fn func(*oxidb.Client) error) error {
+	return p.wrap(p.pick(), fn)
+}
+
+// Reconnect replaces a broken client at index i and resets its breaker
+// state, since the new connection hasn't earned any failures yet.
 func (p *Pool) reconnect(i int) {
 	p.mu[i].Lock()
 	defer p.mu[i].Unlock()
@@ -62,8 +112,12 @@ func (p *Pool) reconnect(i int) {
 		return
 	}
 	p.clients[i] = c
+	p.health[i] = clientHealth{}
 }
 
+// keepalive pings every client on a fixed tick to prevent idle timeout, and
+// doubles as the circuit breaker's probe: a ping routed through wrap is what
+// transitions an open breaker to half-open once OpenDuration has passed.
 func (p *Pool) keepalive() {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
@@ -73,7 +127,18 @@ func (p *Pool) keepalive() {
 			return
 		case <-ticker.C:
 			for i := range p.clients {
-				if _, err := p.clients[i].Ping(); err != nil {
+				p.mu[i].Lock()
+				state := p.health[i].state
+				openedAt := p.health[i].openedAt
+				p.mu[i].Unlock()
+				if state == breakerOpen && time.Since(openedAt) < p.cfg.OpenDuration {
+					continue
+				}
+
+				if err := p.wrap(i, func(c *oxidb.Client) error {
+					_, err := c.Ping()
+					return err
+				}); err != nil {
 					log.Printf("pool: client %d ping failed, reconnecting: %v", i, err)
 					p.reconnect(i)
 				}
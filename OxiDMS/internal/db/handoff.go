@@ -0,0 +1,365 @@
+package db
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// WriteOpKind identifies the kind of mutating call a WriteOp replays.
+type WriteOpKind string
+
+const (
+	WriteOpInsert     WriteOpKind = "insert"
+	WriteOpInsertMany WriteOpKind = "insert_many"
+	WriteOpUpdate     WriteOpKind = "update"
+	WriteOpDelete     WriteOpKind = "delete"
+	WriteOpPutBlob    WriteOpKind = "put_blob"
+)
+
+// WriteOp describes a single write to replay against OxiDB, either
+// immediately via EnqueueWrite or later by the hinted-handoff drainer.
+type WriteOp struct {
+	Kind       WriteOpKind      `json:"kind"`
+	Collection string           `json:"collection,omitempty"`
+	Doc        map[string]any   `json:"doc,omitempty"`
+	Docs       []map[string]any `json:"docs,omitempty"`
+	Query      map[string]any   `json:"query,omitempty"`
+	Update     map[string]any   `json:"update,omitempty"`
+
+	Bucket      string `json:"bucket,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// segmentRecord is the on-disk, length-prefixed JSON record appended to a
+// handoff segment file: [4-byte little-endian length][JSON payload].
+type segmentRecord struct {
+	Op         WriteOp   `json:"op"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	Retries    int       `json:"retries"`
+}
+
+const (
+	// maxHandoffBytes caps the total size of queued records so a downed
+	// backend can't grow the segment file without bound.
+	maxHandoffBytes = 64 << 20 // 64MB
+	// maxHandoffRetries is how many times the drainer retries the head of
+	// the queue before dropping it as a poison message.
+	maxHandoffRetries = 10
+)
+
+// handoffQueue durably buffers WriteOps under a segment file on disk so
+// writes survive a process restart while the backend is unreachable.
+type handoffQueue struct {
+	path string
+
+	mu      sync.Mutex
+	records []segmentRecord
+	bytes   int64
+
+	lastDrainErr error
+	lastDrainAt  time.Time
+}
+
+func newHandoffQueue(dir, host string, port int) (*handoffQueue, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "oxidms-handoff")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	safeHost := strings.NewReplacer(":", "_", "/", "_").Replace(host)
+	path := filepath.Join(dir, fmt.Sprintf("%s_%d.segment", safeHost, port))
+
+	q := &handoffQueue{path: path}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load recovers queued records from the segment file on startup.
+func (q *handoffQueue) load() error {
+	f, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		rec, n, err := readSegmentRecord(f)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A torn trailing write from a crash; stop reading, keep what
+			// was recovered so far.
+			log.Printf("pool: handoff segment %s: truncated record, stopping recovery: %v", q.path, err)
+			break
+		}
+		q.records = append(q.records, rec)
+		q.bytes += n
+	}
+	return nil
+}
+
+func readSegmentRecord(r io.Reader) (segmentRecord, int64, error) {
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(r, lenBuf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return segmentRecord{}, 0, err
+	}
+	length := binary.LittleEndian.Uint32(lenBuf)
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return segmentRecord{}, 0, io.ErrUnexpectedEOF
+	}
+	var rec segmentRecord
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return segmentRecord{}, 0, err
+	}
+	return rec, int64(4 + length), nil
+}
+
+// append adds op to the tail of the queue and persists it to the segment
+// file. It fails once the queue exceeds maxHandoffBytes so a dead backend
+// can't grow the segment file without bound.
+func (q *handoffQueue) append(op WriteOp) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	rec := segmentRecord{Op: op, EnqueuedAt: time.Now().UTC()}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("handoff: marshal record: %w", err)
+	}
+	if q.bytes+int64(len(payload)) > maxHandoffBytes {
+		return fmt.Errorf("handoff: queue full (%d bytes queued)", q.bytes)
+	}
+
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("handoff: open segment: %w", err)
+	}
+	defer f.Close()
+
+	lenBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenBuf, uint32(len(payload)))
+	if _, err := f.Write(lenBuf); err != nil {
+		return fmt.Errorf("handoff: write segment: %w", err)
+	}
+	if _, err := f.Write(payload); err != nil {
+		return fmt.Errorf("handoff: write segment: %w", err)
+	}
+
+	q.records = append(q.records, rec)
+	q.bytes += int64(4 + len(payload))
+	return nil
+}
+
+// rewrite persists the current in-memory queue, replacing the segment file.
+// Called by the drainer after successfully replaying or dropping records.
+func (q *handoffQueue) rewrite() error {
+	tmp := q.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	var bytes int64
+	for _, rec := range q.records {
+		payload, err := json.Marshal(rec)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		lenBuf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(lenBuf, uint32(len(payload)))
+		if _, err := f.Write(lenBuf); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(payload); err != nil {
+			f.Close()
+			return err
+		}
+		bytes += int64(4 + len(payload))
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return err
+	}
+	q.bytes = bytes
+	return nil
+}
+
+// PoolStats reports the state of the hinted-handoff queue for a /healthz
+// endpoint or similar.
+type PoolStats struct {
+	QueuedWrites   int
+	QueuedBytes    int64
+	OldestEntryAge time.Duration
+	LastDrainErr   error
+	LastDrainAt    time.Time
+}
+
+// Stats returns the current hinted-handoff queue state.
+func (p *Pool) Stats() PoolStats {
+	q := p.handoff
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := PoolStats{
+		QueuedWrites: len(q.records),
+		QueuedBytes:  q.bytes,
+		LastDrainErr: q.lastDrainErr,
+		LastDrainAt:  q.lastDrainAt,
+	}
+	if len(q.records) > 0 {
+		stats.OldestEntryAge = time.Since(q.records[0].EnqueuedAt)
+	}
+	return stats
+}
+
+// EnqueueWrite tries op against a live pooled client immediately. If the
+// backend is unreachable, op is durably appended to the on-disk
+// hinted-handoff segment instead of failing outright, and the background
+// drainer replays it once the backend recovers.
+func (p *Pool) EnqueueWrite(op WriteOp) error {
+	if err := execWriteOp(p.Get(), op); err == nil {
+		return nil
+	} else if !isConnError(err) {
+		return err
+	}
+	return p.handoff.append(op)
+}
+
+func execWriteOp(c *oxidb.Client, op WriteOp) error {
+	switch op.Kind {
+	case WriteOpInsert:
+		_, err := c.Insert(op.Collection, op.Doc)
+		return err
+	case WriteOpInsertMany:
+		_, err := c.InsertMany(op.Collection, op.Docs)
+		return err
+	case WriteOpUpdate:
+		_, err := c.Update(op.Collection, op.Query, op.Update)
+		return err
+	case WriteOpDelete:
+		_, err := c.Delete(op.Collection, op.Query)
+		return err
+	case WriteOpPutBlob:
+		_, err := c.PutObject(op.Bucket, op.Key, op.Data, op.ContentType, nil)
+		return err
+	default:
+		return fmt.Errorf("handoff: unknown write op kind %q", op.Kind)
+	}
+}
+
+// isConnError reports whether err looks like a connect/RPC-level failure
+// (as opposed to e.g. a validation error returned by the backend), which is
+// the class of error that should be handed off rather than surfaced.
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connect") ||
+		strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "eof")
+}
+
+// drainHandoff scans the segment queue in FIFO order and replays ops once a
+// Ping succeeds, backing off exponentially between unsuccessful attempts.
+func (p *Pool) drainHandoff() {
+	backoff := time.Second
+	const maxBackoff = 30 * time.Second
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-timer.C:
+		}
+
+		if err := p.drainOnce(); err != nil {
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		} else {
+			backoff = time.Second
+		}
+		timer.Reset(backoff)
+	}
+}
+
+// drainOnce replays queued writes while the backend is reachable. It stops
+// at the first write that still fails so order is preserved, dropping a
+// record only after it has exceeded maxHandoffRetries (a poison message).
+func (p *Pool) drainOnce() error {
+	q := p.handoff
+
+	q.mu.Lock()
+	if len(q.records) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	q.mu.Unlock()
+
+	c := p.Get()
+	if _, err := c.Ping(); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.records) > 0 {
+		rec := q.records[0]
+		if err := execWriteOp(c, rec.Op); err != nil {
+			rec.Retries++
+			if rec.Retries > maxHandoffRetries {
+				log.Printf("pool: dropping poison handoff record (kind=%s collection=%s) after %d retries: %v",
+					rec.Op.Kind, rec.Op.Collection, rec.Retries, err)
+				q.records = q.records[1:]
+				continue
+			}
+			q.records[0] = rec
+			q.lastDrainErr = err
+			q.lastDrainAt = time.Now().UTC()
+			_ = q.rewrite()
+			return err
+		}
+		q.records = q.records[1:]
+	}
+	q.lastDrainErr = nil
+	q.lastDrainAt = time.Now().UTC()
+	return q.rewrite()
+}
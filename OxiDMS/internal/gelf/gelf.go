@@ -1,33 +1,179 @@
+// Package gelf implements a minimal GELF (Graylog Extended Log Format)
+// client: Writer is a flat io.Writer suitable for log.SetOutput, so the
+// standard log package's existing call sites throughout this codebase ship
+// to Graylog without being rewritten. Logger (see logger.go) sits on top
+// of Writer for call sites that want structured additional fields instead.
 package gelf
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Writer sends GELF messages over UDP and implements io.Writer
-// so it can be used with log.SetOutput via io.MultiWriter.
-type Writer struct {
-	conn     net.Conn
-	hostname string
+// Compression selects how an oversized UDP payload is compressed before
+// chunking. Ignored for TCP and TCP+TLS transports: GELF TCP inputs expect
+// uncompressed, null-terminated JSON, not a compressed chunked stream.
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Zlib
+)
+
+// Transport selects the protocol New dials addr with.
+type Transport int
+
+const (
+	// UDP is the default: one datagram per message, chunked per the GELF
+	// v1.1 spec if it's too big for one.
+	UDP Transport = iota
+	// TCP frames each message with a trailing 0x00 null byte, per the GELF
+	// TCP spec, and reconnects with backoff on a write error instead of
+	// failing every subsequent Write against a dead socket.
+	TCP
+	// TCPTLS is TCP over a TLS connection, for Graylog inputs that require it.
+	TCPTLS
+)
+
+const (
+	// defaultChunkSize is the UDP chunk payload size (excluding the
+	// 12-byte chunk header). It is larger than a 1500-byte Ethernet MTU,
+	// so a full chunk is fragmented at the IP layer; WithChunkSize can
+	// set a smaller size.
+	defaultChunkSize = 8192
+	// maxChunkSize caps WithChunkSize for jumbo-frame networks; Graylog's
+	// own clients use the same ~65k ceiling to stay under UDP's 65535-byte
+	// payload limit.
+	maxChunkSize = 65000
+	// maxChunks is the GELF spec's hard limit: the receiver drops a
+	// message split into more chunks than this, so one that would exceed
+	// it is truncated to fit instead of silently vanishing.
+	maxChunks = 128
+
+	reconnectInitialDelay = 500 * time.Millisecond
+	reconnectMaxDelay     = 30 * time.Second
+)
+
+// gelfMagic identifies a chunked GELF UDP datagram, per the GELF 1.1 spec.
+var gelfMagic = [2]byte{0x1e, 0x0f}
+
+// LevelMapper maps a Write call's rendered message to a GELF syslog
+// severity (lower is more severe), overriding Writer's default
+// "PANIC:"/"Fatal"/"Warning:" string sniffing. See WithLevelMapping.
+type LevelMapper func(message string) int
+
+// Option configures a Writer; see New.
+type Option func(*Writer)
+
+// WithCompression gzip- or zlib-compresses a payload before chunking it.
+// Only applies to the UDP transport (see Compression).
+func WithCompression(c Compression) Option {
+	return func(w *Writer) { w.compression = c }
 }
 
-// New creates a GELF UDP writer connected to addr (e.g. "172.17.0.1:12201").
-func New(addr string) (*Writer, error) {
-	conn, err := net.Dial("udp", addr)
-	if err != nil {
-		return nil, err
+// WithChunkSize overrides the UDP chunk payload size (defaultChunkSize),
+// clamped to [1, maxChunkSize].
+func WithChunkSize(n int) Option {
+	return func(w *Writer) {
+		if n < 1 {
+			n = 1
+		}
+		if n > maxChunkSize {
+			n = maxChunkSize
+		}
+		w.chunkSize = n
 	}
+}
+
+// WithTransport selects UDP (the default), TCP, or TCPTLS.
+func WithTransport(t Transport) Option {
+	return func(w *Writer) { w.transport = t }
+}
+
+// WithTLSConfig sets the tls.Config dialed with when Transport is TCPTLS.
+// A nil config (the default) uses Go's defaults: system root CAs and SNI
+// derived from addr.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(w *Writer) { w.tlsConfig = cfg }
+}
 
+// WithLevelMapping overrides Writer's default level detection with fn.
+func WithLevelMapping(fn LevelMapper) Option {
+	return func(w *Writer) { w.levelMapper = fn }
+}
+
+// Writer sends GELF messages and implements io.Writer so it can be used
+// with log.SetOutput via io.MultiWriter. Each call to Write sends one GELF
+// message.
+type Writer struct {
+	addr        string
+	transport   Transport
+	tlsConfig   *tls.Config
+	compression Compression
+	chunkSize   int
+	levelMapper LevelMapper
+	hostname    string
+
+	mu              sync.Mutex
+	conn            net.Conn
+	reconnectDelay  time.Duration
+	nextReconnectAt time.Time
+}
+
+// New creates a GELF writer connected to addr (e.g. "172.17.0.1:12201"),
+// over UDP unless opts selects a different Transport.
+func New(addr string, opts ...Option) (*Writer, error) {
 	hostname, _ := os.Hostname()
 	if hostname == "" {
 		hostname = "oxidms-server"
 	}
 
-	return &Writer{conn: conn, hostname: hostname}, nil
+	w := &Writer{
+		addr:      addr,
+		transport: UDP,
+		chunkSize: defaultChunkSize,
+		hostname:  hostname,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	conn, err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+func (w *Writer) dial() (net.Conn, error) {
+	switch w.transport {
+	case TCP:
+		return net.Dial("tcp", w.addr)
+	case TCPTLS:
+		return tls.Dial("tcp", w.addr, w.tlsConfig)
+	default:
+		return net.Dial("udp", w.addr)
+	}
+}
+
+// Close releases the writer's socket.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.Close()
 }
 
 // Write implements io.Writer. Each call sends one GELF message.
@@ -43,28 +189,166 @@ func (w *Writer) Write(p []byte) (int, error) {
 		short = msg[20:]
 	}
 
-	level := 6 // Informational
-	if strings.Contains(short, "PANIC:") || strings.Contains(short, "Fatal") {
-		level = 3 // Error
-	} else if strings.HasPrefix(short, "Warning:") {
-		level = 4 // Warning
-	}
-
-	gelf := map[string]interface{}{
+	doc := map[string]any{
 		"version":       "1.1",
 		"host":          w.hostname,
 		"short_message": short,
 		"timestamp":     float64(time.Now().UnixNano()) / 1e9,
-		"level":         level,
+		"level":         w.level(short),
 		"_service":      "oxidms",
 	}
 
-	payload, err := json.Marshal(gelf)
+	payload, err := json.Marshal(doc)
 	if err != nil {
 		return len(p), nil // don't fail the log call
 	}
 
-	// Fire-and-forget
-	w.conn.Write(payload)
+	w.send(payload) // fire-and-forget: never fail the caller's log call
 	return len(p), nil
 }
+
+func (w *Writer) level(short string) int {
+	if w.levelMapper != nil {
+		return w.levelMapper(short)
+	}
+	switch {
+	case strings.Contains(short, "PANIC:") || strings.Contains(short, "Fatal"):
+		return 3 // Error
+	case strings.HasPrefix(short, "Warning:"):
+		return 4 // Warning
+	default:
+		return 6 // Informational
+	}
+}
+
+// send writes payload to the wire, chunking/compressing for UDP or
+// null-framing for TCP, reconnecting on a write error before returning it.
+func (w *Writer) send(payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if w.transport == UDP {
+		err = w.sendUDP(payload)
+	} else {
+		err = w.sendTCP(payload)
+	}
+	if err != nil {
+		w.reconnectLocked()
+	}
+	return err
+}
+
+// sendTCP null-frames payload per the GELF TCP spec: each message ends
+// with a single 0x00 byte so the receiver can split a stream of them back
+// into individual documents.
+func (w *Writer) sendTCP(payload []byte) error {
+	_, err := w.conn.Write(append(payload, 0x00))
+	return err
+}
+
+// sendUDP sends payload as one datagram, or as chunked datagrams per the
+// GELF v1.1 spec if it's too large for w.chunkSize, compressing first if
+// compression is configured and that alone doesn't bring it under the
+// limit.
+func (w *Writer) sendUDP(payload []byte) error {
+	if len(payload) <= w.chunkSize {
+		_, err := w.conn.Write(payload)
+		return err
+	}
+
+	if w.compression != NoCompression {
+		if compressed, err := w.compress(payload); err == nil {
+			payload = compressed
+			if len(payload) <= w.chunkSize {
+				_, err := w.conn.Write(payload)
+				return err
+			}
+		}
+	}
+
+	total := (len(payload) + w.chunkSize - 1) / w.chunkSize
+	if total > maxChunks {
+		total = maxChunks
+		payload = payload[:maxChunks*w.chunkSize]
+	}
+
+	var msgID [8]byte
+	if _, err := rand.Read(msgID[:]); err != nil {
+		return fmt.Errorf("gelf: generate message id: %w", err)
+	}
+
+	for seq := 0; seq < total; seq++ {
+		start := seq * w.chunkSize
+		end := start + w.chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		header := make([]byte, 0, 12+end-start)
+		header = append(header, gelfMagic[0], gelfMagic[1])
+		header = append(header, msgID[:]...)
+		header = append(header, byte(seq), byte(total))
+		header = append(header, payload[start:end]...)
+		if _, err := w.conn.Write(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) compress(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var zw interface {
+		io.Writer
+		Close() error
+	}
+	switch w.compression {
+	case Gzip:
+		zw = gzip.NewWriter(&buf)
+	case Zlib:
+		zw = zlib.NewWriter(&buf)
+	default:
+		return p, nil
+	}
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// reconnectLocked redials the transport after a write error, so the next
+// Write gets a fresh connection instead of failing forever against a dead
+// socket. UDP is connectionless — a failed write means something other
+// than a stale connection, so there's nothing to redial. TCP/TCPTLS back
+// off exponentially between attempts (capped at reconnectMaxDelay) rather
+// than redialing on every single failed Write in a hot logging loop.
+func (w *Writer) reconnectLocked() {
+	if w.transport == UDP {
+		return
+	}
+	if time.Now().Before(w.nextReconnectAt) {
+		return
+	}
+
+	conn, err := w.dial()
+	if err != nil {
+		if w.reconnectDelay == 0 {
+			w.reconnectDelay = reconnectInitialDelay
+		} else {
+			w.reconnectDelay *= 2
+			if w.reconnectDelay > reconnectMaxDelay {
+				w.reconnectDelay = reconnectMaxDelay
+			}
+		}
+		w.nextReconnectAt = time.Now().Add(w.reconnectDelay)
+		return
+	}
+
+	w.reconnectDelay = 0
+	w.nextReconnectAt = time.Time{}
+	w.conn.Close()
+	w.conn = conn
+}
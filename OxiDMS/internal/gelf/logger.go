@@ -0,0 +1,131 @@
+package gelf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+)
+
+// fieldNameRe matches a GELF additional field's name (without its leading
+// underscore), per the GELF spec: word characters, dots, and hyphens only.
+var fieldNameRe = regexp.MustCompile(`^[\w.\-]+$`)
+
+// isValidFieldName reports whether key is usable as a GELF additional
+// field name. The GELF spec requires matching fieldNameRe and forbids the
+// reserved "_id" field, which is key == "id" here since Logger always adds
+// the leading underscore itself.
+func isValidFieldName(key string) bool {
+	return key != "id" && fieldNameRe.MatchString(key)
+}
+
+// Logger is a thin layer over Writer for call sites that want structured
+// "_key" GELF additional fields instead of (or alongside) a flat message —
+// DashboardHandler, SearchHandler, and auth.Middleware, say, stashing a
+// request ID or user email so every log line downstream of them carries
+// it. It's deliberately separate from internal/logging's slog-based
+// per-request Logger: that package's structured fields flow only through
+// slog call sites, while this one rides along with the plain log.Printf
+// call sites already scattered across the handler/service/repository
+// layers, via the same Writer log.SetOutput already points at.
+type Logger struct {
+	w      *Writer
+	fields map[string]any
+}
+
+// NewLogger wraps w with no preset fields.
+func NewLogger(w *Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// With returns a Logger with kv (alternating key/value pairs, as with
+// slog) merged over any fields l already carries. An odd-length kv drops
+// its final, value-less key.
+func (l *Logger) With(kv ...any) *Logger {
+	return &Logger{w: l.w, fields: mergeFields(l.fields, kv)}
+}
+
+// Printf renders msg like fmt.Sprintf and sends it as a GELF document
+// whose additional fields are l's own (from With) plus whatever
+// WithFields stashed on ctx, the latter taking precedence on a key
+// collision since it's the more request-specific of the two.
+func (l *Logger) Printf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	fields := mergeMaps(l.fields, FromContext(ctx))
+	if err := l.w.writeFields(msg, fields); err != nil {
+		log.Printf("gelf: send failed: %v", err)
+	}
+}
+
+// writeFields builds and sends a GELF document like Writer.Write, but with
+// fields rendered as validated "_key" additional fields instead of being
+// folded into short_message.
+func (w *Writer) writeFields(msg string, fields map[string]any) error {
+	doc := map[string]any{
+		"version":       "1.1",
+		"host":          w.hostname,
+		"short_message": msg,
+		"timestamp":     float64(time.Now().UnixNano()) / 1e9,
+		"level":         w.level(msg),
+		"_service":      "oxidms",
+	}
+	for k, v := range fields {
+		if isValidFieldName(k) {
+			doc["_"+k] = v
+		}
+	}
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("gelf: marshal document: %w", err)
+	}
+	return w.send(payload)
+}
+
+type ctxKey struct{}
+
+// WithFields returns a context carrying kv (alternating key/value pairs)
+// merged over any fields already stashed by an earlier WithFields call in
+// an ancestor context, so middleware layers compose — auth.Middleware
+// stashes a user email, then a later WithFields adds a request ID, and a
+// Logger.Printf downstream of both picks up all of it.
+func WithFields(ctx context.Context, kv ...any) context.Context {
+	return context.WithValue(ctx, ctxKey{}, mergeFields(FromContext(ctx), kv))
+}
+
+// FromContext returns the fields stashed by WithFields, or nil if ctx
+// carries none.
+func FromContext(ctx context.Context) map[string]any {
+	fields, _ := ctx.Value(ctxKey{}).(map[string]any)
+	return fields
+}
+
+// mergeFields copies base and overlays kv's alternating key/value pairs on
+// top of it, so a repeated key takes kv's value.
+func mergeFields(base map[string]any, kv []any) map[string]any {
+	out := make(map[string]any, len(base)+len(kv)/2)
+	for k, v := range base {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		out[key] = kv[i+1]
+	}
+	return out
+}
+
+// mergeMaps copies base and overlays over's entries on top of it.
+func mergeMaps(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
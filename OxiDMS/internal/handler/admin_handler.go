@@ -1,17 +1,32 @@
 package handler
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/authz"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/operations"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
 )
 
 type AdminHandler struct {
-	subRepo *repository.SubmissionRepo
+	subRepo    *repository.SubmissionRepo
+	clients    *repository.ClientRepo
+	index      *search.Indexer
+	auditRepo  *repository.AuditRepo
+	rec        *audit.Recorder
+	ops        *operations.Registry
+	policyFile string
 }
 
-func NewAdminHandler(subRepo *repository.SubmissionRepo) *AdminHandler {
-	return &AdminHandler{subRepo: subRepo}
+func NewAdminHandler(subRepo *repository.SubmissionRepo, clients *repository.ClientRepo, index *search.Indexer, auditRepo *repository.AuditRepo, rec *audit.Recorder, ops *operations.Registry, policyFile string) *AdminHandler {
+	return &AdminHandler{subRepo: subRepo, clients: clients, index: index, auditRepo: auditRepo, rec: rec, ops: ops, policyFile: policyFile}
 }
 
 func (h *AdminHandler) ListIndexes(w http.ResponseWriter, r *http.Request) {
@@ -23,11 +38,144 @@ func (h *AdminHandler) ListIndexes(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"indexes": indexes})
 }
 
+// Compact compacts the submissions collection and the search index's
+// posting lists. Both can take a while on a large dataset, so it runs as
+// an operations.Operation: the handler returns 202 immediately with a
+// Location pointing at GET /api/v1/operations/{id} for polling.
 func (h *AdminHandler) Compact(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.subRepo.Compact()
+	op := h.ops.Start(operations.TypeCompact, func(ctx context.Context, op *operations.Operation) error {
+		stats, err := h.subRepo.Compact()
+		if err != nil {
+			return err
+		}
+		op.SetProgress(50)
+		searchStats, err := h.index.Compact()
+		if err != nil {
+			return err
+		}
+		stats["searchIndex"] = searchStats
+		if err := h.rec.Record(r, "compact", "system", "", nil, stats); err != nil {
+			log.Printf("audit: record compact: %v", err)
+		}
+		return nil
+	})
+	writeOperationAccepted(w, op)
+}
+
+// Reindex rebuilds the full-text search index from scratch from the
+// Form/Submission collections, as an operations.Operation for the same
+// reason as Compact.
+func (h *AdminHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	op := h.ops.Start(operations.TypeReindex, func(ctx context.Context, op *operations.Operation) error {
+		stats, err := h.index.Reindex()
+		if err != nil {
+			return err
+		}
+		if err := h.rec.Record(r, "reindex", "searchIndex", "", nil, stats); err != nil {
+			log.Printf("audit: record reindex: %v", err)
+		}
+		return nil
+	})
+	writeOperationAccepted(w, op)
+}
+
+// RunningOperations lists operations still in flight in this process, for
+// the admin dashboard's background-jobs panel.
+func (h *AdminHandler) RunningOperations(w http.ResponseWriter, r *http.Request) {
+	ops := h.ops.List()
+	recs := make([]models.OperationRecord, 0, len(ops))
+	for _, op := range ops {
+		rec := op.Snapshot()
+		if rec.Status == operations.StatusPending || rec.Status == operations.StatusRunning {
+			recs = append(recs, rec)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"operations": recs})
+}
+
+// Audit lists recorded audit entries, filterable by actor, resource, and
+// date range (RFC3339 from/to), most recent first.
+func (h *AdminHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	skip, _ := strconv.Atoi(q.Get("skip"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+	filter := repository.AuditFilter{
+		Actor:    q.Get("actor"),
+		Resource: q.Get("resource"),
+		From:     q.Get("from"),
+		To:       q.Get("to"),
+	}
+	entries, total, err := h.auditRepo.List(filter, skip, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+		"total":   total,
+		"skip":    skip,
+		"limit":   limit,
+	})
+}
+
+// ReloadPolicy re-reads the policy file named by DMS_POLICY_FILE and
+// installs it as the active role/permission rules, without a server
+// restart. Errors (missing file, malformed JSON) leave the currently
+// active policy in place.
+func (h *AdminHandler) ReloadPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.policyFile == "" {
+		writeError(w, http.StatusBadRequest, "no policy file configured (DMS_POLICY_FILE)")
+		return
+	}
+	if err := authz.ReloadPolicyFile(h.policyFile); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.rec.Record(r, "reload", "policy", "", nil, nil); err != nil {
+		log.Printf("audit: record policy reload: %v", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+}
+
+func (h *AdminHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.clients.List()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, stats)
+	writeJSON(w, http.StatusOK, clients)
+}
+
+func (h *AdminHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirectUris"`
+		Scopes       []string `json:"scopes"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == "" || len(req.RedirectURIs) == 0 {
+		writeError(w, http.StatusBadRequest, "name and redirectUris are required")
+		return
+	}
+	client, err := h.clients.Create(req.Name, req.RedirectURIs, req.Scopes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, client)
+}
+
+func (h *AdminHandler) RevokeClient(w http.ResponseWriter, r *http.Request) {
+	clientID := chi.URLParam(r, "clientId")
+	if err := h.clients.Revoke(clientID); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
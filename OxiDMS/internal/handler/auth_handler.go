@@ -1,18 +1,28 @@
 package handler
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/authz"
+	mw "github.com/parisxmas/OxiDB/OxiDMS/internal/middleware"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
 type AuthHandler struct {
-	svc *service.AuthService
+	svc       *service.AuthService
+	tokens    *authz.APITokens
+	auditRepo *repository.AuditRepo
+	rec       *audit.Recorder
 }
 
-func NewAuthHandler(svc *service.AuthService) *AuthHandler {
-	return &AuthHandler{svc: svc}
+func NewAuthHandler(svc *service.AuthService, tokens *authz.APITokens, auditRepo *repository.AuditRepo, rec *audit.Recorder) *AuthHandler {
+	return &AuthHandler{svc: svc, tokens: tokens, auditRepo: auditRepo, rec: rec}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -29,11 +39,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "email, password, and name are required")
 		return
 	}
-	result, err := h.svc.Register(req.Email, req.Password, req.Name)
+	result, err := h.svc.Register(req.Email, req.Password, req.Name, r.UserAgent(), mw.ClientIP(r))
 	if err != nil {
 		writeError(w, http.StatusConflict, err.Error())
 		return
 	}
+	if err := h.rec.RecordAs(r, result.User.ID, "register", "user", result.User.ID, nil, result.User); err != nil {
+		log.Printf("audit: record register %s: %v", result.User.ID, err)
+	}
 	writeJSON(w, http.StatusCreated, result)
 }
 
@@ -50,7 +63,32 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "email and password are required")
 		return
 	}
-	result, err := h.svc.Login(req.Email, req.Password)
+	result, err := h.svc.Login(req.Email, req.Password, r.UserAgent(), mw.ClientIP(r))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err := h.rec.RecordAs(r, result.User.ID, "login", "session", result.User.ID, nil, nil); err != nil {
+		log.Printf("audit: record login %s: %v", result.User.ID, err)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Refresh exchanges a refresh token for a new access/refresh pair,
+// rotating the one presented per RFC 6749's refresh token rotation.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refreshToken is required")
+		return
+	}
+	result, err := h.svc.Refresh(req.RefreshToken, r.UserAgent(), mw.ClientIP(r))
 	if err != nil {
 		writeError(w, http.StatusUnauthorized, err.Error())
 		return
@@ -58,6 +96,42 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// Logout revokes the session behind the given refresh token, rejecting
+// its access token on every subsequent request (see auth.Middleware).
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refreshToken is required")
+		return
+	}
+	if err := h.svc.Logout(req.RefreshToken); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// LogoutAll revokes every session belonging to the authenticated user,
+// signing them out on every device at once.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUser(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.LogoutAll(claims.UserID); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUser(r.Context())
 	if claims == nil {
@@ -71,3 +145,69 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, user)
 }
+
+// Activity returns the authenticated user's own audit trail, most recent
+// first.
+func (h *AuthHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUser(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+	entries, total, err := h.auditRepo.List(repository.AuditFilter{Actor: claims.UserID}, skip, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+		"total":   total,
+		"skip":    skip,
+		"limit":   limit,
+	})
+}
+
+// CreateToken mints a scoped, expirable API token for the authenticated
+// user, so an external script can be granted narrow permissions without
+// sharing the user's password. The cleartext token is only ever returned
+// in this response.
+func (h *AuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUser(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn int64    `json:"expiresIn"` // seconds; 0 means no expiry
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	token, rec, err := h.tokens.Mint(claims.UserID, req.Name, req.Scopes, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.rec.Record(r, "create", "apiToken", rec.ID, nil, map[string]any{"name": rec.Name, "permissions": rec.Permissions}); err != nil {
+		log.Printf("audit: record token create %s: %v", rec.ID, err)
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"token":       token,
+		"id":          rec.ID,
+		"name":        rec.Name,
+		"permissions": rec.Permissions,
+		"expiresAt":   rec.ExpiresAt,
+	})
+}
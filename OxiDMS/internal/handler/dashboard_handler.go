@@ -3,30 +3,44 @@ package handler
 import (
 	"net/http"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
 type DashboardHandler struct {
-	formSvc *service.FormService
-	subSvc  *service.SubmissionService
-	docSvc  *service.DocumentService
+	formSvc  *service.FormService
+	subSvc   *service.SubmissionService
+	docSvc   *service.DocumentService
 	formRepo *repository.FormRepo
+	metrics  *metrics.Registry
+	logger   *gelf.Logger
 }
 
-func NewDashboardHandler(formSvc *service.FormService, subSvc *service.SubmissionService, docSvc *service.DocumentService, formRepo *repository.FormRepo) *DashboardHandler {
-	return &DashboardHandler{formSvc: formSvc, subSvc: subSvc, docSvc: docSvc, formRepo: formRepo}
+// NewDashboardHandler's logger may be nil (GELF logging disabled), in
+// which case Dashboard's error logging below is skipped rather than
+// panicking — see gelfLogf.
+func NewDashboardHandler(formSvc *service.FormService, subSvc *service.SubmissionService, docSvc *service.DocumentService, formRepo *repository.FormRepo, reg *metrics.Registry, logger *gelf.Logger) *DashboardHandler {
+	return &DashboardHandler{formSvc: formSvc, subSvc: subSvc, docSvc: docSvc, formRepo: formRepo, metrics: reg, logger: logger}
 }
 
+// Dashboard reads form/submission/document counts from the shared
+// metrics.Registry instead of issuing a CountByForm query per form, so the
+// response is O(number of forms) in map lookups rather than O(number of
+// forms) in database round trips.
 func (h *DashboardHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
-	forms, _ := h.formSvc.List()
-	formCount := len(forms)
+	forms, err := h.formSvc.List()
+	if err != nil {
+		gelfLogf(h.logger, r.Context(), "dashboard: list forms failed: %v", err)
+	}
 
+	subsByForm := h.metrics.SubmissionsByForm()
 	totalSubs := 0
 	formStats := make([]map[string]any, 0, len(forms))
 	for _, f := range forms {
-		count, _ := h.subSvc.CountByForm(f.ID)
-		totalSubs += count
+		count := subsByForm[f.ID]
+		totalSubs += int(count)
 		formStats = append(formStats, map[string]any{
 			"id":              f.ID,
 			"name":            f.Name,
@@ -37,12 +51,10 @@ func (h *DashboardHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	docCount, _ := h.docSvc.Count()
-
 	writeJSON(w, http.StatusOK, map[string]any{
-		"formCount":       formCount,
+		"formCount":       h.metrics.FormsTotal(),
 		"submissionCount": totalSubs,
-		"documentCount":   docCount,
+		"documentCount":   h.metrics.DocumentsTotal(),
 		"forms":           formStats,
 	})
 }
@@ -3,20 +3,29 @@ package handler
 import (
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
+// maxFormFieldSize bounds the formId/submissionId parts of an upload; the
+// file part itself is streamed with no size cap.
+const maxFormFieldSize = 1 << 20
+
 type DocumentHandler struct {
 	svc *service.DocumentService
+	rec *audit.Recorder
 }
 
-func NewDocumentHandler(svc *service.DocumentService) *DocumentHandler {
-	return &DocumentHandler{svc: svc}
+func NewDocumentHandler(svc *service.DocumentService, rec *audit.Recorder) *DocumentHandler {
+	return &DocumentHandler{svc: svc, rec: rec}
 }
 
 func (h *DocumentHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -39,54 +48,100 @@ func (h *DocumentHandler) List(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Upload streams the request body straight into DocumentService.Upload
+// instead of buffering it with ParseMultipartForm, so multi-GB files don't
+// sit fully in memory. Because the file is read as it arrives, the formId
+// and submissionId fields must appear before the file field in the
+// multipart body: by the time the file part is read there's no way to go
+// back and pick up fields that haven't streamed in yet.
 func (h *DocumentHandler) Upload(w http.ResponseWriter, r *http.Request) {
-	// Max 12MB
-	r.ParseMultipartForm(12 << 20)
-
-	file, header, err := r.FormFile("file")
+	mr, err := r.MultipartReader()
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "file is required")
+		writeError(w, http.StatusBadRequest, "expected multipart/form-data")
 		return
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to read file")
-		return
-	}
+	var formID, submissionID string
+	var uploaded any
 
-	formID := r.FormValue("formId")
-	submissionID := r.FormValue("submissionId")
-	claims := auth.GetUser(r.Context())
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "malformed multipart body")
+			return
+		}
 
-	doc, err := h.svc.Upload(header.Filename, data, header.Header.Get("Content-Type"), formID, submissionID, claims.UserID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		switch part.FormName() {
+		case "formId":
+			formID, _ = readFormField(part)
+		case "submissionId":
+			submissionID, _ = readFormField(part)
+		case "file":
+			claims := auth.GetUser(r.Context())
+			doc, err := h.svc.Upload(part.FileName(), part, part.Header.Get("Content-Type"), formID, submissionID, claims.UserID)
+			part.Close()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if err := h.rec.Record(r, "create", "document", doc.ID, nil, doc); err != nil {
+				log.Printf("audit: record document upload %s: %v", doc.ID, err)
+			}
+			uploaded = doc
+		default:
+			part.Close()
+		}
+	}
+
+	if uploaded == nil {
+		writeError(w, http.StatusBadRequest, "file is required")
 		return
 	}
-	writeJSON(w, http.StatusCreated, doc)
+	writeJSON(w, http.StatusCreated, uploaded)
+}
+
+func readFormField(p *multipart.Part) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(p, maxFormFieldSize))
+	p.Close()
+	return string(data), err
 }
 
+// Download serves the document's blob. Backends that hand back a seekable
+// reader (the OxiDB and filesystem drivers) get Range request support for
+// free via http.ServeContent; the S3 driver streams an HTTP response body,
+// which isn't seekable, so those downloads fall back to a plain copy.
 func (h *DocumentHandler) Download(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "docId")
-	data, doc, err := h.svc.Download(id)
+	body, doc, err := h.svc.Download(id)
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	defer body.Close()
 
 	w.Header().Set("Content-Type", doc.ContentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, doc.FileName))
-	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
-	w.Write(data)
+
+	if rs, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, doc.FileName, time.Time{}, rs)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+	io.Copy(w, body)
 }
 
 func (h *DocumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "docId")
+	before, _ := h.svc.Get(id)
 	if err := h.svc.Delete(id); err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if err := h.rec.Record(r, "delete", "document", id, before, nil); err != nil {
+		log.Printf("audit: record document delete %s: %v", id, err)
+	}
 	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
 }
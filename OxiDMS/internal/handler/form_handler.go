@@ -4,16 +4,21 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/logging"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
 type FormHandler struct {
-	svc *service.FormService
+	svc  *service.FormService
+	acls *repository.FormACLRepo
+	rec  *audit.Recorder
 }
 
-func NewFormHandler(svc *service.FormService) *FormHandler {
-	return &FormHandler{svc: svc}
+func NewFormHandler(svc *service.FormService, acls *repository.FormACLRepo, rec *audit.Recorder) *FormHandler {
+	return &FormHandler{svc: svc, acls: acls, rec: rec}
 }
 
 func (h *FormHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -30,17 +35,22 @@ func (h *FormHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Name        string           `json:"name"`
 		Description string           `json:"description"`
 		Fields      []map[string]any `json:"fields"`
+		Public      bool             `json:"public"`
 	}
 	if err := readJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	claims := auth.GetUser(r.Context())
-	form, err := h.svc.Create(req.Name, req.Description, claims.UserID, req.Fields)
+	form, err := h.svc.Create(req.Name, req.Description, claims.UserID, req.Fields, req.Public)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	logging.Tag(r.Context(), "form_id", form.ID)
+	if err := h.rec.Record(r, "create", "form", form.ID, nil, form); err != nil {
+		logging.FromContext(r.Context()).Error("audit: record form create failed", "form_id", form.ID, "error", err)
+	}
 	writeJSON(w, http.StatusCreated, form)
 }
 
@@ -56,28 +66,93 @@ func (h *FormHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 func (h *FormHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "formId")
+	logging.Tag(r.Context(), "form_id", id)
 	var req struct {
 		Name        string           `json:"name"`
 		Description string           `json:"description"`
 		Fields      []map[string]any `json:"fields"`
+		Public      bool             `json:"public"`
 	}
 	if err := readJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	form, err := h.svc.Update(id, req.Name, req.Description, req.Fields)
+	before, _ := h.svc.Get(id)
+	form, err := h.svc.Update(id, req.Name, req.Description, req.Fields, req.Public)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := h.rec.Record(r, "update", "form", id, before, form); err != nil {
+		logging.FromContext(r.Context()).Error("audit: record form update failed", "form_id", id, "error", err)
+	}
 	writeJSON(w, http.StatusOK, form)
 }
 
 func (h *FormHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "formId")
+	logging.Tag(r.Context(), "form_id", id)
+	before, _ := h.svc.Get(id)
 	if err := h.svc.Delete(id); err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if err := h.rec.Record(r, "delete", "form", id, before, nil); err != nil {
+		logging.FromContext(r.Context()).Error("audit: record form delete failed", "form_id", id, "error", err)
+	}
 	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
 }
+
+// ListACL returns every principal granted access to formId beyond the
+// default role permissions.
+func (h *FormHandler) ListACL(w http.ResponseWriter, r *http.Request) {
+	formID := chi.URLParam(r, "formId")
+	entries, err := h.acls.List(formID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// GrantACL lets a principal with form:update access on formId (the
+// creator, by default) share read/write access with another user or
+// "group:<name>".
+func (h *FormHandler) GrantACL(w http.ResponseWriter, r *http.Request) {
+	formID := chi.URLParam(r, "formId")
+	var req struct {
+		Principal   string   `json:"principal"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Principal == "" || len(req.Permissions) == 0 {
+		writeError(w, http.StatusBadRequest, "principal and permissions are required")
+		return
+	}
+	entry, err := h.acls.Grant(formID, req.Principal, req.Permissions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.rec.Record(r, "grant", "formAcl", formID, nil, entry); err != nil {
+		logging.FromContext(r.Context()).Error("audit: record form acl grant failed", "form_id", formID, "error", err)
+	}
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// RevokeACL removes a principal's ACL entry on formId.
+func (h *FormHandler) RevokeACL(w http.ResponseWriter, r *http.Request) {
+	formID := chi.URLParam(r, "formId")
+	principal := chi.URLParam(r, "principal")
+	if err := h.acls.Revoke(formID, principal); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.rec.Record(r, "revoke", "formAcl", formID, nil, map[string]string{"principal": principal}); err != nil {
+		logging.FromContext(r.Context()).Error("audit: record form acl revoke failed", "form_id", formID, "error", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
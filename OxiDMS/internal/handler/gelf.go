@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
+)
+
+// gelfLogf sends a formatted line through logger (via gelf.Logger.Printf,
+// picking up request_id/user_email from ctx if auth.Middleware or
+// middleware.Logger stashed them there) if logger is non-nil, and is a
+// no-op otherwise — handlers are constructed with a nil logger whenever
+// GELF logging isn't configured (see main.go), so call sites don't need
+// their own nil check before every log line.
+func gelfLogf(logger *gelf.Logger, ctx context.Context, format string, args ...any) {
+	if logger == nil {
+		return
+	}
+	logger.Printf(ctx, format, args...)
+}
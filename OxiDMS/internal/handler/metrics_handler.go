@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
+)
+
+// MetricsHandler serves the metrics.Registry's current readings in
+// Prometheus text exposition format.
+type MetricsHandler struct {
+	reg *metrics.Registry
+}
+
+func NewMetricsHandler(reg *metrics.Registry) *MetricsHandler {
+	return &MetricsHandler{reg: reg}
+}
+
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(h.reg.Render()))
+}
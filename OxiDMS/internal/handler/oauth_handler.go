@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/oauth"
+)
+
+type OAuthHandler struct {
+	srv *oauth.Server
+}
+
+func NewOAuthHandler(srv *oauth.Server) *OAuthHandler {
+	return &OAuthHandler{srv: srv}
+}
+
+// Authorize is the authorization_code grant's front-channel step: the
+// caller must already be authenticated (see auth.Middleware on this route)
+// and is redirected back to redirect_uri with a one-time code.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUser(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	q := r.URL.Query()
+	clientID := q.Get("client_id")
+	redirectURI := q.Get("redirect_uri")
+	scope := q.Get("scope")
+	state := q.Get("state")
+
+	code, err := h.srv.Authorize(clientID, redirectURI, scope, claims.UserID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dest, err := url.Parse(redirectURI)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid redirect_uri")
+		return
+	}
+	qs := dest.Query()
+	qs.Set("code", code)
+	if state != "" {
+		qs.Set("state", state)
+	}
+	dest.RawQuery = qs.Encode()
+	http.Redirect(w, r, dest.String(), http.StatusFound)
+}
+
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+	codeOrRefresh := r.FormValue("code")
+	if codeOrRefresh == "" {
+		codeOrRefresh = r.FormValue("refresh_token")
+	}
+
+	tok, err := h.srv.Exchange(
+		r.FormValue("grant_type"),
+		r.FormValue("client_id"),
+		r.FormValue("client_secret"),
+		codeOrRefresh,
+		r.FormValue("redirect_uri"),
+	)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tok)
+}
+
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+	if err := h.srv.Revoke(r.FormValue("token")); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+	result, err := h.srv.Introspect(r.FormValue("token"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *OAuthHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.srv.OpenIDConfiguration())
+}
+
+func (h *OAuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, oauth.JWKS())
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/operations"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// OperationHandler exposes the operations.Registry over HTTP: polling,
+// cancellation, and an SSE stream for live progress. GET-by-ID falls back
+// to OperationRepo for operations started before the last restart, since
+// the registry itself only holds what this process has started.
+type OperationHandler struct {
+	reg  *operations.Registry
+	repo *repository.OperationRepo
+}
+
+func NewOperationHandler(reg *operations.Registry, repo *repository.OperationRepo) *OperationHandler {
+	return &OperationHandler{reg: reg, repo: repo}
+}
+
+// List returns every operation on record, most recently started first,
+// including ones from before a restart.
+func (h *OperationHandler) List(w http.ResponseWriter, r *http.Request) {
+	recs, err := h.repo.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"operations": recs})
+}
+
+func (h *OperationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rec, err := h.lookup(chi.URLParam(r, "opId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "operation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// Cancel requests that a running operation stop; see Operation.Cancel for
+// why this is best-effort. Operations from before a restart aren't in the
+// registry anymore and so can't be cancelled.
+func (h *OperationHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	op := h.reg.Get(chi.URLParam(r, "opId"))
+	if op == nil {
+		writeError(w, http.StatusNotFound, "operation not found")
+		return
+	}
+	op.Cancel()
+	writeJSON(w, http.StatusOK, op.Snapshot())
+}
+
+// Events streams the operation's snapshot as a server-sent event every
+// time its progress or status changes, closing once it reaches a
+// terminal state or the client disconnects.
+func (h *OperationHandler) Events(w http.ResponseWriter, r *http.Request) {
+	op := h.reg.Get(chi.URLParam(r, "opId"))
+	if op == nil {
+		writeError(w, http.StatusNotFound, "operation not found")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ch, unsubscribe := op.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case rec, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(rec)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+			if rec.Status != operations.StatusPending && rec.Status != operations.StatusRunning {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeOperationAccepted is the shared response shape for handlers that
+// start a background operations.Operation instead of doing the work
+// inline: 202 with a Location header pointing at where to poll it, and
+// the operation's initial snapshot as the body.
+func writeOperationAccepted(w http.ResponseWriter, op *operations.Operation) {
+	w.Header().Set("Location", "/api/v1/operations/"+op.ID())
+	writeJSON(w, http.StatusAccepted, op.Snapshot())
+}
+
+func (h *OperationHandler) lookup(id string) (*models.OperationRecord, error) {
+	if op := h.reg.Get(id); op != nil {
+		rec := op.Snapshot()
+		return &rec, nil
+	}
+	return h.repo.FindByID(id)
+}
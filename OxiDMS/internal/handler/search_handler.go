@@ -1,17 +1,31 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
 type SearchHandler struct {
-	svc *service.SearchService
+	svc    *service.SearchService
+	logger *gelf.Logger
+	// suggestLimit returns how many terms Suggest returns when the caller
+	// asks for the default (no "limit" in config.SearchConfig means 0, and
+	// SearchService.Suggest falls back to its own default of 10 in that
+	// case). Called once per request rather than captured as a plain int
+	// so a SIGHUP config reload (see config.Watcher.SuggestLimit) takes
+	// effect without restarting the server, the same pull-based shape
+	// metrics.Registry.WithPoolGauge uses for its own live value.
+	suggestLimit func() int
 }
 
-func NewSearchHandler(svc *service.SearchService) *SearchHandler {
-	return &SearchHandler{svc: svc}
+// NewSearchHandler's logger may be nil (GELF logging disabled); see
+// gelfLogf. suggestLimit is called fresh on every Suggest request, so
+// passing config.Watcher.SuggestLimit picks up SIGHUP reloads.
+func NewSearchHandler(svc *service.SearchService, logger *gelf.Logger, suggestLimit func() int) *SearchHandler {
+	return &SearchHandler{svc: svc, logger: logger, suggestLimit: suggestLimit}
 }
 
 func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
@@ -22,8 +36,58 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 	}
 	result, err := h.svc.Search(req)
 	if err != nil {
+		gelfLogf(h.logger, r.Context(), "search: query %q failed: %v", req.TextQuery, err)
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
 }
+
+// Suggest answers OpenSearch-style typeahead requests with the
+// ["query", [suggestions...], [], []] array shape the spec defines; the
+// trailing two arrays (descriptions and URLs) are always empty since
+// SearchService.Suggest doesn't have either to offer.
+func (h *SearchHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	terms := h.svc.Suggest(q, h.suggestLimit())
+	if terms == nil {
+		terms = []string{}
+	}
+	writeJSON(w, http.StatusOK, [4]any{q, terms, []string{}, []string{}})
+}
+
+// openSearchTemplate is the OpenSearch Description Document served at
+// /opensearch.xml; %s is the deployment's own base URL (scheme://host),
+// filled in per-request by OpenSearchDescription so this works the same
+// behind any hostname without a config setting to keep in sync.
+const openSearchTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <ShortName>OxiDMS</ShortName>
+  <Description>Search forms and submissions in OxiDMS</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <Image height="16" width="16" type="image/x-icon">%[1]s/favicon.ico</Image>
+  <Url type="application/x-suggestions+json" template="%[1]s/api/search/suggest?q={searchTerms}"/>
+  <Url type="text/html" template="%[1]s/api/search?q={searchTerms}"/>
+</OpenSearchDescription>
+`
+
+// OpenSearchDescription serves the OpenSearch Description Document so
+// browsers can auto-detect OxiDMS as a search provider; see the
+// "search" link tag router.spaHandler injects into index.html.
+func (h *SearchHandler) OpenSearchDescription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/opensearchdescription+xml")
+	fmt.Fprintf(w, openSearchTemplate, requestBaseURL(r))
+}
+
+// requestBaseURL reconstructs scheme://host from r, preferring
+// X-Forwarded-Proto (set by a reverse proxy) over r.TLS for the scheme.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
+)
+
+// SitemapHandler serves /sitemap.xml and /robots.txt for forms marked
+// models.Form.Public: every other form (and its submission data) stays
+// out of both, the same way authz keeps it out of anonymous API access.
+//
+// The frontend SPA (served from DMS_STATIC_DIR, outside this repo) is
+// assumed to route a public form at "/forms/{slug}" and its
+// post-submission confirmation page at "/forms/{slug}/submitted" — the
+// two URLs sitemap.xml lists per form.
+type SitemapHandler struct {
+	forms   *service.FormService
+	baseURL string // from config.SiteBaseURL; "" falls back to requestBaseURL(r)
+
+	mu         sync.Mutex
+	cachedMax  string
+	cachedBase string
+	cachedETag string
+	cachedBody []byte
+}
+
+// NewSitemapHandler's baseURL may be "", in which case every request
+// derives its own base URL from the Host header instead of a fixed
+// config value — see requestBaseURL.
+func NewSitemapHandler(forms *service.FormService, baseURL string) *SitemapHandler {
+	return &SitemapHandler{forms: forms, baseURL: baseURL}
+}
+
+// Sitemap writes a spec-compliant urlset listing every public form's page
+// and submission-confirmation page, with <lastmod> taken from the form's
+// UpdatedAt. The response carries an ETag derived from the newest
+// UpdatedAt across all public forms, so a crawler that already has the
+// current version gets a 304 instead of the full body — regeneration
+// only happens when that newest timestamp or the base URL changes.
+func (h *SitemapHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	forms, err := h.forms.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	public := publicForms(forms)
+	maxUpdated := maxUpdatedAt(public)
+
+	etag, body := h.render(maxUpdated, public, r)
+
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(body)
+}
+
+// render returns the cached body if neither maxUpdated nor the base URL
+// has changed since the last request, regenerating it otherwise. base is
+// resolved per-request (config.SiteBaseURL, or the request's own Host)
+// and is part of the cache key, so a request with a different Host header
+// never gets a body built for another one.
+func (h *SitemapHandler) render(maxUpdated string, public []models.Form, r *http.Request) (string, []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	base := h.baseURL
+	if base == "" {
+		base = requestBaseURL(r)
+	}
+
+	if h.cachedBody != nil && h.cachedMax == maxUpdated && h.cachedBase == base {
+		return h.cachedETag, h.cachedBody
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, f := range public {
+		writeURL(&buf, base+"/forms/"+f.Slug, f.UpdatedAt)
+		writeURL(&buf, base+"/forms/"+f.Slug+"/submitted", f.UpdatedAt)
+	}
+	buf.WriteString(`</urlset>` + "\n")
+
+	etag := fmt.Sprintf(`"%s-%d"`, maxUpdated, len(public))
+	h.cachedMax = maxUpdated
+	h.cachedBase = base
+	h.cachedETag = etag
+	h.cachedBody = buf.Bytes()
+	return etag, h.cachedBody
+}
+
+func writeURL(buf *bytes.Buffer, loc, lastmod string) {
+	buf.WriteString("  <url>\n")
+	fmt.Fprintf(buf, "    <loc>%s</loc>\n", escapeXML(loc))
+	if lastmod != "" {
+		fmt.Fprintf(buf, "    <lastmod>%s</lastmod>\n", lastmod)
+	}
+	buf.WriteString("  </url>\n")
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '"':
+			buf.WriteString("&quot;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// Robots serves /robots.txt: it always disallows /admin/, /api/auth/, and
+// a blanket /forms/ (public forms are discoverable through sitemap.xml
+// instead), and points crawlers at /sitemap.xml. It deliberately doesn't
+// enumerate individual non-public slugs: robots.txt is public and
+// routinely scraped specifically for its Disallow entries, so listing
+// them would hand out exactly the list of private forms it's meant to
+// keep hidden.
+func (h *SitemapHandler) Robots(w http.ResponseWriter, r *http.Request) {
+	base := h.baseURL
+	if base == "" {
+		base = requestBaseURL(r)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "User-agent: *\n")
+	fmt.Fprintf(w, "Disallow: /admin/\n")
+	fmt.Fprintf(w, "Disallow: /api/auth/\n")
+	fmt.Fprintf(w, "Disallow: /forms/\n")
+	fmt.Fprintf(w, "Sitemap: %s/sitemap.xml\n", base)
+}
+
+func publicForms(forms []models.Form) []models.Form {
+	out := make([]models.Form, 0, len(forms))
+	for _, f := range forms {
+		if f.Public {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Slug < out[j].Slug })
+	return out
+}
+
+// maxUpdatedAt returns the lexically greatest UpdatedAt among forms. RFC3339
+// UTC timestamps (the format every UpdatedAt in this codebase is stored in)
+// sort correctly as plain strings, so no parsing is needed just to compare
+// them.
+func maxUpdatedAt(forms []models.Form) string {
+	var max string
+	for _, f := range forms {
+		if f.UpdatedAt > max {
+			max = f.UpdatedAt
+		}
+	}
+	return max
+}
@@ -1,23 +1,46 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
+	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
+// writeServiceError renders err as an HTTP response: an *oxidb.Error with
+// field-level Details becomes 422 JSON carrying those details so the
+// client can map them onto form fields directly; anything else falls back
+// to a flat writeError message.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var oxErr *oxidb.Error
+	if errors.As(err, &oxErr) && len(oxErr.Details) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":   oxErr.Message,
+			"code":    oxErr.Code,
+			"details": oxErr.Details,
+		})
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
 type SubmissionHandler struct {
 	subSvc *service.SubmissionService
 	docSvc *service.DocumentService
+	rec    *audit.Recorder
 }
 
-func NewSubmissionHandler(subSvc *service.SubmissionService, docSvc *service.DocumentService) *SubmissionHandler {
-	return &SubmissionHandler{subSvc: subSvc, docSvc: docSvc}
+func NewSubmissionHandler(subSvc *service.SubmissionService, docSvc *service.DocumentService, rec *audit.Recorder) *SubmissionHandler {
+	return &SubmissionHandler{subSvc: subSvc, docSvc: docSvc, rec: rec}
 }
 
 func (h *SubmissionHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -58,9 +81,12 @@ func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 		sub, err := h.subSvc.Create(formID, req.Data, req.Files, claims.UserID)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, err.Error())
+			writeServiceError(w, err)
 			return
 		}
+		if err := h.rec.Record(r, "create", "submission", sub.ID, nil, sub); err != nil {
+			log.Printf("audit: record submission create %s: %v", sub.ID, err)
+		}
 		writeJSON(w, http.StatusCreated, sub)
 		return
 	}
@@ -92,7 +118,7 @@ func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 				if err != nil {
 					continue
 				}
-				doc, err := h.docSvc.Upload(fh.Filename, fileData, fh.Header.Get("Content-Type"), formID, "", claims.UserID)
+				doc, err := h.docSvc.Upload(fh.Filename, bytes.NewReader(fileData), fh.Header.Get("Content-Type"), formID, "", claims.UserID)
 				if err != nil {
 					continue
 				}
@@ -103,9 +129,12 @@ func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	sub, err := h.subSvc.Create(formID, data, fileIDs, claims.UserID)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, err)
 		return
 	}
+	if err := h.rec.Record(r, "create", "submission", sub.ID, nil, sub); err != nil {
+		log.Printf("audit: record submission create %s: %v", sub.ID, err)
+	}
 
 	writeJSON(w, http.StatusCreated, sub)
 }
@@ -134,19 +163,57 @@ func (h *SubmissionHandler) Update(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	before, _ := h.subSvc.Get(subID)
 	sub, err := h.subSvc.Update(subID, req.Data)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := h.rec.Record(r, "update", "submission", subID, before, sub); err != nil {
+		log.Printf("audit: record submission update %s: %v", subID, err)
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// UpdateField sets one nested path under a submission's Data in place,
+// resolving any positional operators in Path against ArrayFilters instead
+// of requiring the whole Data blob to be resubmitted.
+func (h *SubmissionHandler) UpdateField(w http.ResponseWriter, r *http.Request) {
+	subID := chi.URLParam(r, "subId")
+	var req struct {
+		Path         string           `json:"path"`
+		Value        any              `json:"value"`
+		ArrayFilters []map[string]any `json:"arrayFilters"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Path == "" {
+		writeError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	before, _ := h.subSvc.Get(subID)
+	sub, err := h.subSvc.UpdateField(subID, req.Path, req.Value, req.ArrayFilters)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.rec.Record(r, "update", "submission", subID, before, sub); err != nil {
+		log.Printf("audit: record submission update %s: %v", subID, err)
+	}
 	writeJSON(w, http.StatusOK, sub)
 }
 
 func (h *SubmissionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	subID := chi.URLParam(r, "subId")
+	before, _ := h.subSvc.Get(subID)
 	if err := h.subSvc.Delete(subID); err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if err := h.rec.Record(r, "delete", "submission", subID, before, nil); err != nil {
+		log.Printf("audit: record submission delete %s: %v", subID, err)
+	}
 	writeJSON(w, http.StatusOK, map[string]string{"deleted": subID})
 }
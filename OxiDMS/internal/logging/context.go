@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey string
+
+const loggerKey contextKey = "logging.logger"
+
+// holder lets Middleware's request-scoped logger grow additional
+// attributes as the request is handled (see Tag) and have the enriched
+// logger still be the one Middleware's own access-log line uses once
+// ServeHTTP returns, the same way internal/middleware's statusWriter lets
+// auth.Middleware tag the in-flight access log with the caller's identity.
+type holder struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// WithLogger attaches logger to ctx so FromContext and Tag can reach it.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, &holder{logger: logger})
+}
+
+// FromContext returns the logger attached to ctx by Middleware, or
+// slog.Default() if ctx didn't come from a request Middleware wrapped.
+func FromContext(ctx context.Context) *slog.Logger {
+	h, ok := ctx.Value(loggerKey).(*holder)
+	if !ok {
+		return slog.Default()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.logger
+}
+
+// Tag adds args (slog.With-style key/value pairs) to the logger attached
+// to ctx, so both later FromContext calls in this request and
+// Middleware's final access-log line carry them. A no-op if ctx didn't
+// come from a request Middleware wrapped.
+func Tag(ctx context.Context, args ...any) {
+	h, ok := ctx.Value(loggerKey).(*holder)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	h.logger = h.logger.With(args...)
+	h.mu.Unlock()
+}
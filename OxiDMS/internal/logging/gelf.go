@@ -0,0 +1,219 @@
+// Package logging provides a log/slog handler that ships structured
+// records to a GELF (Graylog Extended Log Format) collector over UDP,
+// plus the request-scoped plumbing (internal/logging's context helpers
+// and chi middleware) to carry a correlation ID and per-request fields
+// through to it.
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// chunkSize is the GELF chunk payload size (excluding the 12-byte chunk
+// header below). Kept well under the common 1500-byte Ethernet MTU so
+// chunked datagrams don't get fragmented a second time at the IP layer.
+const chunkSize = 1420
+
+// maxChunks is the GELF spec's hard limit: a message split into more than
+// this many chunks is dropped by the receiver, so messages that would
+// exceed it are truncated instead of silently vanishing.
+const maxChunks = 128
+
+// gelfMagic identifies a chunked GELF datagram, per the GELF 1.1 spec.
+var gelfMagic = [2]byte{0x1e, 0x0f}
+
+// Handler is a slog.Handler that encodes each record as a GELF 1.1 JSON
+// document and sends it to a Graylog (or compatible) UDP input, chunking
+// messages that exceed a single datagram. Every attribute attached via
+// WithAttrs or passed to a log call becomes a GELF "_"-prefixed custom
+// field, so e.g. slog.String("request_id", id) ships as "_request_id".
+type Handler struct {
+	conn     net.Conn
+	hostname string
+	level    slog.Leveler
+
+	// mu guards writes to conn from concurrent Handle calls. It's a pointer
+	// so that Handlers derived via WithAttrs/WithGroup, which still write to
+	// the same conn, share it rather than each serializing against a fresh,
+	// independent lock.
+	mu    *sync.Mutex
+	attrs []slog.Attr
+	group string
+}
+
+// NewHandler dials addr (e.g. "127.0.0.1:12201") over UDP and returns a
+// Handler that ships records there. opts may be nil; only opts.Level is
+// consulted, defaulting to slog.LevelInfo.
+func NewHandler(addr string, opts *slog.HandlerOptions) (*Handler, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("logging: dial gelf %s: %w", addr, err)
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "oxidms-server"
+	}
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	return &Handler{conn: conn, hostname: hostname, level: level, mu: &sync.Mutex{}}, nil
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	doc := map[string]any{
+		"version":       "1.1",
+		"host":          h.hostname,
+		"short_message": r.Message,
+		"timestamp":     float64(r.Time.UnixNano()) / 1e9,
+		"level":         syslogLevel(r.Level),
+	}
+
+	var full strings.Builder
+	addField := func(key string, val any) {
+		key = strings.TrimPrefix(key, "_")
+		if key == "" {
+			return
+		}
+		doc["_"+key] = val
+		fmt.Fprintf(&full, "%s=%v ", key, val)
+	}
+	for _, a := range h.attrs {
+		addField(h.prefixed(a.Key), a.Value.Any())
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addField(h.prefixed(a.Key), a.Value.Any())
+		return true
+	})
+	if full.Len() > 0 {
+		doc["full_message"] = r.Message + "\n" + strings.TrimSpace(full.String())
+	}
+
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("logging: marshal gelf record: %w", err)
+	}
+	return h.send(payload)
+}
+
+func (h *Handler) prefixed(key string) string {
+	if h.group == "" {
+		return key
+	}
+	return h.group + "." + key
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &next
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	next := *h
+	if h.group == "" {
+		next.group = name
+	} else {
+		next.group = h.group + "." + name
+	}
+	return &next
+}
+
+// Close releases the handler's UDP socket.
+func (h *Handler) Close() error {
+	return h.conn.Close()
+}
+
+// send writes payload as a single UDP datagram, or as a sequence of
+// chunked GELF datagrams (per the spec's chunking format) if it's too
+// large for one. Datagrams beyond maxChunks are dropped with the message
+// truncated to fit, rather than silently lost by the receiver.
+func (h *Handler) send(payload []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(payload) <= chunkSize {
+		_, err := h.conn.Write(payload)
+		return err
+	}
+
+	// Gzip before chunking, same as Graylog's own GELF library clients:
+	// most long messages (stack traces, verbose errors) compress well
+	// enough to need fewer chunks, sometimes dropping back to one datagram.
+	if compressed, err := gzipCompress(payload); err == nil {
+		payload = compressed
+		if len(payload) <= chunkSize {
+			_, err := h.conn.Write(payload)
+			return err
+		}
+	}
+
+	total := (len(payload) + chunkSize - 1) / chunkSize
+	if total > maxChunks {
+		total = maxChunks
+		payload = payload[:maxChunks*chunkSize]
+	}
+
+	var msgID [8]byte
+	if _, err := rand.Read(msgID[:]); err != nil {
+		return fmt.Errorf("logging: generate gelf message id: %w", err)
+	}
+
+	for seq := 0; seq < total; seq++ {
+		start := seq * chunkSize
+		end := start + chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		header := make([]byte, 0, 12+end-start)
+		header = append(header, gelfMagic[0], gelfMagic[1])
+		header = append(header, msgID[:]...)
+		header = append(header, byte(seq), byte(total))
+		header = append(header, payload[start:end]...)
+		if _, err := h.conn.Write(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gzipCompress(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// syslogLevel maps slog's levels onto the syslog severities GELF's
+// "level" field expects (lower is more severe).
+func syslogLevel(l slog.Level) int {
+	switch {
+	case l >= slog.LevelError:
+		return 3 // Error
+	case l >= slog.LevelWarn:
+		return 4 // Warning
+	case l >= slog.LevelInfo:
+		return 6 // Informational
+	default:
+		return 7 // Debug
+	}
+}
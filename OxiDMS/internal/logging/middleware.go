@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/middleware"
+)
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Middleware attaches a logger carrying the request ID mw.Logger already
+// generated (see middleware.RequestIDFromContext) to the request context
+// (see FromContext and Tag), and once the request completes emits a single
+// GELF access record carrying the route, status, and latency alongside
+// whatever Tag calls downstream added (e.g. auth.Middleware's user_id,
+// FormHandler's form_id). Must run after mw.Logger in the middleware stack.
+func Middleware(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := middleware.RequestIDFromContext(r.Context())
+			ctx := WithLogger(r.Context(), base.With("request_id", requestID))
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = r.URL.Path
+			}
+			FromContext(ctx).LogAttrs(r.Context(), slog.LevelInfo, "access",
+				slog.String("route", route),
+				slog.String("method", r.Method),
+				slog.Int("status", sw.status),
+				slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+			)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Package metrics is an in-process Prometheus metrics registry: a handful
+// of counters and gauges updated inline by the service layer as requests
+// happen, plus an HTTP latency histogram updated by middleware.Metrics.
+// Registry.Render formats the current values in Prometheus's text
+// exposition format for handler.MetricsHandler to serve at /metrics.
+//
+// There's no vendored client library here, the same zero-dependency
+// stance as authz's policy store: the exposition format is simple enough,
+// and the handful of series this package emits don't need a general
+// instrumentation library to track.
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// numLatencyBuckets is len(latencyBuckets), kept as its own constant since
+// an array length must be a constant expression and latencyBuckets can't
+// be a const (Go has no const slices).
+const numLatencyBuckets = 11
+
+// latencyBuckets are the histogram's upper bounds, in seconds, matching
+// Prometheus client libraries' own DefBuckets so dashboards built against
+// other Go services' metrics still make sense against this one.
+var latencyBuckets = [numLatencyBuckets]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds every metric OxiDMS exposes at /metrics. The zero value
+// is not usable; construct with NewRegistry.
+type Registry struct {
+	formsTotal          int64
+	documentsTotal      int64
+	searchRequestsTotal int64
+
+	subsMu sync.Mutex
+	// subsByForm is oxidms_submissions_total{form="..."}, keyed by form ID
+	// rather than name since IDs are stable across a FormService.Update
+	// rename and names aren't guaranteed unique.
+	subsByForm map[string]int64
+
+	httpMu sync.Mutex
+	// httpBuckets holds, per route/method/code label set, how many
+	// observations fell at or under each of latencyBuckets, plus the
+	// running count and sum Prometheus histograms require.
+	httpBuckets map[httpLabels]*httpHistogram
+
+	// poolInUse is read, not written, by Render: see WithPoolGauge.
+	poolInUse func() int64
+}
+
+type httpLabels struct {
+	route  string
+	method string
+	code   string
+}
+
+type httpHistogram struct {
+	counts [numLatencyBuckets]uint64
+	count  uint64
+	sum    float64
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		subsByForm:  map[string]int64{},
+		httpBuckets: map[httpLabels]*httpHistogram{},
+	}
+}
+
+// WithPoolGauge arranges for oxidms_oxidb_pool_in_use to read fn at scrape
+// time rather than being pushed to, since db.Pool already tracks how many
+// of its clients are healthy and in rotation (main.go passes
+// Pool.HealthyCount) and re-publishing that on every change would mean the
+// db package importing metrics.
+func (r *Registry) WithPoolGauge(fn func() int64) {
+	r.poolInUse = fn
+}
+
+// IncForms and DecForms track oxidms_forms_total as FormService creates
+// and deletes forms.
+func (r *Registry) IncForms() { atomic.AddInt64(&r.formsTotal, 1) }
+func (r *Registry) DecForms() { atomic.AddInt64(&r.formsTotal, -1) }
+
+// IncDocuments and DecDocuments track oxidms_documents_total as
+// DocumentService uploads and deletes documents.
+func (r *Registry) IncDocuments() { atomic.AddInt64(&r.documentsTotal, 1) }
+func (r *Registry) DecDocuments() { atomic.AddInt64(&r.documentsTotal, -1) }
+
+// IncSearchRequests tracks oxidms_search_requests_total as SearchService
+// answers a query.
+func (r *Registry) IncSearchRequests() { atomic.AddInt64(&r.searchRequestsTotal, 1) }
+
+// IncSubmissions and DecSubmissions track
+// oxidms_submissions_total{form="formID"} as SubmissionService creates and
+// deletes submissions.
+func (r *Registry) IncSubmissions(formID string) { r.addSubmissions(formID, 1) }
+func (r *Registry) DecSubmissions(formID string) { r.addSubmissions(formID, -1) }
+
+func (r *Registry) addSubmissions(formID string, delta int64) {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+	r.subsByForm[formID] += delta
+}
+
+// SubmissionsByForm returns the current oxidms_submissions_total reading
+// for every form it has counted a submission for (a form whose
+// submissions were all deleted stays listed, at 0), for DashboardHandler
+// to read in O(1) per form instead of issuing a CountByForm query per
+// form on every request.
+func (r *Registry) SubmissionsByForm() map[string]int64 {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+	out := make(map[string]int64, len(r.subsByForm))
+	for k, v := range r.subsByForm {
+		out[k] = v
+	}
+	return out
+}
+
+// FormsTotal and DocumentsTotal expose the current gauge readings for
+// DashboardHandler, so its summary counts come from the same registry
+// /metrics does instead of a second counting path.
+func (r *Registry) FormsTotal() int64     { return atomic.LoadInt64(&r.formsTotal) }
+func (r *Registry) DocumentsTotal() int64 { return atomic.LoadInt64(&r.documentsTotal) }
+
+// ObserveHTTP records one request's duration (in seconds) against the
+// oxidms_http_request_duration_seconds histogram, labeled by route (the
+// chi route pattern, e.g. "/api/v1/forms/{formId}", not the raw path),
+// method, and status code.
+func (r *Registry) ObserveHTTP(route, method string, code int, seconds float64) {
+	labels := httpLabels{route: route, method: method, code: strconv.Itoa(code)}
+
+	r.httpMu.Lock()
+	defer r.httpMu.Unlock()
+	h, ok := r.httpBuckets[labels]
+	if !ok {
+		h = &httpHistogram{}
+		r.httpBuckets[labels] = h
+	}
+	h.count++
+	h.sum += seconds
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+}
+
+// Render writes every metric currently tracked in Prometheus text
+// exposition format.
+func (r *Registry) Render() string {
+	var b strings.Builder
+
+	writeGauge(&b, "oxidms_forms_total", "Total number of forms.", float64(r.FormsTotal()))
+	writeGauge(&b, "oxidms_documents_total", "Total number of documents.", float64(r.DocumentsTotal()))
+	writeCounter(&b, "oxidms_search_requests_total", "Total number of search queries served.", float64(atomic.LoadInt64(&r.searchRequestsTotal)))
+
+	fmt.Fprintf(&b, "# HELP oxidms_submissions_total Total number of submissions, by form.\n")
+	fmt.Fprintf(&b, "# TYPE oxidms_submissions_total counter\n")
+	for _, formID := range sortedKeys(r.SubmissionsByForm()) {
+		fmt.Fprintf(&b, "oxidms_submissions_total{form=%q} %d\n", formID, r.SubmissionsByForm()[formID])
+	}
+
+	if r.poolInUse != nil {
+		writeGauge(&b, "oxidms_oxidb_pool_in_use", "Number of pooled OxiDB connections currently healthy and in rotation.", float64(r.poolInUse()))
+	}
+
+	r.writeHTTPHistogram(&b)
+
+	return b.String()
+}
+
+func (r *Registry) writeHTTPHistogram(b *strings.Builder) {
+	r.httpMu.Lock()
+	defer r.httpMu.Unlock()
+
+	fmt.Fprintf(b, "# HELP oxidms_http_request_duration_seconds HTTP request latency in seconds, by route, method, and status code.\n")
+	fmt.Fprintf(b, "# TYPE oxidms_http_request_duration_seconds histogram\n")
+
+	labelSets := make([]httpLabels, 0, len(r.httpBuckets))
+	for l := range r.httpBuckets {
+		labelSets = append(labelSets, l)
+	}
+	sort.Slice(labelSets, func(i, j int) bool {
+		if labelSets[i].route != labelSets[j].route {
+			return labelSets[i].route < labelSets[j].route
+		}
+		if labelSets[i].method != labelSets[j].method {
+			return labelSets[i].method < labelSets[j].method
+		}
+		return labelSets[i].code < labelSets[j].code
+	})
+
+	for _, l := range labelSets {
+		h := r.httpBuckets[l]
+		base := fmt.Sprintf("route=%q,method=%q,code=%q", l.route, l.method, l.code)
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(b, "oxidms_http_request_duration_seconds_bucket{%s,le=%q} %d\n", base, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(b, "oxidms_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", base, h.count)
+		fmt.Fprintf(b, "oxidms_http_request_duration_seconds_sum{%s} %s\n", base, formatFloat(h.sum))
+		fmt.Fprintf(b, "oxidms_http_request_duration_seconds_count{%s} %d\n", base, h.count)
+	}
+}
+
+func writeGauge(b *strings.Builder, name, help string, v float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(b, "%s %s\n", name, formatFloat(v))
+}
+
+func writeCounter(b *strings.Builder, name, help string, v float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+	fmt.Fprintf(b, "%s %s\n", name, formatFloat(v))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
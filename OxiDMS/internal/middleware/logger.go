@@ -1,17 +1,36 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
+)
+
+type contextKey string
+
+const (
+	requestIDKey    contextKey = "requestId"
+	statusWriterKey contextKey = "statusWriter"
 )
 
+// accessLog emits one JSON event per request; configuring the handler
+// elsewhere would require threading a *slog.Logger through every
+// middleware and handler constructor for no benefit over this package
+// global, since access logging has exactly one call site.
+var accessLog = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
 type statusWriter struct {
 	http.ResponseWriter
 	status int
 	bytes  int
-	user   string
+	userID string
+	email  string
 }
 
 func (w *statusWriter) WriteHeader(code int) {
@@ -25,12 +44,27 @@ func (w *statusWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// SetUser is called by auth middleware to tag the request with the authenticated user.
-func (w *statusWriter) SetUser(u string) {
-	w.user = u
+// TagUser records the authenticated user on the in-flight request so
+// Logger's access-log event includes who made the request. auth.Middleware
+// calls this once it has resolved the caller's Claims; it's a no-op if ctx
+// didn't come from a request Logger wrapped.
+func TagUser(ctx context.Context, userID, email string) {
+	if sw, ok := ctx.Value(statusWriterKey).(*statusWriter); ok {
+		sw.userID = userID
+		sw.email = email
+	}
 }
 
-func clientIP(r *http.Request) string {
+// RequestIDFromContext returns the request ID Logger generated for the
+// in-flight request, or "" if ctx didn't come from one.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// ClientIP returns the caller's address, preferring X-Forwarded-For (as set
+// by a reverse proxy) and X-Real-Ip over the raw connection address.
+func ClientIP(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		if ip := strings.SplitN(xff, ",", 2)[0]; ip != "" {
 			return strings.TrimSpace(ip)
@@ -42,25 +76,42 @@ func clientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
+// Logger generates a request ID, echoes it back in the X-Request-Id
+// response header (so it can be correlated with downstream service logs,
+// error responses, and audit entries — see internal/audit), and emits one
+// structured JSON access-log event per request once it completes.
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+
+		requestID := uuid.NewString()
+		w.Header().Set("X-Request-Id", requestID)
+
 		sw := &statusWriter{ResponseWriter: w, status: 200}
-		next.ServeHTTP(sw, r)
-		dur := time.Since(start).Round(time.Millisecond)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx = context.WithValue(ctx, statusWriterKey, sw)
+		ctx = gelf.WithFields(ctx, "request_id", requestID)
+
+		next.ServeHTTP(sw, r.WithContext(ctx))
 
 		uri := r.URL.Path
 		if r.URL.RawQuery != "" {
 			uri = uri + "?" + r.URL.RawQuery
 		}
 
-		user := "-"
-		if sw.user != "" {
-			user = sw.user
+		attrs := []slog.Attr{
+			slog.String("requestId", requestID),
+			slog.String("method", r.Method),
+			slog.String("path", uri),
+			slog.Int("status", sw.status),
+			slog.Int("bytes", sw.bytes),
+			slog.Duration("latency", time.Since(start).Round(time.Millisecond)),
+			slog.String("ip", ClientIP(r)),
+			slog.String("userAgent", r.Header.Get("User-Agent")),
 		}
-
-		log.Printf("%s %s %d %dB %s | ip=%s user=%s ua=%q",
-			r.Method, uri, sw.status, sw.bytes, dur,
-			clientIP(r), user, r.Header.Get("User-Agent"))
+		if sw.userID != "" {
+			attrs = append(attrs, slog.String("userId", sw.userID), slog.String("userEmail", sw.email))
+		}
+		accessLog.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
 	})
 }
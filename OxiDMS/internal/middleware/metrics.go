@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeObserver is the subset of metrics.Registry Metrics needs; defined
+// here rather than importing metrics directly so internal/middleware
+// doesn't have to depend on internal/metrics for its other, unrelated
+// middleware (Recovery, Logger, CORS).
+type routeObserver interface {
+	ObserveHTTP(route, method string, code int, seconds float64)
+}
+
+// Metrics times every request and reports it to reg, labeled by the
+// matched chi route pattern (e.g. "/api/v1/forms/{formId}", not the raw
+// path, so distinct form IDs don't each get their own time series). The
+// pattern is only fully built once chi has finished matching nested
+// routers, so it's read after next.ServeHTTP returns rather than before.
+func Metrics(reg routeObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			sw, ok := w.(*statusWriter)
+			if !ok {
+				sw = &statusWriter{ResponseWriter: w, status: 200}
+				w = sw
+			}
+
+			next.ServeHTTP(w, r)
+
+			route := "unmatched"
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				if p := rc.RoutePattern(); p != "" {
+					route = p
+				}
+			}
+			reg.ObserveHTTP(route, r.Method, sw.status, time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+// FormACL grants Principal — a user ID, or a "group:<name>" string for
+// callers that resolve group membership themselves, since OxiDMS has no
+// built-in group store — a set of Permissions on one form, beyond whatever
+// its role already confers. See internal/authz.Require.
+type FormACL struct {
+	ID          string   `json:"_id,omitempty"`
+	FormID      string   `json:"formId"`
+	Principal   string   `json:"principal"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   string   `json:"createdAt"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// APIToken is a scoped, expirable bearer credential minted by a user via
+// AuthHandler.CreateToken, for external scripts that need narrower access
+// than the user's own login session. The bearer value itself is returned
+// to the caller exactly once, at creation time; only its SHA-256 hash is
+// persisted, so a leaked database dump doesn't hand out live credentials.
+type APIToken struct {
+	ID          string   `json:"_id,omitempty"`
+	Name        string   `json:"name"`
+	UserID      string   `json:"userId"`
+	TokenHash   string   `json:"tokenHash"`
+	Permissions []string `json:"permissions"`
+	Revoked     bool     `json:"revoked"`
+	ExpiresAt   string   `json:"expiresAt,omitempty"`
+	CreatedAt   string   `json:"createdAt"`
+}
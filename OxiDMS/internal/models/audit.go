@@ -0,0 +1,20 @@
+package models
+
+// AuditEntry records one mutating action against the system for the admin
+// audit trail and per-user activity feed (see internal/audit.Recorder).
+// Before/After hold the resource's state immediately before/after the
+// action; whichever side doesn't apply (Before on create, After on
+// delete) is left nil.
+type AuditEntry struct {
+	ID         string `json:"_id,omitempty"`
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	Resource   string `json:"resource"`
+	ResourceID string `json:"resourceId,omitempty"`
+	Before     any    `json:"before,omitempty"`
+	After      any    `json:"after,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	UA         string `json:"ua,omitempty"`
+	RequestID  string `json:"requestId,omitempty"`
+	Timestamp  string `json:"ts"`
+}
@@ -11,6 +11,7 @@ type FieldDefinition struct {
 	Placeholder string   `json:"placeholder,omitempty"`
 	Options     []string `json:"options,omitempty"`
 	Indexed     bool     `json:"indexed,omitempty"`
+	Searchable  bool     `json:"searchable,omitempty"`
 	MinLength   *int     `json:"minLength,omitempty"`
 	MaxLength   *int     `json:"maxLength,omitempty"`
 	Min         *float64 `json:"min,omitempty"`
@@ -31,6 +32,12 @@ type Form struct {
 	CreatedBy   string           `json:"createdBy"`
 	CreatedAt   string           `json:"createdAt"`
 	UpdatedAt   string           `json:"updatedAt"`
+
+	// Public marks a form (and its submission-confirmation page) as safe to
+	// list in sitemap.xml and crawl; see handler.SitemapHandler. Defaults
+	// to false, so forms built for internal use aren't indexed by search
+	// engines just because they exist.
+	Public bool `json:"public,omitempty"`
 }
 
 // TypedFields converts the raw field maps to typed FieldDefinition structs.
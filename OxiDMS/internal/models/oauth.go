@@ -0,0 +1,39 @@
+package models
+
+// OAuthClient is a registered OAuth2 client application allowed to sign
+// users in through OxiDMS and act on their behalf within Scopes.
+type OAuthClient struct {
+	ID           string   `json:"_id,omitempty"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+	CreatedAt    string   `json:"createdAt"`
+}
+
+// OAuthTokenKind discriminates the records stored in the shared
+// _dms_oauth_tokens collection: authorization codes, access tokens, and
+// refresh tokens all go through the same issue/lookup/revoke lifecycle.
+type OAuthTokenKind string
+
+const (
+	OAuthKindCode    OAuthTokenKind = "code"
+	OAuthKindAccess  OAuthTokenKind = "access"
+	OAuthKindRefresh OAuthTokenKind = "refresh"
+)
+
+// OAuthToken is one authorization code, access token, or refresh token
+// issued by the oauth package.
+type OAuthToken struct {
+	ID          string         `json:"_id,omitempty"`
+	Kind        OAuthTokenKind `json:"kind"`
+	Token       string         `json:"token"`
+	ClientID    string         `json:"clientId"`
+	UserID      string         `json:"userId"`
+	RedirectURI string         `json:"redirectUri,omitempty"`
+	Scope       string         `json:"scope"`
+	Revoked     bool           `json:"revoked"`
+	ExpiresAt   string         `json:"expiresAt"`
+	CreatedAt   string         `json:"createdAt"`
+}
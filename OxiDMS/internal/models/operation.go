@@ -0,0 +1,17 @@
+package models
+
+// OperationRecord is the persisted snapshot of a long-running background
+// job (index builds, compaction, reindexing), mirrored into
+// _dms_operations so its last known state survives a restart. The live
+// operations.Operation that produces these snapshots also tracks a cancel
+// func and SSE subscribers, which aren't meaningful across a restart and
+// so aren't part of the persisted shape.
+type OperationRecord struct {
+	ID         string `json:"_id,omitempty"`
+	Type       string `json:"type"`
+	Status     string `json:"status"` // pending, running, success, failure, cancelled
+	Progress   int    `json:"progress"`
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	Err        string `json:"err,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// Session is the long-lived half of the access/refresh token pair issued
+// by AuthService.Login: a refresh token, stored hashed so a leaked
+// database dump doesn't hand out live sessions. RevokedAt is set by
+// logout, logout-all, or refresh rotation (the consumed row is revoked
+// rather than deleted, so auth.Middleware's revocation cache can still
+// reject an access token minted from it until ExpiresAt passes).
+type Session struct {
+	ID        string `json:"_id,omitempty"`
+	UserID    string `json:"userId"`
+	TokenHash string `json:"tokenHash"`
+	IssuedAt  string `json:"issuedAt"`
+	ExpiresAt string `json:"expiresAt"`
+	RevokedAt string `json:"revokedAt,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
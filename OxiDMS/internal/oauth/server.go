@@ -0,0 +1,323 @@
+// Package oauth implements an OAuth2/OIDC identity provider mode for
+// OxiDMS: registered client applications can obtain opaque access/refresh
+// tokens for a signed-in user via the authorization code grant, in addition
+// to OxiDMS issuing its own JWTs from AuthService.Login.
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+// Scopes a client can be registered for. ScopeAdmin implies every other
+// scope (see auth.RequireScope).
+const (
+	ScopeFormsRead        = "forms:read"
+	ScopeSubmissionsWrite = "submissions:write"
+	ScopeDocumentsAll     = "documents:*"
+	ScopeAdmin            = "admin"
+)
+
+const (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+	authCodeTTL     = 5 * time.Minute
+)
+
+// Server is an OAuth2/OIDC identity provider backed by ClientRepo and
+// OAuthTokenRepo. It implements auth.TokenLookup so auth.Middleware can
+// resolve the opaque access tokens it issues.
+type Server struct {
+	clients *repository.ClientRepo
+	tokens  *repository.OAuthTokenRepo
+	users   *repository.UserRepo
+	issuer  string
+}
+
+// NewServer builds a Server. issuer is this OxiDMS instance's externally
+// reachable base URL, used in the OIDC discovery document.
+func NewServer(clients *repository.ClientRepo, tokens *repository.OAuthTokenRepo, users *repository.UserRepo, issuer string) *Server {
+	return &Server{clients: clients, tokens: tokens, users: users, issuer: issuer}
+}
+
+// TokenResponse is the RFC 6749 §5.1 access token response.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// IntrospectResponse is the RFC 7662 token introspection response.
+type IntrospectResponse struct {
+	Active   bool   `json:"active"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	UserID   string `json:"sub,omitempty"`
+	Exp      int64  `json:"exp,omitempty"`
+}
+
+// CreateClient registers a new OAuth2 client application.
+func (s *Server) CreateClient(name string, redirectURIs, scopes []string) (*models.OAuthClient, error) {
+	return s.clients.Create(name, redirectURIs, scopes)
+}
+
+// ListClients returns every registered OAuth2 client.
+func (s *Server) ListClients() ([]*models.OAuthClient, error) {
+	return s.clients.List()
+}
+
+// RevokeClient deletes a registered client; existing tokens it issued keep
+// working until they expire or are explicitly revoked.
+func (s *Server) RevokeClient(clientID string) error {
+	return s.clients.Revoke(clientID)
+}
+
+// Authorize validates clientID/redirectURI/scope against the registered
+// client and issues a short-lived, single-use authorization code for
+// userID, per the OAuth2 authorization code grant (RFC 6749 §4.1.1).
+func (s *Server) Authorize(clientID, redirectURI, scope, userID string) (string, error) {
+	client, err := s.clients.FindByClientID(clientID)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		return "", errors.New("unknown client")
+	}
+	if !containsStr(client.RedirectURIs, redirectURI) {
+		return "", errors.New("redirect_uri does not match registered client")
+	}
+	if err := validateScope(client.Scopes, scope); err != nil {
+		return "", err
+	}
+
+	code, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	return code, s.tokens.Create(&models.OAuthToken{
+		Kind:        models.OAuthKindCode,
+		Token:       code,
+		ClientID:    clientID,
+		UserID:      userID,
+		RedirectURI: redirectURI,
+		Scope:       scope,
+		ExpiresAt:   now.Add(authCodeTTL).Format(time.RFC3339),
+		CreatedAt:   now.Format(time.RFC3339),
+	})
+}
+
+// Exchange implements the token endpoint for both the authorization_code
+// and refresh_token grants (RFC 6749 §4.1.3, §6).
+func (s *Server) Exchange(grantType, clientID, clientSecret, codeOrRefresh, redirectURI string) (*TokenResponse, error) {
+	client, err := s.clients.FindByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.ClientSecret != clientSecret {
+		return nil, errors.New("invalid client credentials")
+	}
+
+	switch grantType {
+	case "authorization_code":
+		return s.exchangeCode(client, codeOrRefresh, redirectURI)
+	case "refresh_token":
+		return s.exchangeRefresh(client, codeOrRefresh)
+	default:
+		return nil, fmt.Errorf("unsupported grant_type %q", grantType)
+	}
+}
+
+func (s *Server) exchangeCode(client *models.OAuthClient, code, redirectURI string) (*TokenResponse, error) {
+	rec, err := s.tokens.FindByToken(code)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || rec.Kind != models.OAuthKindCode || rec.Revoked {
+		return nil, errors.New("invalid authorization code")
+	}
+	if rec.ClientID != client.ClientID || rec.RedirectURI != redirectURI {
+		return nil, errors.New("authorization code does not match client or redirect_uri")
+	}
+	if expired(rec.ExpiresAt) {
+		return nil, errors.New("authorization code expired")
+	}
+	// Authorization codes are single-use.
+	_ = s.tokens.DeleteByToken(code)
+
+	return s.issueTokens(client.ClientID, rec.UserID, rec.Scope)
+}
+
+func (s *Server) exchangeRefresh(client *models.OAuthClient, refreshToken string) (*TokenResponse, error) {
+	rec, err := s.tokens.FindByToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || rec.Kind != models.OAuthKindRefresh || rec.Revoked {
+		return nil, errors.New("invalid refresh token")
+	}
+	if rec.ClientID != client.ClientID {
+		return nil, errors.New("refresh token does not belong to client")
+	}
+	if expired(rec.ExpiresAt) {
+		return nil, errors.New("refresh token expired")
+	}
+	return s.issueTokens(client.ClientID, rec.UserID, rec.Scope)
+}
+
+func (s *Server) issueTokens(clientID, userID, scope string) (*TokenResponse, error) {
+	access, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if err := s.tokens.Create(&models.OAuthToken{
+		Kind:      models.OAuthKindAccess,
+		Token:     access,
+		ClientID:  clientID,
+		UserID:    userID,
+		Scope:     scope,
+		ExpiresAt: now.Add(accessTokenTTL).Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Create(&models.OAuthToken{
+		Kind:      models.OAuthKindRefresh,
+		Token:     refresh,
+		ClientID:  clientID,
+		UserID:    userID,
+		Scope:     scope,
+		ExpiresAt: now.Add(refreshTokenTTL).Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+		RefreshToken: refresh,
+		Scope:        scope,
+	}, nil
+}
+
+// Revoke marks token (access or refresh) as revoked (RFC 7009).
+func (s *Server) Revoke(token string) error {
+	return s.tokens.Revoke(token)
+}
+
+// Introspect reports whether token is a currently-active access token
+// (RFC 7662).
+func (s *Server) Introspect(token string) (*IntrospectResponse, error) {
+	rec, err := s.tokens.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || rec.Revoked || rec.Kind != models.OAuthKindAccess || expired(rec.ExpiresAt) {
+		return &IntrospectResponse{Active: false}, nil
+	}
+	exp, _ := time.Parse(time.RFC3339, rec.ExpiresAt)
+	return &IntrospectResponse{
+		Active:   true,
+		Scope:    rec.Scope,
+		ClientID: rec.ClientID,
+		UserID:   rec.UserID,
+		Exp:      exp.Unix(),
+	}, nil
+}
+
+// LookupAccessToken implements auth.TokenLookup, resolving an opaque access
+// token to the Claims auth.Middleware attaches to the request context.
+func (s *Server) LookupAccessToken(token string) (*auth.Claims, bool) {
+	rec, err := s.tokens.FindByToken(token)
+	if err != nil || rec == nil || rec.Revoked || rec.Kind != models.OAuthKindAccess || expired(rec.ExpiresAt) {
+		return nil, false
+	}
+	user, err := s.users.FindByID(rec.UserID)
+	if err != nil || user == nil {
+		return nil, false
+	}
+	return &auth.Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		Scope:  rec.Scope,
+	}, true
+}
+
+// OpenIDConfiguration returns the minimal OIDC discovery document for this
+// issuer (served at /.well-known/openid-configuration).
+func (s *Server) OpenIDConfiguration() map[string]any {
+	return map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/api/v1/oauth/authorize",
+		"token_endpoint":                        s.issuer + "/api/v1/oauth/token",
+		"revocation_endpoint":                   s.issuer + "/api/v1/oauth/revoke",
+		"introspection_endpoint":                s.issuer + "/api/v1/oauth/introspect",
+		"jwks_uri":                              s.issuer + "/.well-known/jwks.json",
+		"scopes_supported":                      []string{ScopeFormsRead, ScopeSubmissionsWrite, ScopeDocumentsAll, ScopeAdmin},
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_post"},
+	}
+}
+
+// JWKS returns an empty JWK Set. OxiDMS resolves access tokens opaquely
+// server-side (via LookupAccessToken) rather than issuing them as signed
+// JWTs, so there are no public signing keys to publish; the endpoint exists
+// so OIDC clients that probe it unconditionally get a well-formed response
+// instead of a 404.
+func JWKS() map[string]any {
+	return map[string]any{"keys": []any{}}
+}
+
+func containsStr(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validateScope ensures every scope token requested is one the client was
+// registered for.
+func validateScope(allowed []string, requested string) error {
+	for _, scope := range strings.Fields(requested) {
+		if !containsStr(allowed, scope) {
+			return fmt.Errorf("client is not authorized for scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func expired(rfc3339 string) bool {
+	t, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return true
+	}
+	return time.Now().UTC().After(t)
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,250 @@
+// Package operations implements an LXD-style registry of asynchronous
+// background jobs. The server's index builds, compaction, and reindexing
+// used to only surface progress via log.Printf; wrapping them in an
+// Operation instead gives callers a typed Status/Progress they can poll,
+// stream over SSE, or cancel, and a models.OperationRecord snapshot
+// mirrored into OxiDB so GET-by-ID still works after a restart even
+// though the in-process registry itself doesn't survive one.
+package operations
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSuccess   = "success"
+	StatusFailure   = "failure"
+	StatusCancelled = "cancelled"
+)
+
+// Job types, used by handlers and main.go to label the operations they
+// start; kept here since they're part of the operations API, not any one
+// caller's private concern.
+const (
+	TypeInit     = "init"
+	TypeCompact  = "compact"
+	TypeReindex  = "reindex"
+	TypeBulkLoad = "bulk_load"
+)
+
+// Operation tracks one background job's live state: handlers read it via
+// Snapshot (for GET) or Subscribe (for the SSE stream), and the goroutine
+// running the job updates it via SetProgress as it makes headway.
+type Operation struct {
+	id     string
+	opType string
+
+	mu         sync.Mutex
+	status     string
+	progress   int
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error
+	cancel     context.CancelFunc
+	subs       map[chan models.OperationRecord]struct{}
+
+	registry *Registry
+}
+
+func (op *Operation) ID() string { return op.id }
+
+// SetProgress updates the operation's percent-complete (0-100) and wakes
+// any SSE subscribers. Callers set it at whatever checkpoints make sense
+// for their job; there's no enforced monotonicity since a job may not
+// know its total work up front.
+func (op *Operation) SetProgress(pct int) {
+	op.mu.Lock()
+	op.progress = pct
+	op.mu.Unlock()
+	op.persist()
+}
+
+// Cancel requests that the operation stop by cancelling the ctx passed to
+// its fn. This is best-effort, not guaranteed: none of the jobs wrapped so
+// far (EnsureIndexes, Compact, Reindex) poll ctx mid-step, only between
+// steps, so a cancel can take as long as the step in flight when it's
+// called.
+func (op *Operation) Cancel() {
+	op.mu.Lock()
+	cancel := op.cancel
+	op.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (op *Operation) setStatus(s string) {
+	op.mu.Lock()
+	op.status = s
+	op.mu.Unlock()
+	op.persist()
+}
+
+func (op *Operation) finish(status string, err error) {
+	op.mu.Lock()
+	op.status = status
+	op.finishedAt = time.Now().UTC()
+	if status == StatusSuccess {
+		op.progress = 100
+	}
+	op.err = err
+	op.mu.Unlock()
+	op.persist()
+}
+
+// Snapshot returns the operation's current state in the shape persisted
+// to OxiDB and returned from the API.
+func (op *Operation) Snapshot() models.OperationRecord {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.snapshotLocked()
+}
+
+// Subscribe registers a channel that receives the operation's snapshot on
+// every state change until unsubscribe is called. The channel is
+// buffered so a slow SSE client can't stall the job goroutine; a
+// subscriber that falls behind only misses intermediate snapshots, since
+// the terminal one is always sent after every field it reports is final.
+func (op *Operation) Subscribe() (ch chan models.OperationRecord, unsubscribe func()) {
+	ch = make(chan models.OperationRecord, 8)
+	op.mu.Lock()
+	op.subs[ch] = struct{}{}
+	snap := op.snapshotLocked()
+	op.mu.Unlock()
+	ch <- snap
+	return ch, func() {
+		op.mu.Lock()
+		delete(op.subs, ch)
+		op.mu.Unlock()
+	}
+}
+
+func (op *Operation) snapshotLocked() models.OperationRecord {
+	rec := models.OperationRecord{
+		ID:        op.id,
+		Type:      op.opType,
+		Status:    op.status,
+		Progress:  op.progress,
+		StartedAt: op.startedAt.Format(time.RFC3339),
+	}
+	if !op.finishedAt.IsZero() {
+		rec.FinishedAt = op.finishedAt.Format(time.RFC3339)
+	}
+	if op.err != nil {
+		rec.Err = op.err.Error()
+	}
+	return rec
+}
+
+// persist mirrors the operation's current state into OxiDB and wakes any
+// SSE subscribers with the same snapshot.
+func (op *Operation) persist() {
+	rec := op.Snapshot()
+	if err := op.registry.repo.Upsert(&rec); err != nil {
+		log.Printf("operations: persist %s (%s): %v", op.id, op.opType, err)
+	}
+	op.mu.Lock()
+	subs := make([]chan models.OperationRecord, 0, len(op.subs))
+	for ch := range op.subs {
+		subs = append(subs, ch)
+	}
+	op.mu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- rec:
+		default:
+			// Buffer full: drop the oldest queued snapshot so this one,
+			// possibly the terminal one, still gets through.
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- rec:
+			default:
+			}
+		}
+	}
+}
+
+// Registry is the set of operations started by this process. List/Get
+// only see operations started since the last restart; GET-by-ID for an
+// older operation falls back to OperationRepo directly (see
+// handler.OperationHandler), since the registry itself isn't persisted.
+type Registry struct {
+	mu   sync.Mutex
+	ops  map[string]*Operation
+	repo *repository.OperationRepo
+}
+
+func NewRegistry(repo *repository.OperationRepo) *Registry {
+	return &Registry{ops: make(map[string]*Operation), repo: repo}
+}
+
+// Start creates a pending Operation of the given type and runs fn in its
+// own goroutine, transitioning it to running and then, once fn returns,
+// to success, failure, or cancelled (if fn returns ctx.Err() after Cancel
+// was called).
+func (reg *Registry) Start(opType string, fn func(ctx context.Context, op *Operation) error) *Operation {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &Operation{
+		id:        uuid.NewString(),
+		opType:    opType,
+		status:    StatusPending,
+		startedAt: time.Now().UTC(),
+		cancel:    cancel,
+		subs:      make(map[chan models.OperationRecord]struct{}),
+		registry:  reg,
+	}
+	reg.mu.Lock()
+	reg.ops[op.id] = op
+	reg.mu.Unlock()
+	op.persist()
+
+	go func() {
+		op.setStatus(StatusRunning)
+		err := fn(ctx, op)
+		switch {
+		case errors.Is(err, context.Canceled):
+			op.finish(StatusCancelled, nil)
+		case err != nil:
+			log.Printf("operations: %s (%s) failed: %v", op.id, op.opType, err)
+			op.finish(StatusFailure, err)
+		default:
+			op.finish(StatusSuccess, nil)
+		}
+	}()
+	return op
+}
+
+// Get returns the operation by ID if this process started it, or nil if
+// not (including if it was started before the last restart).
+func (reg *Registry) Get(id string) *Operation {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.ops[id]
+}
+
+// List returns every operation this process has started, most recently
+// started first.
+func (reg *Registry) List() []*Operation {
+	reg.mu.Lock()
+	ops := make([]*Operation, 0, len(reg.ops))
+	for _, op := range reg.ops {
+		ops = append(ops, op)
+	}
+	reg.mu.Unlock()
+	sort.Slice(ops, func(i, j int) bool { return ops[i].startedAt.After(ops[j].startedAt) })
+	return ops
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const APITokensCollection = "_dms_api_tokens"
+
+// APITokenRepo stores scoped API tokens minted by authz.APITokens, keyed
+// by the SHA-256 hash of the bearer value so the cleartext token is never
+// persisted.
+type APITokenRepo struct {
+	pool *db.Pool
+}
+
+func NewAPITokenRepo(pool *db.Pool) *APITokenRepo {
+	return &APITokenRepo{pool: pool}
+}
+
+func (r *APITokenRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(APITokensCollection, "tokenHash")
+	})
+}
+
+func (r *APITokenRepo) Create(tok *models.APIToken) error {
+	doc := map[string]any{
+		"name":        tok.Name,
+		"userId":      tok.UserID,
+		"tokenHash":   tok.TokenHash,
+		"permissions": tok.Permissions,
+		"revoked":     tok.Revoked,
+		"expiresAt":   tok.ExpiresAt,
+		"createdAt":   tok.CreatedAt,
+	}
+	return r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(APITokensCollection, doc)
+		if err != nil {
+			return err
+		}
+		tok.ID = extractID(result)
+		return nil
+	})
+}
+
+func (r *APITokenRepo) FindByHash(hash string) (*models.APIToken, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(APITokensCollection, map[string]any{"tokenHash": hash})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToAPIToken(doc)
+}
+
+func docToAPIToken(doc map[string]any) (*models.APIToken, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal api token doc: %w", err)
+	}
+	var tok models.APIToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return nil, fmt.Errorf("unmarshal api token: %w", err)
+	}
+	return &tok, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const AuditCollection = "_dms_audit"
+
+type AuditRepo struct {
+	pool *db.Pool
+}
+
+func NewAuditRepo(pool *db.Pool) *AuditRepo {
+	return &AuditRepo{pool: pool}
+}
+
+func (r *AuditRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		if err := c.CreateIndex(AuditCollection, "actor"); err != nil {
+			return err
+		}
+		if err := c.CreateIndex(AuditCollection, "resource"); err != nil {
+			return err
+		}
+		return c.CreateIndex(AuditCollection, "ts")
+	})
+}
+
+func (r *AuditRepo) Create(entry *models.AuditEntry) error {
+	doc := auditToDoc(entry)
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Insert(AuditCollection, doc)
+		return err
+	})
+}
+
+// AuditFilter scopes a List query; zero-value fields are left unrestricted.
+type AuditFilter struct {
+	Actor    string
+	Resource string
+	From     string // RFC3339, inclusive
+	To       string // RFC3339, inclusive
+}
+
+func (r *AuditRepo) List(filter AuditFilter, skip, limit int) ([]models.AuditEntry, int, error) {
+	query := buildAuditQuery(filter)
+
+	var total int
+	var docs []map[string]any
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(AuditCollection, query)
+		if err != nil {
+			return err
+		}
+		docs, err = c.Find(AuditCollection, query, &oxidb.FindOptions{
+			Sort:  map[string]any{"ts": -1},
+			Skip:  &skip,
+			Limit: &limit,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]models.AuditEntry, 0, len(docs))
+	for _, d := range docs {
+		e, err := docToAudit(d)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	return entries, total, nil
+}
+
+func buildAuditQuery(filter AuditFilter) map[string]any {
+	conditions := []any{}
+	if filter.Actor != "" {
+		conditions = append(conditions, map[string]any{"actor": filter.Actor})
+	}
+	if filter.Resource != "" {
+		conditions = append(conditions, map[string]any{"resource": filter.Resource})
+	}
+	if filter.From != "" {
+		conditions = append(conditions, map[string]any{"ts": map[string]any{"$gte": filter.From}})
+	}
+	if filter.To != "" {
+		conditions = append(conditions, map[string]any{"ts": map[string]any{"$lte": filter.To}})
+	}
+	if len(conditions) == 0 {
+		return map[string]any{}
+	}
+	if len(conditions) == 1 {
+		return conditions[0].(map[string]any)
+	}
+	return map[string]any{"$and": conditions}
+}
+
+func auditToDoc(e *models.AuditEntry) map[string]any {
+	data, _ := json.Marshal(e)
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	delete(doc, "_id")
+	return doc
+}
+
+func docToAudit(doc map[string]any) (*models.AuditEntry, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit doc: %w", err)
+	}
+	var e models.AuditEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("unmarshal audit entry: %w", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// BlobRefCollection tracks how many Document rows point at each
+// content-addressed blob key, so DocumentService only deletes the
+// underlying object once the last reference to it is gone.
+const BlobRefCollection = "_dms_blob_refs"
+
+type BlobRefRepo struct {
+	pool *db.Pool
+}
+
+func NewBlobRefRepo(pool *db.Pool) *BlobRefRepo {
+	return &BlobRefRepo{pool: pool}
+}
+
+func (r *BlobRefRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(BlobRefCollection, "key")
+	})
+}
+
+// Acquire records a new reference to key, creating its ref-count row on the
+// first reference, and returns the resulting count. The read-modify-write
+// runs inside a transaction so two uploads of identical content racing to
+// create the row can't both see "no row yet" and duplicate it.
+func (r *BlobRefRepo) Acquire(key string) (int, error) {
+	count := 0
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		return c.WithTransaction(func() error {
+			existing, err := c.FindOne(BlobRefCollection, map[string]any{"key": key})
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				count = 1
+				_, err := c.Insert(BlobRefCollection, map[string]any{"key": key, "refCount": count})
+				return err
+			}
+			count = asInt(existing["refCount"]) + 1
+			_, err = c.UpdateOne(BlobRefCollection, map[string]any{"key": key}, map[string]any{"$set": map[string]any{"refCount": count}})
+			return err
+		})
+	})
+	return count, err
+}
+
+// Release drops a reference to key and returns the resulting count; 0
+// means the caller dropped the last reference and should delete the blob
+// itself. Releasing a key with no tracked references is a no-op.
+func (r *BlobRefRepo) Release(key string) (int, error) {
+	count := 0
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		return c.WithTransaction(func() error {
+			existing, err := c.FindOne(BlobRefCollection, map[string]any{"key": key})
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				return nil
+			}
+			count = asInt(existing["refCount"]) - 1
+			if count <= 0 {
+				count = 0
+				_, err := c.Delete(BlobRefCollection, map[string]any{"key": key})
+				return err
+			}
+			_, err = c.UpdateOne(BlobRefCollection, map[string]any{"key": key}, map[string]any{"$set": map[string]any{"refCount": count}})
+			return err
+		})
+	})
+	return count, err
+}
+
+func asInt(v any) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// defaultBulkOpCap is the maximum number of consecutive Insert ops coalesced
+// into a single InsertMany call before a new batch is started.
+const defaultBulkOpCap = 1000
+
+type bulkOpKind int
+
+const (
+	bulkInsert bulkOpKind = iota
+	bulkUpdate
+	bulkUpsert
+	bulkRemove
+)
+
+type bulkItem struct {
+	kind   bulkOpKind
+	doc    map[string]any // Insert, Upsert
+	filter map[string]any // Update, Upsert, Remove
+	update map[string]any // Update
+}
+
+// BulkError records a failure for one operation in a BulkOp.Run(), tagged
+// with its index in submission order.
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("bulk op %d: %v", e.Index, e.Err)
+}
+
+// BulkResult summarizes the outcome of a BulkOp.Run() call.
+type BulkResult struct {
+	Matched     int
+	Modified    int
+	Inserted    int
+	Removed     int
+	UpsertedIDs []string
+	Errors      []BulkError
+}
+
+// BulkOp is a builder for batched document writes against DocumentsCollection,
+// modelled on the mgo.v2 Bulk API. Obtain one via DocumentRepo.Bulk().
+type BulkOp struct {
+	repo      *DocumentRepo
+	ops       []bulkItem
+	unordered bool
+	opCap     int
+}
+
+// Bulk returns a new BulkOp builder. By default operations run in ordered
+// mode: Run stops at the first failure.
+func (r *DocumentRepo) Bulk() *BulkOp {
+	return &BulkOp{repo: r, opCap: defaultBulkOpCap}
+}
+
+// Unordered makes Run continue past per-op failures instead of stopping at
+// the first one, collecting every failure in the returned BulkResult.
+func (b *BulkOp) Unordered() *BulkOp {
+	b.unordered = true
+	return b
+}
+
+// Insert queues a document insert. Consecutive Insert calls are coalesced
+// into a single InsertMany call (up to the op cap) when Run executes.
+func (b *BulkOp) Insert(doc map[string]any) *BulkOp {
+	b.ops = append(b.ops, bulkItem{kind: bulkInsert, doc: doc})
+	return b
+}
+
+// Update queues an update of every document matching filter.
+func (b *BulkOp) Update(filter, update map[string]any) *BulkOp {
+	b.ops = append(b.ops, bulkItem{kind: bulkUpdate, filter: filter, update: update})
+	return b
+}
+
+// Upsert queues an update-or-insert: if filter matches no document, doc is
+// inserted instead.
+func (b *BulkOp) Upsert(filter, doc map[string]any) *BulkOp {
+	b.ops = append(b.ops, bulkItem{kind: bulkUpsert, filter: filter, doc: doc})
+	return b
+}
+
+// Remove queues removal of every document matching filter.
+func (b *BulkOp) Remove(filter map[string]any) *BulkOp {
+	b.ops = append(b.ops, bulkItem{kind: bulkRemove, filter: filter})
+	return b
+}
+
+// Run executes the queued operations in submission order. Consecutive
+// Insert ops are coalesced into batched InsertMany calls up to the op cap;
+// Update/Upsert/Remove ops run one at a time since the backend has no
+// batch-update endpoint. In ordered mode (the default) Run stops at the
+// first error and returns it as a *BulkError; in unordered mode it keeps
+// going and reports every failure via BulkResult.Errors.
+func (b *BulkOp) Run() (BulkResult, error) {
+	var result BulkResult
+
+	// fail records a per-op error. It returns true when Run should stop.
+	fail := func(index int, err error) bool {
+		result.Errors = append(result.Errors, BulkError{Index: index, Err: err})
+		return !b.unordered
+	}
+	lastErr := func() error {
+		return &result.Errors[len(result.Errors)-1]
+	}
+
+	err := b.repo.pool.Do(func(c *oxidb.Client) error {
+		i := 0
+		for i < len(b.ops) {
+			op := b.ops[i]
+			switch op.kind {
+			case bulkInsert:
+				j := i
+				batch := make([]map[string]any, 0, b.opCap)
+				for j < len(b.ops) && b.ops[j].kind == bulkInsert && len(batch) < b.opCap {
+					batch = append(batch, b.ops[j].doc)
+					j++
+				}
+				if _, err := c.InsertMany(DocumentsCollection, batch); err != nil {
+					if fail(i, err) {
+						return lastErr()
+					}
+				} else {
+					result.Inserted += len(batch)
+				}
+				i = j
+
+			case bulkUpdate:
+				res, err := c.Update(DocumentsCollection, op.filter, op.update)
+				if err != nil {
+					if fail(i, err) {
+						return lastErr()
+					}
+					i++
+					continue
+				}
+				result.Matched += intField(res, "matched")
+				result.Modified += intField(res, "modified")
+				i++
+
+			case bulkUpsert:
+				res, err := c.Update(DocumentsCollection, op.filter, map[string]any{"$set": op.doc})
+				if err != nil {
+					if fail(i, err) {
+						return lastErr()
+					}
+					i++
+					continue
+				}
+				if matched := intField(res, "matched"); matched > 0 {
+					result.Matched += matched
+					result.Modified += intField(res, "modified")
+					i++
+					continue
+				}
+				insertRes, err := c.Insert(DocumentsCollection, op.doc)
+				if err != nil {
+					if fail(i, err) {
+						return lastErr()
+					}
+					i++
+					continue
+				}
+				result.Inserted++
+				if id, ok := insertRes["id"]; ok {
+					result.UpsertedIDs = append(result.UpsertedIDs, fmt.Sprint(id))
+				}
+				i++
+
+			case bulkRemove:
+				res, err := c.Delete(DocumentsCollection, op.filter)
+				if err != nil {
+					if fail(i, err) {
+						return lastErr()
+					}
+					i++
+					continue
+				}
+				result.Removed += intField(res, "deleted")
+				i++
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func intField(m map[string]any, key string) int {
+	switch v := m[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const OAuthClientsCollection = "_dms_oauth_clients"
+
+// ClientRepo stores registered OAuth2 client applications.
+type ClientRepo struct {
+	pool *db.Pool
+}
+
+func NewClientRepo(pool *db.Pool) *ClientRepo {
+	return &ClientRepo{pool: pool}
+}
+
+func (r *ClientRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(OAuthClientsCollection, "clientId")
+	})
+}
+
+// Create registers a new OAuth2 client with a random client ID/secret pair.
+func (r *ClientRepo) Create(name string, redirectURIs, scopes []string) (*models.OAuthClient, error) {
+	clientID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	doc := map[string]any{
+		"clientId":     client.ClientID,
+		"clientSecret": client.ClientSecret,
+		"name":         client.Name,
+		"redirectUris": client.RedirectURIs,
+		"scopes":       client.Scopes,
+		"createdAt":    client.CreatedAt,
+	}
+	err = r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(OAuthClientsCollection, doc)
+		if err != nil {
+			return err
+		}
+		client.ID = extractID(result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (r *ClientRepo) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(OAuthClientsCollection, map[string]any{"clientId": clientID})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToOAuthClient(doc)
+}
+
+func (r *ClientRepo) List() ([]*models.OAuthClient, error) {
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(OAuthClientsCollection, map[string]any{}, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	clients := make([]*models.OAuthClient, 0, len(docs))
+	for _, doc := range docs {
+		client, err := docToOAuthClient(doc)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+func (r *ClientRepo) Revoke(clientID string) error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Delete(OAuthClientsCollection, map[string]any{"clientId": clientID})
+		return err
+	})
+}
+
+func docToOAuthClient(doc map[string]any) (*models.OAuthClient, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal oauth client doc: %w", err)
+	}
+	var client models.OAuthClient
+	if err := json.Unmarshal(data, &client); err != nil {
+		return nil, fmt.Errorf("unmarshal oauth client: %w", err)
+	}
+	return &client, nil
+}
+
+// randomToken returns a hex-encoded random token of n random bytes, used
+// by ClientRepo.Create for client IDs and secrets.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,52 +3,66 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/blobstore"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
 	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
-const (
-	DocumentsCollection = "_dms_documents"
-	BlobBucket          = "dms_files"
-)
+const DocumentsCollection = "_dms_documents"
 
 type DocumentRepo struct {
-	pool *db.Pool
+	pool  *db.Pool
+	blobs blobstore.Backend
 }
 
-func NewDocumentRepo(pool *db.Pool) *DocumentRepo {
-	return &DocumentRepo{pool: pool}
+func NewDocumentRepo(pool *db.Pool, blobs blobstore.Backend) *DocumentRepo {
+	return &DocumentRepo{pool: pool, blobs: blobs}
 }
 
 func (r *DocumentRepo) EnsureIndexes() error {
-	c := r.pool.Get()
-	if err := c.CreateIndex(DocumentsCollection, "formId"); err != nil {
-		return err
-	}
-	return c.CreateIndex(DocumentsCollection, "submissionId")
+	return r.pool.Do(func(c *oxidb.Client) error {
+		if err := c.CreateIndex(DocumentsCollection, "formId"); err != nil {
+			return err
+		}
+		return c.CreateIndex(DocumentsCollection, "submissionId")
+	})
 }
 
+// EnsureBucket provisions the blob backend ahead of first use. Only
+// backends that need an explicit step (the OxiDB driver's bucket)
+// implement blobstore.BucketEnsurer; filesystem and S3 backends are ready
+// as soon as they're constructed, so this is a no-op for them.
 func (r *DocumentRepo) EnsureBucket() error {
-	c := r.pool.Get()
-	return c.CreateBucket(BlobBucket)
+	if be, ok := r.blobs.(blobstore.BucketEnsurer); ok {
+		return be.EnsureBucket()
+	}
+	return nil
 }
 
 func (r *DocumentRepo) Create(doc *models.Document) (string, error) {
-	c := r.pool.Get()
 	d := documentToDoc(doc)
-	result, err := c.Insert(DocumentsCollection, d)
-	if err != nil {
-		return "", err
-	}
-	return extractID(result), nil
+	var id string
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(DocumentsCollection, d)
+		if err != nil {
+			return err
+		}
+		id = extractID(result)
+		return nil
+	})
+	return id, err
 }
 
 func (r *DocumentRepo) FindByID(id string) (*models.Document, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(DocumentsCollection, map[string]any{"_id": toNumericID(id)})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(DocumentsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -58,18 +72,22 @@ func (r *DocumentRepo) FindByID(id string) (*models.Document, error) {
 }
 
 func (r *DocumentRepo) FindAll(skip, limit int) ([]models.Document, int, error) {
-	c := r.pool.Get()
 	query := map[string]any{}
 
-	total, err := c.Count(DocumentsCollection, query)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	docs, err := c.Find(DocumentsCollection, query, &oxidb.FindOptions{
-		Sort:  map[string]any{"createdAt": -1},
-		Skip:  &skip,
-		Limit: &limit,
+	var total int
+	var docs []map[string]any
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(DocumentsCollection, query)
+		if err != nil {
+			return err
+		}
+		docs, err = c.Find(DocumentsCollection, query, &oxidb.FindOptions{
+			Sort:  map[string]any{"createdAt": -1},
+			Skip:  &skip,
+			Limit: &limit,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, 0, err
@@ -86,10 +104,57 @@ func (r *DocumentRepo) FindAll(skip, limit int) ([]models.Document, int, error)
 	return result, total, nil
 }
 
-func (r *DocumentRepo) FindBySubmission(submissionID string) ([]models.Document, error) {
-	c := r.pool.Get()
-	docs, err := c.Find(DocumentsCollection, map[string]any{"submissionId": submissionID}, nil)
+// DocumentFindResult is a single item delivered by FindStream: either a
+// decoded document or a terminal error.
+type DocumentFindResult struct {
+	Doc *models.Document
+	Err error
+}
+
+// FindStream streams documents matching query over a channel instead of
+// materializing them into a slice, for large result sets (export/report
+// pipelines, the 1M-doc benchmark). The returned cancel func must be called
+// once the caller is done, even after draining the channel, to stop the
+// underlying oxidb.Client.FindStream goroutine.
+func (r *DocumentRepo) FindStream(query map[string]any, opts *oxidb.FindOptions) (<-chan DocumentFindResult, func(), error) {
+	var rawCh <-chan oxidb.FindResult
+	var cancel func()
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		rawCh, cancel, err = c.FindStream(DocumentsCollection, query, opts)
+		return err
+	})
 	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan DocumentFindResult, cap(rawCh))
+	go func() {
+		defer close(out)
+		for res := range rawCh {
+			if res.Err != nil {
+				out <- DocumentFindResult{Err: res.Err}
+				return
+			}
+			doc, err := docToDocument(res.Doc)
+			if err != nil {
+				out <- DocumentFindResult{Err: err}
+				return
+			}
+			out <- DocumentFindResult{Doc: doc}
+		}
+	}()
+
+	return out, cancel, nil
+}
+
+func (r *DocumentRepo) FindBySubmission(submissionID string) ([]models.Document, error) {
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(DocumentsCollection, map[string]any{"submissionId": submissionID}, nil)
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	result := make([]models.Document, 0, len(docs))
@@ -104,30 +169,32 @@ func (r *DocumentRepo) FindBySubmission(submissionID string) ([]models.Document,
 }
 
 func (r *DocumentRepo) Delete(id string) error {
-	c := r.pool.Get()
-	_, err := c.Delete(DocumentsCollection, map[string]any{"_id": toNumericID(id)})
-	return err
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Delete(DocumentsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	})
 }
 
-func (r *DocumentRepo) PutBlob(key string, data []byte, contentType string) error {
-	c := r.pool.Get()
-	_, err := c.PutObject(BlobBucket, key, data, contentType, nil)
-	return err
+func (r *DocumentRepo) PutBlob(key string, data io.Reader, contentType string) error {
+	return r.blobs.Put(key, data, contentType)
 }
 
-func (r *DocumentRepo) GetBlob(key string) ([]byte, map[string]any, error) {
-	c := r.pool.Get()
-	return c.GetObject(BlobBucket, key)
+func (r *DocumentRepo) GetBlob(key string) (io.ReadCloser, error) {
+	return r.blobs.Get(key)
 }
 
 func (r *DocumentRepo) DeleteBlob(key string) error {
-	c := r.pool.Get()
-	return c.DeleteObject(BlobBucket, key)
+	return r.blobs.Delete(key)
 }
 
 func (r *DocumentRepo) CountAll() (int, error) {
-	c := r.pool.Get()
-	return c.Count(DocumentsCollection, map[string]any{})
+	var total int
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(DocumentsCollection, map[string]any{})
+		return err
+	})
+	return total, err
 }
 
 func documentToDoc(d *models.Document) map[string]any {
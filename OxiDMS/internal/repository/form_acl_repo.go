@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const FormACLsCollection = "_dms_form_acls"
+
+// FormACLRepo stores per-form ACL entries: which principals (user IDs or
+// "group:<name>" strings) hold which permissions on a form, beyond what
+// their role already grants. See internal/authz.Require.
+type FormACLRepo struct {
+	pool *db.Pool
+}
+
+func NewFormACLRepo(pool *db.Pool) *FormACLRepo {
+	return &FormACLRepo{pool: pool}
+}
+
+func (r *FormACLRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateIndex(FormACLsCollection, "formId")
+	})
+}
+
+// Grant creates or replaces the ACL entry for (formID, principal) with
+// perms, so re-granting the same principal updates its permissions rather
+// than accumulating duplicate entries.
+func (r *FormACLRepo) Grant(formID, principal string, perms []string) (*models.FormACL, error) {
+	if err := r.Revoke(formID, principal); err != nil {
+		return nil, err
+	}
+	entry := &models.FormACL{
+		FormID:      formID,
+		Principal:   principal,
+		Permissions: perms,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	doc := map[string]any{
+		"formId":      entry.FormID,
+		"principal":   entry.Principal,
+		"permissions": entry.Permissions,
+		"createdAt":   entry.CreatedAt,
+	}
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(FormACLsCollection, doc)
+		if err != nil {
+			return err
+		}
+		entry.ID = extractID(result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// FindGrant returns the ACL entry for (formID, principal), or nil if none
+// exists.
+func (r *FormACLRepo) FindGrant(formID, principal string) (*models.FormACL, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(FormACLsCollection, map[string]any{"formId": formID, "principal": principal})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToFormACL(doc)
+}
+
+// List returns every ACL entry on formID.
+func (r *FormACLRepo) List(formID string) ([]models.FormACL, error) {
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(FormACLsCollection, map[string]any{"formId": formID}, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	entries := make([]models.FormACL, 0, len(docs))
+	for _, d := range docs {
+		entry, err := docToFormACL(d)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// Revoke removes the ACL entry for (formID, principal), if any.
+func (r *FormACLRepo) Revoke(formID, principal string) error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Delete(FormACLsCollection, map[string]any{"formId": formID, "principal": principal})
+		return err
+	})
+}
+
+func docToFormACL(doc map[string]any) (*models.FormACL, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal form acl doc: %w", err)
+	}
+	var entry models.FormACL
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("unmarshal form acl: %w", err)
+	}
+	return &entry, nil
+}
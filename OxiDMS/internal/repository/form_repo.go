@@ -20,26 +20,34 @@ func NewFormRepo(pool *db.Pool) *FormRepo {
 }
 
 func (r *FormRepo) EnsureIndexes() error {
-	c := r.pool.Get()
-	return c.CreateUniqueIndex(FormsCollection, "slug")
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(FormsCollection, "slug")
+	})
 }
 
 func (r *FormRepo) Create(form *models.Form) (string, error) {
-	c := r.pool.Get()
 	doc := formToDoc(form)
-	result, err := c.Insert(FormsCollection, doc)
-	if err != nil {
-		return "", err
-	}
-	return extractID(result), nil
+	var id string
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(FormsCollection, doc)
+		if err != nil {
+			return err
+		}
+		id = extractID(result)
+		return nil
+	})
+	return id, err
 }
 
 func (r *FormRepo) FindAll() ([]models.Form, error) {
-	c := r.pool.Get()
-	docs, err := c.Find(FormsCollection, map[string]any{}, &oxidb.FindOptions{
-		Sort: map[string]any{"createdAt": -1},
-	})
-	if err != nil {
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(FormsCollection, map[string]any{}, &oxidb.FindOptions{
+			Sort: map[string]any{"createdAt": -1},
+		})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	forms := make([]models.Form, 0, len(docs))
@@ -54,9 +62,12 @@ func (r *FormRepo) FindAll() ([]models.Form, error) {
 }
 
 func (r *FormRepo) FindByID(id string) (*models.Form, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(FormsCollection, map[string]any{"_id": toNumericID(id)})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(FormsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -66,9 +77,12 @@ func (r *FormRepo) FindByID(id string) (*models.Form, error) {
 }
 
 func (r *FormRepo) FindBySlug(slug string) (*models.Form, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(FormsCollection, map[string]any{"slug": slug})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(FormsCollection, map[string]any{"slug": slug})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -78,21 +92,28 @@ func (r *FormRepo) FindBySlug(slug string) (*models.Form, error) {
 }
 
 func (r *FormRepo) Update(id string, form *models.Form) error {
-	c := r.pool.Get()
 	doc := formToDoc(form)
-	_, err := c.Update(FormsCollection, map[string]any{"_id": toNumericID(id)}, map[string]any{"$set": doc})
-	return err
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Update(FormsCollection, map[string]any{"_id": toNumericID(id)}, map[string]any{"$set": doc})
+		return err
+	})
 }
 
 func (r *FormRepo) Delete(id string) error {
-	c := r.pool.Get()
-	_, err := c.Delete(FormsCollection, map[string]any{"_id": toNumericID(id)})
-	return err
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Delete(FormsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	})
 }
 
 func (r *FormRepo) Count() (int, error) {
-	c := r.pool.Get()
-	return c.Count(FormsCollection, map[string]any{})
+	var total int
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(FormsCollection, map[string]any{})
+		return err
+	})
+	return total, err
 }
 
 func formToDoc(f *models.Form) map[string]any {
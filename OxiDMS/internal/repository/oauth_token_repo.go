@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const OAuthTokensCollection = "_dms_oauth_tokens"
+
+// OAuthTokenRepo stores authorization codes, access tokens, and refresh
+// tokens issued by the oauth package, distinguished by OAuthToken.Kind.
+type OAuthTokenRepo struct {
+	pool *db.Pool
+}
+
+func NewOAuthTokenRepo(pool *db.Pool) *OAuthTokenRepo {
+	return &OAuthTokenRepo{pool: pool}
+}
+
+func (r *OAuthTokenRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(OAuthTokensCollection, "token")
+	})
+}
+
+func (r *OAuthTokenRepo) Create(tok *models.OAuthToken) error {
+	doc := map[string]any{
+		"kind":        tok.Kind,
+		"token":       tok.Token,
+		"clientId":    tok.ClientID,
+		"userId":      tok.UserID,
+		"redirectUri": tok.RedirectURI,
+		"scope":       tok.Scope,
+		"revoked":     tok.Revoked,
+		"expiresAt":   tok.ExpiresAt,
+		"createdAt":   tok.CreatedAt,
+	}
+	return r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(OAuthTokensCollection, doc)
+		if err != nil {
+			return err
+		}
+		tok.ID = extractID(result)
+		return nil
+	})
+}
+
+func (r *OAuthTokenRepo) FindByToken(token string) (*models.OAuthToken, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(OAuthTokensCollection, map[string]any{"token": token})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToOAuthToken(doc)
+}
+
+// Revoke marks token as revoked without deleting it, so introspection can
+// still report it as known-but-inactive.
+func (r *OAuthTokenRepo) Revoke(token string) error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Update(OAuthTokensCollection, map[string]any{"token": token}, map[string]any{"$set": map[string]any{"revoked": true}})
+		return err
+	})
+}
+
+// DeleteByToken removes token outright; used to consume single-use
+// authorization codes once exchanged.
+func (r *OAuthTokenRepo) DeleteByToken(token string) error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Delete(OAuthTokensCollection, map[string]any{"token": token})
+		return err
+	})
+}
+
+func docToOAuthToken(doc map[string]any) (*models.OAuthToken, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal oauth token doc: %w", err)
+	}
+	var tok models.OAuthToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return nil, fmt.Errorf("unmarshal oauth token: %w", err)
+	}
+	return &tok, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const OperationsCollection = "_dms_operations"
+
+// OperationRepo mirrors operations.Operation snapshots into OxiDB, keyed
+// by the operation's own ID (assigned by the registry, not the storage
+// engine), so GET /api/v1/operations/{id} survives a server restart even
+// though the in-process registry doesn't.
+type OperationRepo struct {
+	pool *db.Pool
+}
+
+func NewOperationRepo(pool *db.Pool) *OperationRepo {
+	return &OperationRepo{pool: pool}
+}
+
+func (r *OperationRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		if err := c.CreateIndex(OperationsCollection, "type"); err != nil {
+			return err
+		}
+		return c.CreateIndex(OperationsCollection, "status")
+	})
+}
+
+// Upsert writes rec's current state, replacing any prior snapshot with the
+// same ID. Each operation has exactly one writer (the goroutine running
+// it), so this doesn't need the read-modify-write transaction BlobRefRepo
+// uses for concurrent writers.
+func (r *OperationRepo) Upsert(rec *models.OperationRecord) error {
+	doc := operationToDoc(rec)
+	return r.pool.Do(func(c *oxidb.Client) error {
+		existing, err := c.FindOne(OperationsCollection, map[string]any{"id": rec.ID})
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			_, err := c.Insert(OperationsCollection, doc)
+			return err
+		}
+		_, err = c.UpdateOne(OperationsCollection, map[string]any{"id": rec.ID}, map[string]any{"$set": doc})
+		return err
+	})
+}
+
+func (r *OperationRepo) FindByID(id string) (*models.OperationRecord, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(OperationsCollection, map[string]any{"id": id})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToOperation(doc)
+}
+
+func (r *OperationRepo) List() ([]models.OperationRecord, error) {
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(OperationsCollection, map[string]any{}, &oxidb.FindOptions{
+			Sort: map[string]any{"startedAt": -1},
+		})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	recs := make([]models.OperationRecord, 0, len(docs))
+	for _, d := range docs {
+		rec, err := docToOperation(d)
+		if err != nil {
+			continue
+		}
+		recs = append(recs, *rec)
+	}
+	return recs, nil
+}
+
+func operationToDoc(rec *models.OperationRecord) map[string]any {
+	data, _ := json.Marshal(rec)
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	delete(doc, "_id")
+	doc["id"] = rec.ID
+	return doc
+}
+
+func docToOperation(doc map[string]any) (*models.OperationRecord, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal operation doc: %w", err)
+	}
+	var rec models.OperationRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshal operation record: %w", err)
+	}
+	rec.ID, _ = doc["id"].(string)
+	return &rec, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const SessionsCollection = "_dms_sessions"
+
+// SessionRepo stores refresh-token sessions minted by auth.SessionManager,
+// keyed by the SHA-256 hash of the refresh token so the cleartext value is
+// never persisted.
+type SessionRepo struct {
+	pool *db.Pool
+}
+
+func NewSessionRepo(pool *db.Pool) *SessionRepo {
+	return &SessionRepo{pool: pool}
+}
+
+func (r *SessionRepo) EnsureIndexes() error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		if err := c.CreateUniqueIndex(SessionsCollection, "tokenHash"); err != nil {
+			return err
+		}
+		return c.CreateIndex(SessionsCollection, "userId")
+	})
+}
+
+func (r *SessionRepo) Create(sess *models.Session) error {
+	doc := map[string]any{
+		"userId":    sess.UserID,
+		"tokenHash": sess.TokenHash,
+		"issuedAt":  sess.IssuedAt,
+		"expiresAt": sess.ExpiresAt,
+		"revokedAt": "",
+		"userAgent": sess.UserAgent,
+		"ip":        sess.IP,
+	}
+	return r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(SessionsCollection, doc)
+		if err != nil {
+			return err
+		}
+		sess.ID = extractID(result)
+		return nil
+	})
+}
+
+func (r *SessionRepo) FindByHash(hash string) (*models.Session, error) {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(SessionsCollection, map[string]any{"tokenHash": hash})
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return docToSession(doc)
+}
+
+// Revoke marks the session with the given refresh token hash revoked
+// without deleting it, both for audit purposes and so the revocation
+// cache (see auth.SessionManager) can keep rejecting its access tokens
+// until they expire on their own.
+func (r *SessionRepo) Revoke(hash string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Update(SessionsCollection, map[string]any{"tokenHash": hash}, map[string]any{"$set": map[string]any{"revokedAt": now}})
+		return err
+	})
+}
+
+// RevokeIfActive revokes the session with the given refresh token hash
+// only if it isn't already revoked, reporting whether this call was the
+// one that revoked it. Used by auth.SessionManager.Rotate so two
+// concurrent refreshes of the same token can't both win the
+// check-then-act race and mint a pair each: the query's revokedAt=""
+// condition only matches for the first Update to land.
+func (r *SessionRepo) RevokeIfActive(hash string) (bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var modified bool
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		res, err := c.Update(SessionsCollection, map[string]any{"tokenHash": hash, "revokedAt": ""}, map[string]any{"$set": map[string]any{"revokedAt": now}})
+		if err != nil {
+			return err
+		}
+		modified = intField(res, "modified") > 0
+		return nil
+	})
+	return modified, err
+}
+
+// RevokeAllForUser revokes every session belonging to userID, for
+// POST /auth/logout-all.
+func (r *SessionRepo) RevokeAllForUser(userID string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.Update(SessionsCollection, map[string]any{"userId": userID}, map[string]any{"$set": map[string]any{"revokedAt": now}})
+		return err
+	})
+}
+
+// ActiveRevocations returns every session revoked but not yet expired, for
+// auth.SessionManager to periodically reload into its in-memory
+// revocation cache. A session past its ExpiresAt is dropped from the
+// query rather than the cache's eviction logic, since an expired access
+// token is already rejected by ValidateToken regardless of revocation.
+func (r *SessionRepo) ActiveRevocations() ([]models.Session, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var docs []map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		docs, err = c.Find(SessionsCollection, map[string]any{"expiresAt": map[string]any{"$gt": now}}, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	sessions := make([]models.Session, 0, len(docs))
+	for _, d := range docs {
+		sess, err := docToSession(d)
+		if err != nil || sess.RevokedAt == "" {
+			continue
+		}
+		sessions = append(sessions, *sess)
+	}
+	return sessions, nil
+}
+
+func docToSession(doc map[string]any) (*models.Session, error) {
+	normalizeID(doc)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal session doc: %w", err)
+	}
+	var sess models.Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return &sess, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
@@ -20,36 +21,45 @@ func NewSubmissionRepo(pool *db.Pool) *SubmissionRepo {
 }
 
 func (r *SubmissionRepo) EnsureIndexes() error {
-	c := r.pool.Get()
-	if err := c.CreateIndex(SubmissionsCollection, "formId"); err != nil {
-		return err
-	}
-	return c.CreateCompositeIndex(SubmissionsCollection, []string{"formId", "createdAt"})
+	return r.pool.Do(func(c *oxidb.Client) error {
+		if err := c.CreateIndex(SubmissionsCollection, "formId"); err != nil {
+			return err
+		}
+		return c.CreateCompositeIndex(SubmissionsCollection, []string{"formId", "createdAt"})
+	})
 }
 
 func (r *SubmissionRepo) Create(sub *models.Submission) (string, error) {
-	c := r.pool.Get()
 	doc := submissionToDoc(sub)
-	result, err := c.Insert(SubmissionsCollection, doc)
-	if err != nil {
-		return "", err
-	}
-	return extractID(result), nil
+	var id string
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(SubmissionsCollection, doc)
+		if err != nil {
+			return err
+		}
+		id = extractID(result)
+		return nil
+	})
+	return id, err
 }
 
 func (r *SubmissionRepo) FindByFormID(formID string, skip, limit int) ([]models.Submission, int, error) {
-	c := r.pool.Get()
 	query := map[string]any{"formId": formID}
 
-	total, err := c.Count(SubmissionsCollection, query)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	docs, err := c.Find(SubmissionsCollection, query, &oxidb.FindOptions{
-		Sort:  map[string]any{"createdAt": -1},
-		Skip:  &skip,
-		Limit: &limit,
+	var total int
+	var docs []map[string]any
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(SubmissionsCollection, query)
+		if err != nil {
+			return err
+		}
+		docs, err = c.Find(SubmissionsCollection, query, &oxidb.FindOptions{
+			Sort:  map[string]any{"createdAt": -1},
+			Skip:  &skip,
+			Limit: &limit,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, 0, err
@@ -67,9 +77,12 @@ func (r *SubmissionRepo) FindByFormID(formID string, skip, limit int) ([]models.
 }
 
 func (r *SubmissionRepo) FindByID(id string) (*models.Submission, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -79,53 +92,63 @@ func (r *SubmissionRepo) FindByID(id string) (*models.Submission, error) {
 }
 
 func (r *SubmissionRepo) Update(id string, sub *models.Submission) error {
-	c := r.pool.Get()
 	doc := submissionToDoc(sub)
-	_, err := c.UpdateOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)}, map[string]any{"$set": doc})
-	return err
-}
-
-func (r *SubmissionRepo) Delete(id string) error {
-	c := r.pool.Get()
-	_, err := c.DeleteOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)})
-	return err
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.UpdateOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)}, map[string]any{"$set": doc})
+		return err
+	})
 }
 
-func (r *SubmissionRepo) TextSearch(query string, limit int) ([]models.Submission, error) {
-	c := r.pool.Get()
-	docs, err := c.TextSearch(SubmissionsCollection, query, limit)
-	if err != nil {
-		return nil, err
-	}
-	subs := make([]models.Submission, 0, len(docs))
-	for _, d := range docs {
-		s, err := docToSubmission(d)
-		if err != nil {
-			continue
-		}
-		subs = append(subs, *s)
-	}
-	return subs, nil
+// UpdateDataField sets a single path under data (e.g. "items.$[elem].discount")
+// on one submission, applying filters as the update's ArrayFilters so only
+// the array elements they match are touched. This avoids replacing the
+// whole Data blob for a change to one nested field.
+func (r *SubmissionRepo) UpdateDataField(id, path string, value any, filters []map[string]any) error {
+	update := map[string]any{"$set": map[string]any{
+		"data." + path: value,
+		"updatedAt":    time.Now().UTC().Format(time.RFC3339),
+	}}
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.UpdateOneWithOptions(SubmissionsCollection, map[string]any{"_id": toNumericID(id)}, update, &oxidb.UpdateOptions{ArrayFilters: filters})
+		return err
+	})
 }
 
-func (r *SubmissionRepo) EnsureTextIndex(fields []string) error {
-	c := r.pool.Get()
-	return c.CreateTextIndex(SubmissionsCollection, fields)
+func (r *SubmissionRepo) Delete(id string) error {
+	return r.pool.Do(func(c *oxidb.Client) error {
+		_, err := c.DeleteOne(SubmissionsCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	})
 }
 
 func (r *SubmissionRepo) ListIndexes() ([]map[string]any, error) {
-	c := r.pool.Get()
-	return c.ListIndexes(SubmissionsCollection)
+	var indexes []map[string]any
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		indexes, err = c.ListIndexes(SubmissionsCollection)
+		return err
+	})
+	return indexes, err
 }
 
 func (r *SubmissionRepo) Compact() (map[string]any, error) {
-	c := r.pool.Get()
-	return c.Compact(SubmissionsCollection)
+	var result map[string]any
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		result, err = c.Compact(SubmissionsCollection)
+		return err
+	})
+	return result, err
 }
 
 func (r *SubmissionRepo) CountByFormID(formID string) (int, error) {
-	c := r.pool.Get()
-	return c.Count(SubmissionsCollection, map[string]any{"formId": formID})
+	var total int
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		total, err = c.Count(SubmissionsCollection, map[string]any{"formId": formID})
+		return err
+	})
+	return total, err
 }
 
 func submissionToDoc(s *models.Submission) map[string]any {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
 const UsersCollection = "_dms_users"
@@ -20,14 +21,18 @@ func NewUserRepo(pool *db.Pool) *UserRepo {
 }
 
 func (r *UserRepo) EnsureIndexes() error {
-	c := r.pool.Get()
-	return c.CreateUniqueIndex(UsersCollection, "email")
+	return r.pool.Do(func(c *oxidb.Client) error {
+		return c.CreateUniqueIndex(UsersCollection, "email")
+	})
 }
 
 func (r *UserRepo) FindByEmail(email string) (*models.User, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(UsersCollection, map[string]any{"email": email})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(UsersCollection, map[string]any{"email": email})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -37,9 +42,12 @@ func (r *UserRepo) FindByEmail(email string) (*models.User, error) {
 }
 
 func (r *UserRepo) FindByID(id string) (*models.User, error) {
-	c := r.pool.Get()
-	doc, err := c.FindOne(UsersCollection, map[string]any{"_id": toNumericID(id)})
-	if err != nil {
+	var doc map[string]any
+	if err := r.pool.Do(func(c *oxidb.Client) error {
+		var err error
+		doc, err = c.FindOne(UsersCollection, map[string]any{"_id": toNumericID(id)})
+		return err
+	}); err != nil {
 		return nil, err
 	}
 	if doc == nil {
@@ -49,7 +57,6 @@ func (r *UserRepo) FindByID(id string) (*models.User, error) {
 }
 
 func (r *UserRepo) Create(user *models.User) (string, error) {
-	c := r.pool.Get()
 	doc := map[string]any{
 		"email":        user.Email,
 		"passwordHash": user.PasswordHash,
@@ -57,11 +64,16 @@ func (r *UserRepo) Create(user *models.User) (string, error) {
 		"role":         user.Role,
 		"createdAt":    user.CreatedAt,
 	}
-	result, err := c.Insert(UsersCollection, doc)
-	if err != nil {
-		return "", err
-	}
-	return extractID(result), nil
+	var id string
+	err := r.pool.Do(func(c *oxidb.Client) error {
+		result, err := c.Insert(UsersCollection, doc)
+		if err != nil {
+			return err
+		}
+		id = extractID(result)
+		return nil
+	})
+	return id, err
 }
 
 func docToUser(doc map[string]any) (*models.User, error) {
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -8,12 +9,19 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/authz"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/handler"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/logging"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	mw "github.com/parisxmas/OxiDB/OxiDMS/internal/middleware"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/oauth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
 )
 
 func New(
-	jwtSecret string,
+	appLogger *slog.Logger,
+	jwtKeys auth.KeySet,
+	revoked auth.RevocationChecker,
 	authH *handler.AuthHandler,
 	formH *handler.FormHandler,
 	subH *handler.SubmissionHandler,
@@ -21,55 +29,134 @@ func New(
 	searchH *handler.SearchHandler,
 	dashH *handler.DashboardHandler,
 	adminH *handler.AdminHandler,
+	opH *handler.OperationHandler,
+	oauthH *handler.OAuthHandler,
+	metricsH *handler.MetricsHandler,
+	sitemapH *handler.SitemapHandler,
+	metricsReg *metrics.Registry,
+	tokens auth.TokenLookup,
+	acls *repository.FormACLRepo,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(mw.Recovery)
 	r.Use(mw.Logger)
+	r.Use(mw.Metrics(metricsReg))
+	r.Use(logging.Middleware(appLogger))
 	r.Use(mw.CORS)
 
+	// OIDC discovery, outside /api/v1 per spec.
+	r.Get("/.well-known/openid-configuration", oauthH.OpenIDConfiguration)
+	r.Get("/.well-known/jwks.json", oauthH.JWKS)
+
+	// Metrics: unauthenticated, same as most Prometheus exporters — scope
+	// network access to it at the reverse proxy / firewall instead.
+	r.Get("/metrics", metricsH.Metrics)
+
+	// Search discovery: unauthenticated, since they're fetched by browsers
+	// and third-party tools that have no OxiDMS session yet.
+	r.Get("/opensearch.xml", searchH.OpenSearchDescription)
+	r.Get("/api/search/suggest", searchH.Suggest)
+
+	// Crawler discovery: unauthenticated, same reasoning.
+	r.Get("/sitemap.xml", sitemapH.Sitemap)
+	r.Get("/robots.txt", sitemapH.Robots)
+
 	r.Route("/api/v1", func(r chi.Router) {
 		// Public routes
 		r.Post("/auth/login", authH.Login)
 		r.Post("/auth/register", authH.Register)
+		r.Post("/auth/refresh", authH.Refresh)
+		r.Post("/oauth/token", oauthH.Token)
+		r.Post("/oauth/revoke", oauthH.Revoke)
+		r.Post("/oauth/introspect", oauthH.Introspect)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
-			r.Use(auth.Middleware(jwtSecret))
+			r.Use(auth.Middleware(jwtKeys, tokens, revoked))
 
 			// Auth
 			r.Get("/auth/me", authH.Me)
+			r.Get("/auth/me/activity", authH.Activity)
+			r.Post("/auth/tokens", authH.CreateToken)
+			r.Post("/auth/logout", authH.Logout)
+			r.Post("/auth/logout-all", authH.LogoutAll)
+
+			// OAuth: the authorize step needs the caller to already be
+			// signed in (JWT or a prior OAuth token) to approve a client.
+			r.Get("/oauth/authorize", oauthH.Authorize)
 
 			// Dashboard
 			r.Get("/dashboard", dashH.Dashboard)
 
 			// Forms
-			r.Get("/forms", formH.List)
-			r.Post("/forms", formH.Create)
-			r.Get("/forms/{formId}", formH.Get)
-			r.Put("/forms/{formId}", formH.Update)
-			r.Delete("/forms/{formId}", formH.Delete)
+			r.With(authz.Require(acls, authz.PermFormRead)).Get("/forms", formH.List)
+			r.With(authz.Require(acls, authz.PermFormCreate)).Post("/forms", formH.Create)
+			r.With(authz.Require(acls, authz.PermFormRead)).Get("/forms/{formId}", formH.Get)
+			r.With(authz.Require(acls, authz.PermFormUpdate)).Put("/forms/{formId}", formH.Update)
+			r.With(authz.Require(acls, authz.PermFormDelete)).Delete("/forms/{formId}", formH.Delete)
+
+			// Per-form ACLs: the creator (auto-granted form:update on
+			// create, see FormService.Create) decides who else can read or
+			// change their form.
+			r.With(authz.Require(acls, authz.PermFormRead)).Get("/forms/{formId}/acl", formH.ListACL)
+			r.With(authz.Require(acls, authz.PermFormUpdate)).Post("/forms/{formId}/acl", formH.GrantACL)
+			r.With(authz.Require(acls, authz.PermFormUpdate)).Delete("/forms/{formId}/acl/{principal}", formH.RevokeACL)
 
 			// Submissions
-			r.Get("/forms/{formId}/submissions", subH.List)
-			r.Post("/forms/{formId}/submissions", subH.Create)
-			r.Get("/forms/{formId}/submissions/{subId}", subH.Get)
-			r.Put("/forms/{formId}/submissions/{subId}", subH.Update)
-			r.Delete("/forms/{formId}/submissions/{subId}", subH.Delete)
+			r.Group(func(r chi.Router) {
+				r.Use(authz.Require(acls, authz.PermSubmissionAll))
+				r.Get("/forms/{formId}/submissions", subH.List)
+				r.Post("/forms/{formId}/submissions", subH.Create)
+				r.Get("/forms/{formId}/submissions/{subId}", subH.Get)
+				r.Put("/forms/{formId}/submissions/{subId}", subH.Update)
+				r.Patch("/forms/{formId}/submissions/{subId}", subH.UpdateField)
+				r.Delete("/forms/{formId}/submissions/{subId}", subH.Delete)
+			})
 
 			// Documents
-			r.Get("/documents", docH.List)
-			r.Post("/documents", docH.Upload)
-			r.Get("/documents/{docId}/download", docH.Download)
-			r.Delete("/documents/{docId}", docH.Delete)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(oauth.ScopeDocumentsAll))
+				r.Use(authz.Require(acls, authz.PermDocumentAll))
+				r.Get("/documents", docH.List)
+				r.Post("/documents", docH.Upload)
+				r.Get("/documents/{docId}/download", docH.Download)
+				r.Delete("/documents/{docId}", docH.Delete)
+			})
 
 			// Search
 			r.Post("/search", searchH.Search)
 
 			// Admin
-			r.Get("/admin/indexes", adminH.ListIndexes)
-			r.Post("/admin/compact", adminH.Compact)
+			r.Group(func(r chi.Router) {
+				r.Use(authz.Require(acls, authz.PermAdminAll))
+				r.Get("/admin/indexes", adminH.ListIndexes)
+				r.Post("/admin/compact", adminH.Compact)
+				r.Post("/admin/reindex", adminH.Reindex)
+				r.Get("/admin/audit", adminH.Audit)
+				r.Get("/admin/operations", adminH.RunningOperations)
+				r.Get("/admin/oauth/clients", adminH.ListClients)
+				r.Post("/admin/oauth/clients", adminH.CreateClient)
+				r.Delete("/admin/oauth/clients/{clientId}", adminH.RevokeClient)
+
+				// Operations: poll/cancel/stream the background jobs the
+				// routes above kick off. Every operation a request can start
+				// (compact, reindex) is admin-only to start, and the init
+				// operation is started by the server itself at boot, so
+				// listing, inspecting, streaming, and cancelling one is
+				// gated the same way rather than open to any authenticated
+				// user.
+				r.Get("/operations", opH.List)
+				r.Get("/operations/{opId}", opH.Get)
+				r.Delete("/operations/{opId}", opH.Cancel)
+				r.Get("/operations/{opId}/events", opH.Events)
+			})
+
+			// Policy hot-reload: gated on the admin role itself rather than
+			// a permission, since it's what decides what every other
+			// permission grant means.
+			r.With(authz.RequireRole(authz.RoleAdmin)).Post("/admin/policy/reload", adminH.ReloadPolicy)
 		})
 	})
 
@@ -96,10 +183,36 @@ func spaHandler(r *chi.Mux, staticDir string) {
 		}
 
 		// SPA fallback: serve index.html for all other routes
-		http.ServeFile(w, r, filepath.Join(staticDir, "index.html"))
+		serveIndexWithOpenSearchLink(w, filepath.Join(staticDir, "index.html"))
 	})
 }
 
+// openSearchLinkTag is what browsers look for to auto-detect OxiDMS as a
+// search provider; see handler.SearchHandler.OpenSearchDescription.
+const openSearchLinkTag = `<link rel="search" type="application/opensearchdescription+xml" title="OxiDMS" href="/opensearch.xml">`
+
+// serveIndexWithOpenSearchLink serves indexPath with openSearchLinkTag
+// inserted before </head>, so the frontend's index.html doesn't need to
+// carry it directly. Falls back to serving the file unmodified if it can't
+// be read or already has the tag.
+func serveIndexWithOpenSearchLink(w http.ResponseWriter, indexPath string) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		http.Error(w, "index.html not found", http.StatusNotFound)
+		return
+	}
+
+	html := string(data)
+	if !strings.Contains(html, openSearchLinkTag) {
+		if i := strings.Index(strings.ToLower(html), "</head>"); i >= 0 {
+			html = html[:i] + openSearchLinkTag + "\n" + html[i:]
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
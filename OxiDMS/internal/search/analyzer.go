@@ -0,0 +1,114 @@
+// Package search implements an inverted-index full-text search subsystem
+// over Submission.data fields marked Searchable on their owning Form. See
+// Indexer for index maintenance and Parse for the query DSL it serves.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is one occurrence of an analyzed term at a position within a
+// field's text, as produced by Analyze. Position counts analyzed tokens,
+// not runes, so stopwords removed during analysis don't leave gaps that
+// would break phrase matching.
+type Token struct {
+	Term     string
+	Position int
+}
+
+// stopwords are dropped during analysis, so they're never indexed. A query
+// word that is only a stopword analyzes to no term and Parse rejects it
+// with an error; inside a quoted phrase a stopword is simply skipped.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// asciiFold maps common accented Latin letters to their unaccented ASCII
+// equivalent, so "café" and "cafe" index and query to the same term.
+var asciiFold = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ý': 'y', 'ÿ': 'y',
+}
+
+// Analyze tokenizes text the same way at index time and query time: it
+// lowercases, splits on Unicode letter/digit boundaries, ASCII-folds
+// accents, drops stopwords, and — when stem is true — light-stems what's
+// left. The stemmer is a small suffix-stripping approximation of Snowball's
+// English algorithm rather than a full implementation; OxiDMS has no
+// dependency on an external stemming library, and this covers the common
+// plural/verb-tense suffixes well enough for ranking purposes.
+func Analyze(text string, stem bool) []Token {
+	var tokens []Token
+	pos := 0
+	var b strings.Builder
+
+	flush := func() {
+		if b.Len() == 0 {
+			return
+		}
+		word := b.String()
+		b.Reset()
+		if stopwords[word] {
+			return
+		}
+		if stem {
+			word = stemWord(word)
+		}
+		tokens = append(tokens, Token{Term: word, Position: pos})
+		pos++
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			r = unicode.ToLower(r)
+			if folded, ok := asciiFold[r]; ok {
+				r = folded
+			}
+			b.WriteRune(r)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// stemWord strips a handful of common English inflectional suffixes. It
+// errs on the side of doing nothing to short words, where stripping a
+// "suffix" is more likely to collide unrelated terms than to conflate
+// genuine inflections of the same word.
+func stemWord(word string) string {
+	switch {
+	case len(word) > 9 && strings.HasSuffix(word, "ational"):
+		return word[:len(word)-7] + "ate"
+	case len(word) > 9 && strings.HasSuffix(word, "ization"):
+		return word[:len(word)-7] + "ize"
+	case len(word) > 7 && strings.HasSuffix(word, "ation"):
+		return word[:len(word)-3]
+	case len(word) > 6 && strings.HasSuffix(word, "ingly"):
+		return word[:len(word)-2]
+	case len(word) > 6 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		return strings.TrimSuffix(word, "ing")
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		return strings.TrimSuffix(word, "ed")
+	case len(word) > 4 && strings.HasSuffix(word, "ly"):
+		return strings.TrimSuffix(word, "ly")
+	case len(word) > 4 && strings.HasSuffix(word, "es"):
+		return strings.TrimSuffix(word, "es")
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
@@ -0,0 +1,175 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Aggregation requests one named facet over the Hits a query matches. Exactly
+// one of Terms or Range should be set.
+type Aggregation struct {
+	Name  string
+	Terms *TermsAgg
+	Range *RangeAgg
+}
+
+// TermsAgg buckets hits by the distinct values of a submission's data Field,
+// ordered by descending count. Size caps the number of buckets returned; 0
+// means the default of 10.
+type TermsAgg struct {
+	Field string
+	Size  int
+}
+
+// RangeAgg buckets hits into the given Ranges by the numeric value of a
+// submission's data Field.
+type RangeAgg struct {
+	Field  string
+	Ranges []RangeBucket
+}
+
+// RangeBucket is one bound of a RangeAgg, named Key for the result and
+// matching From <= value < To. A nil From or To leaves that side open.
+type RangeBucket struct {
+	Key  string
+	From *float64
+	To   *float64
+}
+
+// AggResult is one Aggregation's computed buckets.
+type AggResult struct {
+	Name    string
+	Buckets []AggBucket
+}
+
+// AggBucket is one bucket's label and the number of hits falling in it.
+type AggBucket struct {
+	Key   string
+	Count int
+}
+
+// runAggregations computes every requested aggregation over hits' backing
+// submissions. It re-fetches each hit's Data the same way highlightHits does,
+// since the inverted index itself stores only postings, not field values.
+func (ix *Indexer) runAggregations(hits []Hit, aggs []Aggregation) ([]AggResult, error) {
+	if len(aggs) == 0 {
+		return nil, nil
+	}
+
+	results := make([]AggResult, 0, len(aggs))
+	for _, agg := range aggs {
+		switch {
+		case agg.Terms != nil:
+			buckets, err := ix.termsBuckets(hits, *agg.Terms)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, AggResult{Name: agg.Name, Buckets: buckets})
+		case agg.Range != nil:
+			buckets, err := ix.rangeBuckets(hits, *agg.Range)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, AggResult{Name: agg.Name, Buckets: buckets})
+		}
+	}
+	return results, nil
+}
+
+func (ix *Indexer) termsBuckets(hits []Hit, agg TermsAgg) ([]AggBucket, error) {
+	counts := map[string]int{}
+	for _, hit := range hits {
+		sub, err := ix.subs.FindByID(hit.SubmissionID)
+		if err != nil {
+			return nil, err
+		}
+		if sub == nil {
+			continue
+		}
+		val, ok := sub.Data[agg.Field]
+		if !ok || val == nil {
+			continue
+		}
+		counts[toBucketKey(val)]++
+	}
+
+	buckets := make([]AggBucket, 0, len(counts))
+	for key, count := range counts {
+		buckets = append(buckets, AggBucket{Key: key, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Key < buckets[j].Key
+	})
+
+	size := agg.Size
+	if size <= 0 {
+		size = 10
+	}
+	if len(buckets) > size {
+		buckets = buckets[:size]
+	}
+	return buckets, nil
+}
+
+func (ix *Indexer) rangeBuckets(hits []Hit, agg RangeAgg) ([]AggBucket, error) {
+	counts := make([]int, len(agg.Ranges))
+	for _, hit := range hits {
+		sub, err := ix.subs.FindByID(hit.SubmissionID)
+		if err != nil {
+			return nil, err
+		}
+		if sub == nil {
+			continue
+		}
+		val, ok := toFloat(sub.Data[agg.Field])
+		if !ok {
+			continue
+		}
+		for i, rb := range agg.Ranges {
+			if rb.From != nil && val < *rb.From {
+				continue
+			}
+			if rb.To != nil && val >= *rb.To {
+				continue
+			}
+			counts[i]++
+		}
+	}
+
+	buckets := make([]AggBucket, len(agg.Ranges))
+	for i, rb := range agg.Ranges {
+		buckets[i] = AggBucket{Key: rb.Key, Count: counts[i]}
+	}
+	return buckets, nil
+}
+
+func toBucketKey(val any) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	if f, ok := toFloat(val); ok {
+		return formatBucketFloat(f)
+	}
+	return ""
+}
+
+func toFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func formatBucketFloat(f float64) string {
+	if f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Highlight controls how Indexer.SearchWithOptions surfaces matched terms:
+// it extracts up to NumFragments excerpts of about FragmentSize characters
+// from each of Fields (every Searchable field on the submission's form, if
+// Fields is empty), wrapping every matched span in PreTag/PostTag.
+type Highlight struct {
+	Fields       []string
+	PreTag       string
+	PostTag      string
+	FragmentSize int
+	NumFragments int
+}
+
+// highlightField re-tokenizes text the same way Analyze does — so analyzed
+// terms line up with the query's own analyzed terms — while tracking each
+// token's rune span, then builds fragments around the spans that match
+// exact or prefixes.
+func highlightField(text string, exact map[string]bool, prefixes []string, stem bool, opts Highlight) []string {
+	if text == "" || (len(exact) == 0 && len(prefixes) == 0) {
+		return nil
+	}
+	runes := []rune(text)
+	spans := matchedSpans(runes, exact, prefixes, stem)
+	if len(spans) == 0 {
+		return nil
+	}
+
+	fragSize := opts.FragmentSize
+	if fragSize <= 0 {
+		fragSize = 120
+	}
+	numFrags := opts.NumFragments
+	if numFrags <= 0 {
+		numFrags = 1
+	}
+	preTag, postTag := opts.PreTag, opts.PostTag
+	if preTag == "" {
+		preTag = "<em>"
+	}
+	if postTag == "" {
+		postTag = "</em>"
+	}
+
+	var fragments []string
+	used := -1 // rune index of the last span already rendered into a fragment
+	for _, span := range spans {
+		if len(fragments) >= numFrags {
+			break
+		}
+		if span[0] <= used {
+			continue
+		}
+
+		start := span[0] - fragSize/2
+		if start < 0 {
+			start = 0
+		}
+		end := start + fragSize
+		if end > len(runes) {
+			end = len(runes)
+			if start = end - fragSize; start < 0 {
+				start = 0
+			}
+		}
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("...")
+		}
+		pos := start
+		for _, s := range spans {
+			if s[0] < pos || s[1] > end {
+				continue
+			}
+			b.WriteString(string(runes[pos:s[0]]))
+			b.WriteString(preTag)
+			b.WriteString(string(runes[s[0]:s[1]]))
+			b.WriteString(postTag)
+			pos = s[1]
+			used = s[0]
+		}
+		b.WriteString(string(runes[pos:end]))
+		if end < len(runes) {
+			b.WriteString("...")
+		}
+		fragments = append(fragments, b.String())
+	}
+	return fragments
+}
+
+// matchedSpans tokenizes runes the same way Analyze does, tracking each
+// token's rune span, and returns the spans whose analyzed term is in exact
+// or starts with one of prefixes.
+func matchedSpans(runes []rune, exact map[string]bool, prefixes []string, stem bool) [][2]int {
+	var spans [][2]int
+	var b strings.Builder
+	start := -1
+
+	flush := func(end int) {
+		if b.Len() == 0 {
+			return
+		}
+		word := b.String()
+		b.Reset()
+		if stem {
+			word = stemWord(word)
+		}
+		if exact[word] || hasAnyPrefix(word, prefixes) {
+			spans = append(spans, [2]int{start, end})
+		}
+		start = -1
+	}
+
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start == -1 {
+				start = i
+			}
+			folded := unicode.ToLower(r)
+			if f, ok := asciiFold[folded]; ok {
+				folded = f
+			}
+			b.WriteRune(folded)
+		} else {
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return spans
+}
+
+func hasAnyPrefix(word string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	return false
+}
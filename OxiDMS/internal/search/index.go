@@ -0,0 +1,1231 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// Collections backing the inverted index. IndexCollection holds one
+// document per term; the others support BM25 scoring and crash-safe
+// incremental updates.
+const (
+	IndexCollection    = "_dms_search_index"
+	DocTermsCollection = "_dms_search_doc_terms"
+	DocLenCollection   = "_dms_search_doclen"
+	StatsCollection    = "_dms_search_stats"
+	OpLogCollection    = "_dms_search_oplog"
+)
+
+// statsKey is the single StatsCollection row's lookup key; there's only
+// ever one, tracking corpus-wide totals for BM25's avgdl term.
+const statsKey = "global"
+
+// BM25 constants, per the classic Okapi BM25 defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// posting is one field's occurrences of a term within one submission.
+type posting struct {
+	SubmissionID string `json:"submissionId"`
+	FormID       string `json:"formId"`
+	Field        string `json:"field"`
+	Positions    []int  `json:"positions"`
+}
+
+type termDoc struct {
+	Term     string    `json:"term"`
+	Postings []posting `json:"postings"`
+}
+
+type docLenRow struct {
+	SubmissionID string `json:"submissionId"`
+	FormID       string `json:"formId"`
+	Length       int    `json:"length"`
+}
+
+type statsRow struct {
+	Key         string `json:"key"`
+	TotalDocs   int    `json:"totalDocs"`
+	TotalLength int    `json:"totalLength"`
+}
+
+// Hit is one ranked result from Indexer.Search.
+type Hit struct {
+	SubmissionID string
+	FormID       string
+	Score        float64
+}
+
+// Indexer builds and maintains an inverted index over Submission.data
+// fields marked Searchable on their owning Form, and answers Search
+// queries against it with BM25 ranking. Incremental updates go through an
+// op log (see IndexSubmission/DeleteSubmission) so a crash between a
+// submission write and its index write can be replayed by ReplayPending.
+type Indexer struct {
+	pool  *db.Pool
+	forms *repository.FormRepo
+	subs  *repository.SubmissionRepo
+	stem  bool
+
+	// suggest backs Suggest with a cheap in-memory prefix trie, updated
+	// alongside the inverted index rather than scanned from OxiDB on every
+	// call; see Suggester.
+	suggest *Suggester
+}
+
+// NewIndexer builds an Indexer. stem controls whether the light English
+// stemmer runs during analysis (DMS_SEARCH_STEMMING); it must stay
+// consistent between index time and query time; toggling it requires a
+// Reindex.
+func NewIndexer(pool *db.Pool, forms *repository.FormRepo, subs *repository.SubmissionRepo, stem bool) *Indexer {
+	return &Indexer{pool: pool, forms: forms, subs: subs, stem: stem, suggest: NewSuggester()}
+}
+
+func (ix *Indexer) EnsureIndexes() error {
+	c := ix.pool.Get()
+	if err := c.CreateUniqueIndex(IndexCollection, "term"); err != nil {
+		return err
+	}
+	if err := c.CreateUniqueIndex(DocTermsCollection, "submissionId"); err != nil {
+		return err
+	}
+	return c.CreateUniqueIndex(DocLenCollection, "submissionId")
+}
+
+// searchableFields returns the Searchable field names declared on form.
+func searchableFields(form *models.Form) map[string]bool {
+	fields := map[string]bool{}
+	for _, f := range form.TypedFields() {
+		if f.Searchable {
+			fields[f.Name] = true
+		}
+	}
+	return fields
+}
+
+// IndexSubmission (re)indexes sub under form, replacing any postings left
+// by a previous version of the same submission. It logs the op before
+// applying it so a crash mid-apply leaves a trail ReplayPending can finish.
+func (ix *Indexer) IndexSubmission(form *models.Form, sub *models.Submission) error {
+	opID, err := ix.logOp("index", sub.ID, sub.FormID, sub.Data)
+	if err != nil {
+		return fmt.Errorf("search: log index op: %w", err)
+	}
+	if err := ix.applyIndex(form, sub); err != nil {
+		return fmt.Errorf("search: apply index (recoverable via ReplayPending): %w", err)
+	}
+	return ix.clearOp(opID)
+}
+
+// DeleteSubmission removes sub's postings from the index.
+func (ix *Indexer) DeleteSubmission(sub *models.Submission) error {
+	opID, err := ix.logOp("delete", sub.ID, sub.FormID, nil)
+	if err != nil {
+		return fmt.Errorf("search: log delete op: %w", err)
+	}
+	if err := ix.applyDelete(sub.ID); err != nil {
+		return fmt.Errorf("search: apply delete (recoverable via ReplayPending): %w", err)
+	}
+	return ix.clearOp(opID)
+}
+
+func (ix *Indexer) applyIndex(form *models.Form, sub *models.Submission) error {
+	old, err := ix.getDocLen(sub.ID)
+	if err != nil {
+		return err
+	}
+	if err := ix.removePostings(sub.ID); err != nil {
+		return err
+	}
+	ix.suggest.RemoveAll(sub.ID)
+
+	fields := searchableFields(form)
+	allTerms := map[string]bool{}
+	length := 0
+	for field := range fields {
+		val, ok := sub.Data[field]
+		if !ok {
+			continue
+		}
+		text, ok := val.(string)
+		if !ok || text == "" {
+			continue
+		}
+		ix.suggest.Add(sub.ID, sub.ID+":"+field, "submission", text)
+		toks := Analyze(text, ix.stem)
+		if len(toks) == 0 {
+			continue
+		}
+		length += len(toks)
+
+		positionsByTerm := map[string][]int{}
+		for _, t := range toks {
+			positionsByTerm[t.Term] = append(positionsByTerm[t.Term], t.Position)
+			allTerms[t.Term] = true
+		}
+		for term, positions := range positionsByTerm {
+			if err := ix.addPosting(term, posting{
+				SubmissionID: sub.ID,
+				FormID:       sub.FormID,
+				Field:        field,
+				Positions:    positions,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	terms := make([]string, 0, len(allTerms))
+	for t := range allTerms {
+		terms = append(terms, t)
+	}
+	if len(terms) > 0 {
+		if err := ix.setDocTerms(sub.ID, terms); err != nil {
+			return err
+		}
+		if err := ix.setDocLen(sub.ID, sub.FormID, length); err != nil {
+			return err
+		}
+	}
+
+	return ix.adjustStats(old, length, len(terms) > 0)
+}
+
+func (ix *Indexer) applyDelete(submissionID string) error {
+	old, err := ix.getDocLen(submissionID)
+	if err != nil {
+		return err
+	}
+	if err := ix.removePostings(submissionID); err != nil {
+		return err
+	}
+	ix.suggest.RemoveAll(submissionID)
+	if old == nil {
+		return nil
+	}
+	return ix.adjustStats(old, 0, false)
+}
+
+// removePostings drops every posting sub contributed to the index, using
+// the DocTermsCollection row recorded by the previous IndexSubmission call
+// to avoid scanning every term in the index.
+func (ix *Indexer) removePostings(submissionID string) error {
+	c := ix.pool.Get()
+	row, err := c.FindOne(DocTermsCollection, map[string]any{"submissionId": submissionID})
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return nil
+	}
+	terms, _ := row["terms"].([]any)
+	for _, t := range terms {
+		term, _ := t.(string)
+		if term == "" {
+			continue
+		}
+		if err := ix.removePostingFromTerm(term, submissionID); err != nil {
+			return err
+		}
+	}
+	if _, err := c.Delete(DocTermsCollection, map[string]any{"submissionId": submissionID}); err != nil {
+		return err
+	}
+	_, err = c.Delete(DocLenCollection, map[string]any{"submissionId": submissionID})
+	return err
+}
+
+func (ix *Indexer) removePostingFromTerm(term, submissionID string) error {
+	c := ix.pool.Get()
+	return c.WithTransaction(func() error {
+		existing, err := c.FindOne(IndexCollection, map[string]any{"term": term})
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return nil
+		}
+		td, err := decodeTermDoc(existing)
+		if err != nil {
+			return err
+		}
+		kept := td.Postings[:0]
+		for _, p := range td.Postings {
+			if p.SubmissionID != submissionID {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			_, err := c.Delete(IndexCollection, map[string]any{"term": term})
+			return err
+		}
+		_, err = c.UpdateOne(IndexCollection, map[string]any{"term": term}, map[string]any{"$set": map[string]any{"postings": kept}})
+		return err
+	})
+}
+
+func (ix *Indexer) addPosting(term string, p posting) error {
+	c := ix.pool.Get()
+	return c.WithTransaction(func() error {
+		existing, err := c.FindOne(IndexCollection, map[string]any{"term": term})
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			_, err := c.Insert(IndexCollection, map[string]any{"term": term, "postings": []posting{p}})
+			return err
+		}
+		td, err := decodeTermDoc(existing)
+		if err != nil {
+			return err
+		}
+		td.Postings = append(td.Postings, p)
+		_, err = c.UpdateOne(IndexCollection, map[string]any{"term": term}, map[string]any{"$set": map[string]any{"postings": td.Postings}})
+		return err
+	})
+}
+
+func (ix *Indexer) setDocTerms(submissionID string, terms []string) error {
+	c := ix.pool.Get()
+	doc := map[string]any{"submissionId": submissionID, "terms": terms}
+	return upsert(c, DocTermsCollection, "submissionId", submissionID, doc)
+}
+
+func (ix *Indexer) setDocLen(submissionID, formID string, length int) error {
+	c := ix.pool.Get()
+	doc := map[string]any{"submissionId": submissionID, "formId": formID, "length": length}
+	return upsert(c, DocLenCollection, "submissionId", submissionID, doc)
+}
+
+func (ix *Indexer) getDocLen(submissionID string) (*docLenRow, error) {
+	c := ix.pool.Get()
+	raw, err := c.FindOne(DocLenCollection, map[string]any{"submissionId": submissionID})
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	var dl docLenRow
+	if err := remarshal(raw, &dl); err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+func (ix *Indexer) adjustStats(old *docLenRow, newLength int, nowIndexed bool) error {
+	c := ix.pool.Get()
+	return c.WithTransaction(func() error {
+		st, err := ix.loadStats(c)
+		if err != nil {
+			return err
+		}
+		if old != nil {
+			st.TotalLength -= old.Length
+		}
+		switch {
+		case old == nil && nowIndexed:
+			st.TotalDocs++
+		case old != nil && !nowIndexed:
+			st.TotalDocs--
+		}
+		if nowIndexed {
+			st.TotalLength += newLength
+		}
+		if st.TotalDocs < 0 {
+			st.TotalDocs = 0
+		}
+		if st.TotalLength < 0 {
+			st.TotalLength = 0
+		}
+		return ix.saveStats(c, st)
+	})
+}
+
+func (ix *Indexer) loadStats(c *oxidb.Client) (statsRow, error) {
+	raw, err := c.FindOne(StatsCollection, map[string]any{"key": statsKey})
+	if err != nil {
+		return statsRow{}, err
+	}
+	if raw == nil {
+		return statsRow{Key: statsKey}, nil
+	}
+	var st statsRow
+	if err := remarshal(raw, &st); err != nil {
+		return statsRow{}, err
+	}
+	return st, nil
+}
+
+func (ix *Indexer) saveStats(c *oxidb.Client, st statsRow) error {
+	st.Key = statsKey
+	doc := map[string]any{"key": st.Key, "totalDocs": st.TotalDocs, "totalLength": st.TotalLength}
+	return upsert(c, StatsCollection, "key", statsKey, doc)
+}
+
+// upsert finds the row where keyField == keyValue and replaces its fields
+// with doc, or inserts doc if no such row exists yet. The wire protocol has
+// no native upsert, so this mirrors the find-then-write pattern
+// repository.BlobRefRepo uses for its own ref-count rows; callers that need
+// it atomic against concurrent writers wrap it in c.WithTransaction.
+func upsert(c *oxidb.Client, collection, keyField, keyValue string, doc map[string]any) error {
+	existing, err := c.FindOne(collection, map[string]any{keyField: keyValue})
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		_, err := c.Insert(collection, doc)
+		return err
+	}
+	_, err = c.UpdateOne(collection, map[string]any{keyField: keyValue}, map[string]any{"$set": doc})
+	return err
+}
+
+func (ix *Indexer) loadTermDoc(term string) (*termDoc, error) {
+	c := ix.pool.Get()
+	raw, err := c.FindOne(IndexCollection, map[string]any{"term": term})
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	return decodeTermDoc(raw)
+}
+
+// loadTermDocsByPrefix scans the whole index for terms starting with
+// prefix. OxiDB's wire protocol has no prefix/range query over string
+// fields, so this is a client-side filter; acceptable at OxiDMS's corpus
+// sizes, but it's the one query shape here that isn't O(matching terms).
+func (ix *Indexer) loadTermDocsByPrefix(prefix string) ([]*termDoc, error) {
+	c := ix.pool.Get()
+	rows, err := c.Find(IndexCollection, map[string]any{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*termDoc
+	for _, row := range rows {
+		term, _ := row["term"].(string)
+		if !strings.HasPrefix(term, prefix) {
+			continue
+		}
+		td, err := decodeTermDoc(row)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, td)
+	}
+	return matches, nil
+}
+
+func decodeTermDoc(raw map[string]any) (*termDoc, error) {
+	var td termDoc
+	if err := remarshal(raw, &td); err != nil {
+		return nil, err
+	}
+	return &td, nil
+}
+
+func remarshal(raw map[string]any, out any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// --- op log, for crash recovery between a submission write and its index write ---
+
+func (ix *Indexer) logOp(op, submissionID, formID string, data map[string]any) (string, error) {
+	c := ix.pool.Get()
+	result, err := c.Insert(OpLogCollection, map[string]any{
+		"op":           op,
+		"submissionId": submissionID,
+		"formId":       formID,
+		"data":         data,
+		"createdAt":    time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+	return extractID(result), nil
+}
+
+func (ix *Indexer) clearOp(opID string) error {
+	if opID == "" {
+		return nil
+	}
+	c := ix.pool.Get()
+	_, err := c.Delete(OpLogCollection, map[string]any{"_id": toNumericID(opID)})
+	return err
+}
+
+// ReplayPending re-applies any op-log entries left behind by a crash
+// between a submission write and its index write, then clears them. Safe
+// to call repeatedly; each op is idempotent (applyIndex/applyDelete both
+// start by removing the submission's existing postings).
+func (ix *Indexer) ReplayPending() (int, error) {
+	c := ix.pool.Get()
+	rows, err := c.Find(OpLogCollection, map[string]any{}, nil)
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, row := range rows {
+		normalizeID(row)
+		opID, _ := row["_id"].(string)
+		op, _ := row["op"].(string)
+		submissionID, _ := row["submissionId"].(string)
+		formID, _ := row["formId"].(string)
+
+		switch op {
+		case "index":
+			form, err := ix.forms.FindByID(formID)
+			if err != nil {
+				return replayed, err
+			}
+			sub, err := ix.subs.FindByID(submissionID)
+			if err != nil {
+				return replayed, err
+			}
+			if form == nil || sub == nil {
+				// The form or submission was deleted after this op was
+				// logged and before it replayed; nothing left to index.
+				if err := ix.clearOp(opID); err != nil {
+					return replayed, err
+				}
+				continue
+			}
+			if err := ix.applyIndex(form, sub); err != nil {
+				return replayed, err
+			}
+		case "delete":
+			if err := ix.applyDelete(submissionID); err != nil {
+				return replayed, err
+			}
+		}
+		if err := ix.clearOp(opID); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Reindex rebuilds the entire search index from the Form/Submission
+// collections, which remain the source of truth. It also drops any
+// leftover op-log entries, since a full rebuild supersedes them.
+func (ix *Indexer) Reindex() (map[string]any, error) {
+	c := ix.pool.Get()
+	for _, collection := range []string{IndexCollection, DocTermsCollection, DocLenCollection, StatsCollection, OpLogCollection} {
+		if _, err := c.Delete(collection, map[string]any{}); err != nil {
+			return nil, fmt.Errorf("search: clear %s: %w", collection, err)
+		}
+	}
+
+	forms, err := ix.forms.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	const pageSize = 200
+	indexed, scanned := 0, 0
+	for i := range forms {
+		form := &forms[i]
+		ix.IndexForm(form)
+		if len(searchableFields(form)) == 0 {
+			continue
+		}
+		scanned++
+		skip := 0
+		for {
+			subs, total, err := ix.subs.FindByFormID(form.ID, skip, pageSize)
+			if err != nil {
+				return nil, err
+			}
+			for j := range subs {
+				if err := ix.applyIndex(form, &subs[j]); err != nil {
+					return nil, err
+				}
+				indexed++
+			}
+			skip += pageSize
+			if skip >= total || len(subs) == 0 {
+				break
+			}
+		}
+	}
+	return map[string]any{"formsScanned": scanned, "documentsIndexed": indexed}, nil
+}
+
+// Compact removes term rows left with no postings (shouldn't normally
+// happen, since removePostingFromTerm deletes them as soon as the last
+// posting is gone, but a Reindex interrupted partway through could leave
+// one) and asks the backend to compact the index collections.
+func (ix *Indexer) Compact() (map[string]any, error) {
+	c := ix.pool.Get()
+	rows, err := c.Find(IndexCollection, map[string]any{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	removed := 0
+	for _, row := range rows {
+		postings, _ := row["postings"].([]any)
+		if len(postings) > 0 {
+			continue
+		}
+		term, _ := row["term"].(string)
+		if _, err := c.Delete(IndexCollection, map[string]any{"term": term}); err == nil {
+			removed++
+		}
+	}
+	stats, err := c.Compact(IndexCollection)
+	if err != nil {
+		return nil, err
+	}
+	stats["emptyTermsRemoved"] = removed
+	return stats, nil
+}
+
+// --- query evaluation ---
+
+// hitInfo is one submission's accumulated BM25 score for a (sub-)query.
+type hitInfo struct {
+	formID string
+	score  float64
+}
+
+// hitSet maps submissionID to its accumulated score for a (sub-)query.
+type hitSet map[string]*hitInfo
+
+// searchCtx carries the corpus stats and a per-search doc-length cache
+// through query evaluation, so scoring the same submission against
+// multiple clauses doesn't refetch its length every time.
+type searchCtx struct {
+	totalDocs int
+	avgdl     float64
+	docLens   map[string]*docLenRow
+}
+
+func (ctx *searchCtx) docLen(ix *Indexer, submissionID string) (*docLenRow, error) {
+	if dl, ok := ctx.docLens[submissionID]; ok {
+		return dl, nil
+	}
+	dl, err := ix.getDocLen(submissionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.docLens[submissionID] = dl
+	return dl, nil
+}
+
+// Search parses query and returns matching submissions ranked by BM25,
+// optionally restricted to formID, with skip/limit pagination.
+func (ix *Indexer) Search(query, formID string, skip, limit int) ([]Hit, int, error) {
+	node, err := Parse(query, ix.stem)
+	if err != nil {
+		return nil, 0, err
+	}
+	hits, err := ix.rankedHits(node, formID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(hits)
+	if limit <= 0 {
+		limit = total
+	}
+	end := skip + limit
+	if end > total {
+		end = total
+	}
+	if skip > total {
+		skip = total
+	}
+	return hits[skip:end], total, nil
+}
+
+// rankedHits evaluates node (already Parse'd or Compile'd) and returns every
+// matching hit, restricted to formID, sorted best-first. It's the scoring
+// core shared by Search, SearchWithOptions, and SearchTextWithOptions; each
+// applies its own pagination and extras (highlighting, facets) on top.
+func (ix *Indexer) rankedHits(node QueryNode, formID string) ([]Hit, error) {
+	if _, ok := node.(NotNode); ok {
+		return nil, errors.New("search: a query can't start with NOT on its own, e.g. use \"a NOT b\"")
+	}
+
+	c := ix.pool.Get()
+	st, err := ix.loadStats(c)
+	if err != nil {
+		return nil, err
+	}
+	avgdl := 0.0
+	if st.TotalDocs > 0 {
+		avgdl = float64(st.TotalLength) / float64(st.TotalDocs)
+	}
+	ctx := &searchCtx{totalDocs: st.TotalDocs, avgdl: avgdl, docLens: map[string]*docLenRow{}}
+
+	scores, err := ix.eval(node, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for subID, hi := range scores {
+		if formID != "" && hi.formID != formID {
+			continue
+		}
+		hits = append(hits, Hit{SubmissionID: subID, FormID: hi.formID, Score: hi.score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].SubmissionID < hits[j].SubmissionID
+	})
+	return hits, nil
+}
+
+// withExtras wraps page, already cut from hits by the caller (by
+// SearchAfter/Limit or skip/limit), into a SearchOutcome and computes its
+// optional Highlight/Aggregations, shared by SearchWithOptions and
+// SearchTextWithOptions.
+func (ix *Indexer) withExtras(node QueryNode, hits []Hit, page []Hit, highlight *Highlight, aggs []Aggregation) (*SearchOutcome, error) {
+	outcome := &SearchOutcome{Hits: page, Total: len(hits)}
+	if highlight != nil {
+		exact, prefixes := queryTerms(node)
+		highlights, err := ix.highlightHits(page, exact, prefixes, *highlight)
+		if err != nil {
+			return nil, err
+		}
+		outcome.Highlights = highlights
+	}
+	if len(aggs) > 0 {
+		aggResults, err := ix.runAggregations(hits, aggs)
+		if err != nil {
+			return nil, err
+		}
+		outcome.Aggregations = aggResults
+	}
+	return outcome, nil
+}
+
+// SearchAfterCursor resumes a ranked search after the given hit, for
+// keyset pagination over deep result sets instead of Search's skip/limit,
+// which re-scores and re-sorts every call.
+type SearchAfterCursor struct {
+	Score        float64
+	SubmissionID string
+}
+
+// SearchOptions configures SearchWithOptions. Clause is required; FormID,
+// Limit, SearchAfter, Highlight, and Aggregations are all optional.
+type SearchOptions struct {
+	Clause Clause
+	FormID string
+	Limit  int
+
+	// SearchAfter, when set, returns hits ranked strictly after it instead of
+	// from the top; Limit then caps the page that follows it.
+	SearchAfter *SearchAfterCursor
+
+	// Highlight, when set, computes matched-term snippets for every hit.
+	Highlight *Highlight
+	// Aggregations, when set, computes facet buckets over every hit matching
+	// Clause (before SearchAfter/Limit narrow the page returned).
+	Aggregations []Aggregation
+}
+
+// SearchOutcome is the result of SearchWithOptions: Hits is the current
+// page, Total the full match count before paging, and Highlights/
+// Aggregations are populated only when SearchOptions requested them.
+type SearchOutcome struct {
+	Hits         []Hit
+	Total        int
+	Highlights   map[string][]string // SubmissionID -> fragments, only for Highlight
+	Aggregations []AggResult
+}
+
+// SearchWithOptions runs a structured Clause query (see Compile), the
+// equivalent of Search for callers that built a Clause tree instead of a
+// query string, and optionally computes highlighting and facets alongside
+// it. It exists alongside Search rather than replacing it so existing
+// callers of the simpler string-query API are unaffected.
+func (ix *Indexer) SearchWithOptions(opts SearchOptions) (*SearchOutcome, error) {
+	node, err := Compile(opts.Clause, ix.stem)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := ix.rankedHits(node, opts.FormID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := findAfter(hits, opts.SearchAfter)
+	limit := opts.Limit
+	if limit <= 0 || limit > len(page) {
+		limit = len(page)
+	}
+	page = page[:limit]
+
+	return ix.withExtras(node, hits, page, opts.Highlight, opts.Aggregations)
+}
+
+// SearchTextWithOptions is Search plus optional highlighting and faceting,
+// for callers with a query string (rather than a Clause) that still want
+// SearchOutcome's extras — the combination service.SearchService's FTS mode
+// needs.
+func (ix *Indexer) SearchTextWithOptions(query, formID string, skip, limit int, highlight *Highlight, aggs []Aggregation) (*SearchOutcome, error) {
+	node, err := Parse(query, ix.stem)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := ix.rankedHits(node, formID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(hits)
+	if limit <= 0 {
+		limit = total
+	}
+	end := skip + limit
+	if end > total {
+		end = total
+	}
+	if skip > total {
+		skip = total
+	}
+	page := hits[skip:end]
+
+	return ix.withExtras(node, hits, page, highlight, aggs)
+}
+
+// findAfter returns the suffix of hits (already sorted best-first) that
+// ranks strictly after cursor, or all of hits when cursor is nil.
+func findAfter(hits []Hit, cursor *SearchAfterCursor) []Hit {
+	if cursor == nil {
+		return hits
+	}
+	for i, h := range hits {
+		if h.Score < cursor.Score || (h.Score == cursor.Score && h.SubmissionID > cursor.SubmissionID) {
+			return hits[i:]
+		}
+	}
+	return nil
+}
+
+// highlightHits builds matched-term fragments for each hit by re-fetching
+// its submission and re-tokenizing its Searchable text fields at query time,
+// rather than persisting character offsets in the index. exact and prefixes
+// are the query's own analyzed terms, from queryTerms.
+func (ix *Indexer) highlightHits(hits []Hit, exact map[string]bool, prefixes []string, opts Highlight) (map[string][]string, error) {
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	result := make(map[string][]string, len(hits))
+	for _, hit := range hits {
+		sub, err := ix.subs.FindByID(hit.SubmissionID)
+		if err != nil {
+			return nil, err
+		}
+		if sub == nil {
+			continue
+		}
+
+		fields := opts.Fields
+		if len(fields) == 0 {
+			form, err := ix.forms.FindByID(hit.FormID)
+			if err != nil {
+				return nil, err
+			}
+			if form == nil {
+				continue
+			}
+			for f := range searchableFields(form) {
+				fields = append(fields, f)
+			}
+		}
+
+		var fragments []string
+		for _, field := range fields {
+			text, ok := sub.Data[field].(string)
+			if !ok || text == "" {
+				continue
+			}
+			fragments = append(fragments, highlightField(text, exact, prefixes, ix.stem, opts)...)
+		}
+		if len(fragments) > 0 {
+			result[hit.SubmissionID] = fragments
+		}
+	}
+	return result, nil
+}
+
+// queryTerms walks node, a compiled QueryNode tree, and collects the exact
+// and prefix terms it matches on — the words highlightHits should look for
+// in each hit's text — skipping NotNode subtrees, since a term excluded by
+// NOT shouldn't be highlighted as a match.
+func queryTerms(node QueryNode) (exact map[string]bool, prefixes []string) {
+	exact = map[string]bool{}
+	var walk func(QueryNode)
+	walk = func(n QueryNode) {
+		switch v := n.(type) {
+		case TermNode:
+			if v.Prefix {
+				prefixes = append(prefixes, v.Term)
+			} else {
+				exact[v.Term] = true
+			}
+		case PhraseNode:
+			for _, t := range v.Terms {
+				exact[t] = true
+			}
+		case AndNode:
+			for _, child := range v.Nodes {
+				if _, ok := child.(NotNode); ok {
+					continue
+				}
+				walk(child)
+			}
+		case OrNode:
+			for _, child := range v.Nodes {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return exact, prefixes
+}
+
+func (ix *Indexer) eval(node QueryNode, ctx *searchCtx) (hitSet, error) {
+	switch n := node.(type) {
+	case TermNode:
+		return ix.evalTerm(n, ctx)
+	case PhraseNode:
+		return ix.evalPhrase(n, ctx)
+	case AndNode:
+		return ix.evalAnd(n, ctx)
+	case OrNode:
+		return ix.evalOr(n, ctx)
+	case NotNode:
+		return nil, errors.New("search: NOT must be combined with at least one positive term")
+	default:
+		return nil, fmt.Errorf("search: unknown query node %T", node)
+	}
+}
+
+func (ix *Indexer) evalTerm(n TermNode, ctx *searchCtx) (hitSet, error) {
+	var docs []*termDoc
+	if n.Prefix {
+		matches, err := ix.loadTermDocsByPrefix(n.Term)
+		if err != nil {
+			return nil, err
+		}
+		docs = matches
+	} else {
+		td, err := ix.loadTermDoc(n.Term)
+		if err != nil {
+			return nil, err
+		}
+		if td != nil {
+			docs = []*termDoc{td}
+		}
+	}
+
+	result := hitSet{}
+	for _, td := range docs {
+		scored, err := ix.scoreTermDoc(td, n.Field, ctx)
+		if err != nil {
+			return nil, err
+		}
+		mergeSum(result, scored)
+	}
+	return result, nil
+}
+
+func (ix *Indexer) scoreTermDoc(td *termDoc, field string, ctx *searchCtx) (hitSet, error) {
+	tfBySub := map[string]int{}
+	formBySub := map[string]string{}
+	for _, p := range td.Postings {
+		if field != "" && p.Field != field {
+			continue
+		}
+		tfBySub[p.SubmissionID] += len(p.Positions)
+		formBySub[p.SubmissionID] = p.FormID
+	}
+	if len(tfBySub) == 0 {
+		return hitSet{}, nil
+	}
+
+	idf := idfOf(len(tfBySub), ctx.totalDocs)
+	result := hitSet{}
+	for subID, tf := range tfBySub {
+		dl, err := ctx.docLen(ix, subID)
+		if err != nil {
+			return nil, err
+		}
+		length := ctx.avgdl
+		if dl != nil {
+			length = float64(dl.Length)
+		}
+		result[subID] = &hitInfo{formID: formBySub[subID], score: bm25Score(idf, tf, length, ctx.avgdl)}
+	}
+	return result, nil
+}
+
+func (ix *Indexer) evalPhrase(n PhraseNode, ctx *searchCtx) (hitSet, error) {
+	if len(n.Terms) == 0 {
+		return hitSet{}, nil
+	}
+	termDocs := make([]*termDoc, len(n.Terms))
+	for i, t := range n.Terms {
+		td, err := ix.loadTermDoc(t)
+		if err != nil {
+			return nil, err
+		}
+		if td == nil {
+			return hitSet{}, nil
+		}
+		termDocs[i] = td
+	}
+
+	type docField struct{ sub, field string }
+	first := map[docField]map[int]bool{}
+	formBySub := map[string]string{}
+	for _, p := range termDocs[0].Postings {
+		if n.Field != "" && p.Field != n.Field {
+			continue
+		}
+		k := docField{p.SubmissionID, p.Field}
+		set := first[k]
+		if set == nil {
+			set = map[int]bool{}
+			first[k] = set
+		}
+		for _, pos := range p.Positions {
+			set[pos] = true
+		}
+		formBySub[p.SubmissionID] = p.FormID
+	}
+
+	matchedSubs := map[string]bool{}
+	for k, positions := range first {
+		for pos := range positions {
+			if phraseContinues(termDocs[1:], k.sub, k.field, pos+1) {
+				matchedSubs[k.sub] = true
+				break
+			}
+		}
+	}
+	if len(matchedSubs) == 0 {
+		return hitSet{}, nil
+	}
+
+	idf := idfOf(len(matchedSubs), ctx.totalDocs)
+	result := hitSet{}
+	for subID := range matchedSubs {
+		dl, err := ctx.docLen(ix, subID)
+		if err != nil {
+			return nil, err
+		}
+		length := ctx.avgdl
+		if dl != nil {
+			length = float64(dl.Length)
+		}
+		result[subID] = &hitInfo{formID: formBySub[subID], score: bm25Score(idf, 1, length, ctx.avgdl)}
+	}
+	return result, nil
+}
+
+// phraseContinues reports whether every term doc in rest has a posting for
+// (sub, field) at the expected consecutive position, starting at pos.
+func phraseContinues(rest []*termDoc, sub, field string, pos int) bool {
+	for i, td := range rest {
+		if !hasPosition(td, sub, field, pos+i) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPosition(td *termDoc, sub, field string, pos int) bool {
+	for _, p := range td.Postings {
+		if p.SubmissionID != sub || p.Field != field {
+			continue
+		}
+		for _, x := range p.Positions {
+			if x == pos {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ix *Indexer) evalAnd(n AndNode, ctx *searchCtx) (hitSet, error) {
+	var positives, negatives []QueryNode
+	for _, child := range n.Nodes {
+		if not, ok := child.(NotNode); ok {
+			negatives = append(negatives, not.Node)
+		} else {
+			positives = append(positives, child)
+		}
+	}
+	if len(positives) == 0 {
+		return nil, errors.New("search: AND needs at least one positive term")
+	}
+
+	result, err := ix.eval(positives[0], ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range positives[1:] {
+		next, err := ix.eval(p, ctx)
+		if err != nil {
+			return nil, err
+		}
+		result = intersectSum(result, next)
+	}
+	for _, neg := range negatives {
+		excl, err := ix.eval(neg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		for subID := range excl {
+			delete(result, subID)
+		}
+	}
+	return result, nil
+}
+
+func (ix *Indexer) evalOr(n OrNode, ctx *searchCtx) (hitSet, error) {
+	result := hitSet{}
+	for _, child := range n.Nodes {
+		hs, err := ix.eval(child, ctx)
+		if err != nil {
+			return nil, err
+		}
+		mergeSum(result, hs)
+	}
+	return result, nil
+}
+
+func mergeSum(dst, src hitSet) {
+	for subID, hi := range src {
+		if existing, ok := dst[subID]; ok {
+			existing.score += hi.score
+		} else {
+			cp := *hi
+			dst[subID] = &cp
+		}
+	}
+}
+
+func intersectSum(a, b hitSet) hitSet {
+	result := hitSet{}
+	for subID, hi := range a {
+		if other, ok := b[subID]; ok {
+			result[subID] = &hitInfo{formID: hi.formID, score: hi.score + other.score}
+		}
+	}
+	return result
+}
+
+func idfOf(df, totalDocs int) float64 {
+	if df <= 0 || totalDocs <= 0 {
+		return 0
+	}
+	return math.Log(1 + (float64(totalDocs-df)+0.5)/(float64(df)+0.5))
+}
+
+func bm25Score(idf float64, tf int, docLength, avgdl float64) float64 {
+	if avgdl <= 0 {
+		avgdl = docLength
+	}
+	if avgdl <= 0 {
+		avgdl = 1
+	}
+	denom := float64(tf) + bm25K1*(1-bm25B+bm25B*docLength/avgdl)
+	if denom <= 0 {
+		return 0
+	}
+	return idf * (float64(tf) * (bm25K1 + 1)) / denom
+}
+
+// --- typeahead suggestions ---
+
+// IndexForm (re)indexes form's name for Suggest. Called whenever a form is
+// created or updated, and once per form during a full Reindex.
+func (ix *Indexer) IndexForm(form *models.Form) {
+	ix.suggest.Add(form.ID, form.ID, "form", form.Name)
+}
+
+// DeleteForm removes form's name from Suggest.
+func (ix *Indexer) DeleteForm(formID string) {
+	ix.suggest.RemoveAll(formID)
+}
+
+// IndexDocument (re)indexes doc's file name for Suggest. Called whenever a
+// document is uploaded.
+func (ix *Indexer) IndexDocument(doc *models.Document) {
+	ix.suggest.Add(doc.ID, doc.ID, "document", doc.FileName)
+}
+
+// DeleteDocument removes doc's file name from Suggest.
+func (ix *Indexer) DeleteDocument(docID string) {
+	ix.suggest.RemoveAll(docID)
+}
+
+// Suggest returns up to limit form names, document titles, and recent
+// submission field values starting with prefix, for typeahead search. It
+// never touches OxiDB — see Suggester.
+func (ix *Indexer) Suggest(prefix string, limit int) []string {
+	return ix.suggest.Suggest(prefix, limit)
+}
+
+// --- small helpers mirroring repository.helpers, kept local since this
+// package doesn't import repository's unexported identifiers ---
+
+func normalizeID(doc map[string]any) {
+	if id, ok := doc["_id"]; ok {
+		switch v := id.(type) {
+		case float64:
+			doc["_id"] = strconv.FormatFloat(v, 'f', 0, 64)
+		case int:
+			doc["_id"] = strconv.Itoa(v)
+		}
+	}
+}
+
+func extractID(result map[string]any) string {
+	if id, ok := result["id"]; ok {
+		switch v := id.(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+	}
+	return ""
+}
+
+func toNumericID(id string) any {
+	if n, err := strconv.ParseFloat(id, 64); err == nil {
+		return n
+	}
+	return id
+}
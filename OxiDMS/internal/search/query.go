@@ -0,0 +1,454 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryNode is a node in a query expression tree produced by Parse.
+type QueryNode interface{ isQueryNode() }
+
+// TermNode matches documents containing Term (already analyzed). Field
+// restricts the match to that Searchable field; "" matches any field.
+// Prefix, when set, matches any indexed term starting with Term instead of
+// requiring an exact match.
+type TermNode struct {
+	Field  string
+	Term   string
+	Prefix bool
+}
+
+// PhraseNode matches documents where Terms appear at consecutive positions
+// within a single field, in order.
+type PhraseNode struct {
+	Field string
+	Terms []string
+}
+
+// NotNode excludes documents matched by Node. Only meaningful as a clause
+// of an AndNode — see Indexer.evalAnd.
+type NotNode struct{ Node QueryNode }
+
+// AndNode matches documents that satisfy every node in Nodes.
+type AndNode struct{ Nodes []QueryNode }
+
+// OrNode matches documents that satisfy any node in Nodes.
+type OrNode struct{ Nodes []QueryNode }
+
+func (TermNode) isQueryNode()   {}
+func (PhraseNode) isQueryNode() {}
+func (NotNode) isQueryNode()    {}
+func (AndNode) isQueryNode()    {}
+func (OrNode) isQueryNode()     {}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokEOF
+)
+
+type lexToken struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits a query string into words, quoted phrases, AND/OR/NOT
+// keywords (case-insensitive) and parens, honoring quotes so spaces inside
+// a phrase don't split it.
+func lex(q string) []lexToken {
+	runes := []rune(q)
+	var toks []lexToken
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, lexToken{kind: tokLParen})
+			i++
+		case r == ')':
+			toks = append(toks, lexToken{kind: tokRParen})
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			toks = append(toks, lexToken{kind: tokPhrase, text: string(runes[i+1 : j])})
+			if j < len(runes) {
+				j++
+			}
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '(' && runes[j] != ')' && runes[j] != '"' {
+				j++
+			}
+			word := string(runes[i:j])
+			i = j
+			switch strings.ToUpper(word) {
+			case "AND":
+				toks = append(toks, lexToken{kind: tokAnd})
+			case "OR":
+				toks = append(toks, lexToken{kind: tokOr})
+			case "NOT":
+				toks = append(toks, lexToken{kind: tokNot})
+			default:
+				toks = append(toks, lexToken{kind: tokWord, text: word})
+			}
+		}
+	}
+	return toks
+}
+
+type parser struct {
+	toks []lexToken
+	pos  int
+	stem bool
+}
+
+// Parse compiles a query string into a QueryNode tree. Supported syntax:
+// bare words (implicitly AND'd), AND/OR/NOT keywords, parenthesized
+// grouping, "quoted phrases", field-scoped terms ("title:invoice"), a
+// leading "-" as shorthand for NOT ("-draft"), and trailing-"*" prefix
+// terms ("inv*"). stem must match the Indexer's own setting, since a term
+// analyzed one way at query time won't match postings analyzed the other
+// way at index time.
+func Parse(q string, stem bool) (QueryNode, error) {
+	p := &parser{toks: lex(q), stem: stem}
+	if len(p.toks) == 0 {
+		return nil, errors.New("search: empty query")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.toks) {
+		return nil, fmt.Errorf("search: unexpected %q in query", p.toks[p.pos].text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() tokenKind {
+	if p.pos >= len(p.toks) {
+		return tokEOF
+	}
+	return p.toks[p.pos].kind
+}
+
+func (p *parser) parseOr() (QueryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []QueryNode{left}
+	for p.peek() == tokOr {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return OrNode{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (QueryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []QueryNode{left}
+	for {
+		switch p.peek() {
+		case tokAnd:
+			p.pos++
+			right, err := p.parseNot()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, right)
+		case tokWord, tokPhrase, tokNot, tokLParen:
+			// No explicit operator between two atoms means AND, the same
+			// default most search engines use.
+			right, err := p.parseNot()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, right)
+		default:
+			if len(nodes) == 1 {
+				return nodes[0], nil
+			}
+			return AndNode{Nodes: nodes}, nil
+		}
+	}
+}
+
+func (p *parser) parseNot() (QueryNode, error) {
+	if p.peek() == tokNot {
+		p.pos++
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return NotNode{Node: inner}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *parser) parseAtom() (QueryNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, errors.New("search: unexpected end of query")
+	}
+	tok := p.toks[p.pos]
+	switch tok.kind {
+	case tokLParen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != tokRParen {
+			return nil, errors.New("search: expected closing paren")
+		}
+		p.pos++
+		return node, nil
+	case tokPhrase:
+		p.pos++
+		return newPhraseNode("", tok.text, p.stem)
+	case tokWord:
+		p.pos++
+		return p.parseWord(tok.text)
+	default:
+		return nil, fmt.Errorf("search: unexpected token in query")
+	}
+}
+
+func (p *parser) parseWord(text string) (QueryNode, error) {
+	if strings.HasPrefix(text, "-") && len(text) > 1 {
+		inner, err := p.parseWord(text[1:])
+		if err != nil {
+			return nil, err
+		}
+		return NotNode{Node: inner}, nil
+	}
+
+	field, rest := splitField(text)
+
+	// "field:" directly followed by a quoted phrase, e.g. title:"annual report".
+	if rest == "" && field != "" && p.peek() == tokPhrase {
+		phraseTok := p.toks[p.pos]
+		p.pos++
+		return newPhraseNode(field, phraseTok.text, p.stem)
+	}
+
+	prefix := false
+	if strings.HasSuffix(rest, "*") {
+		prefix = true
+		rest = strings.TrimSuffix(rest, "*")
+	}
+
+	terms := Analyze(rest, p.stem)
+	switch len(terms) {
+	case 0:
+		return nil, fmt.Errorf("search: %q has no searchable term", text)
+	case 1:
+		return TermNode{Field: field, Term: terms[0].Term, Prefix: prefix}, nil
+	default:
+		// The "word" contained internal punctuation (e.g. "re-upload") and
+		// analyzed to more than one term; treat it as an implicit phrase.
+		strs := make([]string, len(terms))
+		for i, t := range terms {
+			strs[i] = t.Term
+		}
+		return PhraseNode{Field: field, Terms: strs}, nil
+	}
+}
+
+func newPhraseNode(field, raw string, stem bool) (QueryNode, error) {
+	toks := Analyze(raw, stem)
+	if len(toks) == 0 {
+		return nil, errors.New("search: phrase has no searchable terms")
+	}
+	terms := make([]string, len(toks))
+	for i, t := range toks {
+		terms[i] = t.Term
+	}
+	if len(terms) == 1 {
+		return TermNode{Field: field, Term: terms[0]}, nil
+	}
+	return PhraseNode{Field: field, Terms: terms}, nil
+}
+
+// splitField splits "field:rest" into ("field", "rest"); a word with no
+// colon, or one starting with a colon, has no field scope.
+func splitField(s string) (field, rest string) {
+	if idx := strings.IndexByte(s, ':'); idx > 0 {
+		return s[:idx], s[idx+1:]
+	}
+	return "", s
+}
+
+// ------------------------------------------------------------------
+// Structured query DSL
+// ------------------------------------------------------------------
+
+// Clause is a node in a structured query, for callers building a query
+// programmatically (e.g. from a UI's filter builder) instead of parsing a
+// query string. Compile turns a Clause into the same QueryNode tree Parse
+// produces, so Indexer.eval needs no separate evaluation path for it.
+type Clause interface {
+	compile(stem bool) (QueryNode, error)
+}
+
+// MatchClause matches Text, analyzed the same way a bare word is, against
+// Field ("" for any Searchable field).
+type MatchClause struct {
+	Field string
+	Text  string
+}
+
+// MultiMatchClause matches Text against any one of Fields, OR'd together —
+// the structured-DSL equivalent of repeating a MatchClause per field.
+type MultiMatchClause struct {
+	Fields []string
+	Text   string
+}
+
+// PhraseClause matches Text's analyzed terms at consecutive positions within
+// Field, in order.
+type PhraseClause struct {
+	Field string
+	Text  string
+}
+
+// PrefixClause matches any indexed term in Field starting with Text.
+type PrefixClause struct {
+	Field string
+	Text  string
+}
+
+// BoolClause combines sub-clauses the way Elasticsearch's bool query does:
+// every Must clause has to match, at least one Should clause has to match
+// (when Should is non-empty), no MustNot clause may match, and Filter
+// clauses narrow the match like Must without being scored — since this
+// package's scoring is BM25-only per term, Filter compiles identically to
+// Must here.
+type BoolClause struct {
+	Must    []Clause
+	Should  []Clause
+	MustNot []Clause
+	Filter  []Clause
+}
+
+func (c MatchClause) compile(stem bool) (QueryNode, error) {
+	terms := Analyze(c.Text, stem)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search: match clause %q has no searchable term", c.Text)
+	}
+	if len(terms) == 1 {
+		return TermNode{Field: c.Field, Term: terms[0].Term}, nil
+	}
+	strs := make([]string, len(terms))
+	for i, t := range terms {
+		strs[i] = t.Term
+	}
+	return PhraseNode{Field: c.Field, Terms: strs}, nil
+}
+
+func (c MultiMatchClause) compile(stem bool) (QueryNode, error) {
+	if len(c.Fields) == 0 {
+		return MatchClause{Text: c.Text}.compile(stem)
+	}
+	nodes := make([]QueryNode, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		node, err := (MatchClause{Field: field, Text: c.Text}).compile(stem)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return OrNode{Nodes: nodes}, nil
+}
+
+func (c PhraseClause) compile(stem bool) (QueryNode, error) {
+	return newPhraseNode(c.Field, c.Text, stem)
+}
+
+func (c PrefixClause) compile(stem bool) (QueryNode, error) {
+	terms := Analyze(c.Text, stem)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search: prefix clause %q has no searchable term", c.Text)
+	}
+	return TermNode{Field: c.Field, Term: terms[0].Term, Prefix: true}, nil
+}
+
+func (c BoolClause) compile(stem bool) (QueryNode, error) {
+	var nodes []QueryNode
+
+	for _, sub := range append(append([]Clause{}, c.Must...), c.Filter...) {
+		node, err := sub.compile(stem)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(c.Should) > 0 {
+		should := make([]QueryNode, 0, len(c.Should))
+		for _, sub := range c.Should {
+			node, err := sub.compile(stem)
+			if err != nil {
+				return nil, err
+			}
+			should = append(should, node)
+		}
+		if len(should) == 1 {
+			nodes = append(nodes, should[0])
+		} else {
+			nodes = append(nodes, OrNode{Nodes: should})
+		}
+	}
+
+	for _, sub := range c.MustNot {
+		node, err := sub.compile(stem)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, NotNode{Node: node})
+	}
+
+	if len(nodes) == 0 {
+		return nil, errors.New("search: bool clause has no must, should, or filter clauses")
+	}
+	if len(nodes) == 1 {
+		if _, ok := nodes[0].(NotNode); ok {
+			return nil, errors.New("search: a query can't consist of only must_not clauses")
+		}
+		return nodes[0], nil
+	}
+	return AndNode{Nodes: nodes}, nil
+}
+
+// Compile turns clause into a QueryNode tree, the structured-DSL equivalent
+// of Parse. stem must match the Indexer's own setting, same as Parse.
+func Compile(clause Clause, stem bool) (QueryNode, error) {
+	return clause.compile(stem)
+}
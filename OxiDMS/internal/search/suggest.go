@@ -0,0 +1,183 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// suggestEntry is one indexed string a Suggester can return from Suggest.
+type suggestEntry struct {
+	id   string
+	kind string // "form", "document", or "submission"
+	text string
+	at   time.Time
+}
+
+// trieNode is one character of a Suggester's index; entries holds every
+// suggestEntry whose text ends exactly at this node, keyed by entry ID so
+// two different sources can share the same text without colliding.
+type trieNode struct {
+	children map[byte]*trieNode
+	entries  map[string]*suggestEntry
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: map[byte]*trieNode{}}
+}
+
+// Suggester is an in-memory prefix trie over form names, document titles,
+// and submission field values, answering typeahead queries without a
+// round trip to OxiDB. It's rebuilt incrementally as those mutate (see
+// Indexer.IndexForm/DeleteForm, IndexDocument/DeleteDocument, and
+// applyIndex/applyDelete's own calls for submissions) rather than kept in
+// sync with a background scan, the same tradeoff the rest of this package
+// makes for the inverted index itself.
+type Suggester struct {
+	mu   sync.RWMutex
+	root *trieNode
+	// byID maps an entry ID to the node it lives at, so Remove doesn't need
+	// to re-walk the trie from the text alone.
+	byID map[string]*trieNode
+	// children maps a parent ID (e.g. a submission ID) to every entry ID
+	// added under it, so RemoveAll can drop a whole submission's field
+	// values in one call without the caller enumerating fields itself —
+	// mirroring how removePostings uses DocTermsCollection instead of
+	// scanning every term in the index.
+	children map[string][]string
+}
+
+// NewSuggester returns an empty Suggester.
+func NewSuggester() *Suggester {
+	return &Suggester{root: newTrieNode(), byID: map[string]*trieNode{}, children: map[string][]string{}}
+}
+
+// Add indexes text under entryID, grouped for removal under parentID
+// (RemoveAll(parentID) removes it along with every other entry added under
+// the same parentID). parentID == entryID for a 1:1 source like a form or
+// document. Re-adding the same entryID replaces its previous text. A blank
+// text is a no-op.
+func (s *Suggester) Add(parentID, entryID, kind, text string) {
+	norm := normalizeSuggest(text)
+	if norm == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(entryID)
+
+	node := s.root
+	for i := 0; i < len(norm); i++ {
+		b := norm[i]
+		child, ok := node.children[b]
+		if !ok {
+			child = newTrieNode()
+			node.children[b] = child
+		}
+		node = child
+	}
+	if node.entries == nil {
+		node.entries = map[string]*suggestEntry{}
+	}
+	node.entries[entryID] = &suggestEntry{id: entryID, kind: kind, text: text, at: time.Now()}
+	s.byID[entryID] = node
+	s.children[parentID] = appendUnique(s.children[parentID], entryID)
+}
+
+// RemoveAll removes every entry previously Add'ed under parentID.
+func (s *Suggester) RemoveAll(parentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entryID := range s.children[parentID] {
+		s.removeLocked(entryID)
+	}
+	delete(s.children, parentID)
+}
+
+func (s *Suggester) removeLocked(entryID string) {
+	node, ok := s.byID[entryID]
+	if !ok {
+		return
+	}
+	delete(node.entries, entryID)
+	delete(s.byID, entryID)
+}
+
+// Suggest returns up to limit distinct texts whose normalized form starts
+// with prefix, most recently indexed first.
+func (s *Suggester) Suggest(prefix string, limit int) []string {
+	if limit <= 0 {
+		limit = 10
+	}
+	norm := normalizeSuggest(prefix)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node := s.root
+	for i := 0; i < len(norm); i++ {
+		child, ok := node.children[norm[i]]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	// Collect at most limit*20 entries before sorting by recency and
+	// truncating, so a very broad prefix ("a") doesn't walk the whole
+	// subtree. When more entries than that match, the result is the newest
+	// of the ones collected (in map-iteration order), not necessarily the
+	// newest overall.
+	var matches []*suggestEntry
+	collectSuggestions(node, &matches, limit*20)
+	sort.Slice(matches, func(i, j int) bool { return matches[i].at.After(matches[j].at) })
+
+	seen := map[string]bool{}
+	out := make([]string, 0, limit)
+	for _, m := range matches {
+		if seen[m.text] {
+			continue
+		}
+		seen[m.text] = true
+		out = append(out, m.text)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// collectSuggestions walks node's subtree depth-first, appending every
+// entry found to matches, stopping early once cap is reached.
+func collectSuggestions(node *trieNode, matches *[]*suggestEntry, cap int) {
+	if len(*matches) >= cap {
+		return
+	}
+	for _, e := range node.entries {
+		*matches = append(*matches, e)
+		if len(*matches) >= cap {
+			return
+		}
+	}
+	for _, child := range node.children {
+		collectSuggestions(child, matches, cap)
+		if len(*matches) >= cap {
+			return
+		}
+	}
+}
+
+func normalizeSuggest(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func appendUnique(ids []string, id string) []string {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -10,20 +10,25 @@ import (
 )
 
 type AuthService struct {
-	users     *repository.UserRepo
-	jwtSecret string
+	users    *repository.UserRepo
+	sessions *auth.SessionManager
 }
 
-func NewAuthService(users *repository.UserRepo, jwtSecret string) *AuthService {
-	return &AuthService{users: users, jwtSecret: jwtSecret}
+func NewAuthService(users *repository.UserRepo, sessions *auth.SessionManager) *AuthService {
+	return &AuthService{users: users, sessions: sessions}
 }
 
+// AuthResult is the access/refresh token pair handed back by Register,
+// Login, and Refresh. AccessToken is the short-lived JWT sent as a Bearer
+// token; RefreshToken is the opaque value to present at POST /auth/refresh
+// once AccessToken expires.
 type AuthResult struct {
-	Token string              `json:"token"`
-	User  models.UserResponse `json:"user"`
+	AccessToken  string              `json:"accessToken"`
+	RefreshToken string              `json:"refreshToken"`
+	User         models.UserResponse `json:"user"`
 }
 
-func (s *AuthService) Register(email, password, name string) (*AuthResult, error) {
+func (s *AuthService) Register(email, password, name, userAgent, ip string) (*AuthResult, error) {
 	existing, _ := s.users.FindByEmail(email)
 	if existing != nil {
 		return nil, errors.New("email already registered")
@@ -44,14 +49,14 @@ func (s *AuthService) Register(email, password, name string) (*AuthResult, error
 		return nil, err
 	}
 	user.ID = id
-	token, err := auth.GenerateToken(s.jwtSecret, id, email, user.Role)
+	tokens, err := s.sessions.Issue(id, email, user.Role, userAgent, ip)
 	if err != nil {
 		return nil, err
 	}
-	return &AuthResult{Token: token, User: user.ToResponse()}, nil
+	return &AuthResult{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user.ToResponse()}, nil
 }
 
-func (s *AuthService) Login(email, password string) (*AuthResult, error) {
+func (s *AuthService) Login(email, password, userAgent, ip string) (*AuthResult, error) {
 	user, err := s.users.FindByEmail(email)
 	if err != nil {
 		return nil, err
@@ -62,11 +67,43 @@ func (s *AuthService) Login(email, password string) (*AuthResult, error) {
 	if !auth.CheckPassword(password, user.PasswordHash) {
 		return nil, errors.New("invalid credentials")
 	}
-	token, err := auth.GenerateToken(s.jwtSecret, user.ID, user.Email, user.Role)
+	tokens, err := s.sessions.Issue(user.ID, user.Email, user.Role, userAgent, ip)
 	if err != nil {
 		return nil, err
 	}
-	return &AuthResult{Token: token, User: user.ToResponse()}, nil
+	return &AuthResult{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user.ToResponse()}, nil
+}
+
+// Refresh exchanges a still-valid refresh token for a new access/refresh
+// pair, rotating out the one presented (see auth.SessionManager.Rotate).
+func (s *AuthService) Refresh(refreshToken, userAgent, ip string) (*AuthResult, error) {
+	userID, err := s.sessions.Rotate(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	tokens, err := s.sessions.Issue(user.ID, user.Email, user.Role, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResult{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, User: user.ToResponse()}, nil
+}
+
+// Logout revokes the session refreshToken belongs to.
+func (s *AuthService) Logout(refreshToken string) error {
+	return s.sessions.Revoke(refreshToken)
+}
+
+// LogoutAll revokes every session belonging to userID, signing the user
+// out everywhere at once.
+func (s *AuthService) LogoutAll(userID string) error {
+	return s.sessions.RevokeAll(userID)
 }
 
 func (s *AuthService) Me(userID string) (*models.UserResponse, error) {
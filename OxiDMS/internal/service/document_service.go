@@ -1,44 +1,80 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
 )
 
 type DocumentService struct {
-	docs *repository.DocumentRepo
+	docs     *repository.DocumentRepo
+	blobRefs *repository.BlobRefRepo
+	index    *search.Indexer
+	metrics  *metrics.Registry
 }
 
-func NewDocumentService(docs *repository.DocumentRepo) *DocumentService {
-	return &DocumentService{docs: docs}
+func NewDocumentService(docs *repository.DocumentRepo, blobRefs *repository.BlobRefRepo, index *search.Indexer, reg *metrics.Registry) *DocumentService {
+	return &DocumentService{docs: docs, blobRefs: blobRefs, index: index, metrics: reg}
 }
 
-func (s *DocumentService) Upload(fileName string, data []byte, contentType, formID, submissionID, uploadedBy string) (*models.Document, error) {
-	if len(data) == 0 {
-		return nil, errors.New("file data is empty")
-	}
-
+// Upload reads r to completion, staging it to a local temp file while
+// hashing it so multi-GB uploads never sit fully in memory: the blob's key
+// (the SHA-256 of its content) isn't known until the last byte arrives,
+// but the bytes themselves only pass through once. If another document
+// already holds identical content, the existing blob is reused and the
+// backend is never written to a second time.
+func (s *DocumentService) Upload(fileName string, r io.Reader, contentType, formID, submissionID, uploadedBy string) (*models.Document, error) {
 	if contentType == "" {
 		contentType = detectContentType(fileName)
 	}
 
-	blobKey := fmt.Sprintf("%s_%s", uuid.New().String(), fileName)
+	tmp, err := os.CreateTemp("", "oxidms-upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("stage upload: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
 
-	if err := s.docs.PutBlob(blobKey, data, contentType); err != nil {
-		return nil, fmt.Errorf("upload blob: %w", err)
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return nil, fmt.Errorf("stage upload: %w", err)
+	}
+	if size == 0 {
+		return nil, errors.New("file data is empty")
+	}
+	blobKey := hex.EncodeToString(hash.Sum(nil))
+
+	refs, err := s.blobRefs.Acquire(blobKey)
+	if err != nil {
+		return nil, fmt.Errorf("track blob reference: %w", err)
+	}
+	if refs == 1 {
+		// First upload of these bytes: actually store them.
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("stage upload: %w", err)
+		}
+		if err := s.docs.PutBlob(blobKey, tmp, contentType); err != nil {
+			_, _ = s.blobRefs.Release(blobKey)
+			return nil, fmt.Errorf("upload blob: %w", err)
+		}
 	}
 
 	doc := &models.Document{
 		FileName:     fileName,
 		ContentType:  contentType,
-		Size:         int64(len(data)),
+		Size:         size,
 		BlobKey:      blobKey,
 		FormID:       formID,
 		SubmissionID: submissionID,
@@ -48,13 +84,19 @@ func (s *DocumentService) Upload(fileName string, data []byte, contentType, form
 
 	id, err := s.docs.Create(doc)
 	if err != nil {
+		_, _ = s.blobRefs.Release(blobKey)
 		return nil, err
 	}
 	doc.ID = id
+	s.index.IndexDocument(doc)
+	s.metrics.IncDocuments()
 	return doc, nil
 }
 
-func (s *DocumentService) Download(id string) ([]byte, *models.Document, error) {
+// Download returns the document's content as a reader the caller must
+// close, alongside its metadata. If the backend's reader also implements
+// io.Seeker, callers can serve Range requests from it directly.
+func (s *DocumentService) Download(id string) (io.ReadCloser, *models.Document, error) {
 	doc, err := s.docs.FindByID(id)
 	if err != nil {
 		return nil, nil, err
@@ -63,11 +105,15 @@ func (s *DocumentService) Download(id string) ([]byte, *models.Document, error)
 		return nil, nil, errors.New("document not found")
 	}
 
-	data, _, err := s.docs.GetBlob(doc.BlobKey)
+	body, err := s.docs.GetBlob(doc.BlobKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("download blob: %w", err)
 	}
-	return data, doc, nil
+	return body, doc, nil
+}
+
+func (s *DocumentService) Get(id string) (*models.Document, error) {
+	return s.docs.FindByID(id)
 }
 
 func (s *DocumentService) List(skip, limit int) ([]models.Document, int, error) {
@@ -78,6 +124,9 @@ func (s *DocumentService) ListBySubmission(submissionID string) ([]models.Docume
 	return s.docs.FindBySubmission(submissionID)
 }
 
+// Delete removes the Document row and releases its blob reference,
+// physically deleting the blob only once the last Document pointing at it
+// is gone.
 func (s *DocumentService) Delete(id string) error {
 	doc, err := s.docs.FindByID(id)
 	if err != nil {
@@ -86,8 +135,19 @@ func (s *DocumentService) Delete(id string) error {
 	if doc == nil {
 		return errors.New("document not found")
 	}
-	s.docs.DeleteBlob(doc.BlobKey)
-	return s.docs.Delete(id)
+	if err := s.docs.Delete(id); err != nil {
+		return err
+	}
+	s.index.DeleteDocument(id)
+	s.metrics.DecDocuments()
+	refs, err := s.blobRefs.Release(doc.BlobKey)
+	if err != nil {
+		return fmt.Errorf("release blob reference: %w", err)
+	}
+	if refs == 0 {
+		return s.docs.DeleteBlob(doc.BlobKey)
+	}
+	return nil
 }
 
 func (s *DocumentService) Count() (int, error) {
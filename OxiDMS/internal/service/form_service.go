@@ -7,20 +7,33 @@ import (
 	"time"
 
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
+	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
 type FormService struct {
-	forms *repository.FormRepo
-	pool  *db.Pool
+	forms   *repository.FormRepo
+	acls    *repository.FormACLRepo
+	pool    *db.Pool
+	index   *search.Indexer
+	metrics *metrics.Registry
 }
 
-func NewFormService(forms *repository.FormRepo, pool *db.Pool) *FormService {
-	return &FormService{forms: forms, pool: pool}
+func NewFormService(forms *repository.FormRepo, acls *repository.FormACLRepo, pool *db.Pool, index *search.Indexer, reg *metrics.Registry) *FormService {
+	return &FormService{forms: forms, acls: acls, pool: pool, index: index, metrics: reg}
 }
 
-func (s *FormService) Create(name, description, createdBy string, fields []map[string]any) (*models.Form, error) {
+// creatorPermissions are the permissions auto-granted to a form's creator
+// via FormACL, since the user role's defaults only cover form:create,
+// form:read, and document:* (see authz.rolePermissions) — update, delete,
+// and submission access on a specific form otherwise only come from an
+// explicit grant.
+var creatorPermissions = []string{"form:read", "form:update", "form:delete", "submission:*", "document:*"}
+
+func (s *FormService) Create(name, description, createdBy string, fields []map[string]any, public bool) (*models.Form, error) {
 	if name == "" {
 		return nil, errors.New("form name is required")
 	}
@@ -45,6 +58,7 @@ func (s *FormService) Create(name, description, createdBy string, fields []map[s
 		CreatedBy:   createdBy,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Public:      public,
 	}
 
 	id, err := s.forms.Create(form)
@@ -53,12 +67,26 @@ func (s *FormService) Create(name, description, createdBy string, fields []map[s
 	}
 	form.ID = id
 
+	if _, err := s.acls.Grant(id, createdBy, creatorPermissions); err != nil {
+		return nil, err
+	}
+
+	s.index.IndexForm(form)
+	s.metrics.IncForms()
+
 	// Create indexes for indexed fields
-	c := s.pool.Get()
-	for _, f := range form.TypedFields() {
-		if f.Indexed {
-			c.CreateIndex(repository.SubmissionsCollection, "data."+f.Name)
+	err = s.pool.Do(func(c *oxidb.Client) error {
+		for _, f := range form.TypedFields() {
+			if f.Indexed {
+				if err := c.CreateIndex(repository.SubmissionsCollection, "data."+f.Name); err != nil {
+					return err
+				}
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return form, nil
@@ -79,7 +107,7 @@ func (s *FormService) Get(id string) (*models.Form, error) {
 	return form, nil
 }
 
-func (s *FormService) Update(id, name, description string, fields []map[string]any) (*models.Form, error) {
+func (s *FormService) Update(id, name, description string, fields []map[string]any, public bool) (*models.Form, error) {
 	form, err := s.forms.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -95,11 +123,13 @@ func (s *FormService) Update(id, name, description string, fields []map[string]a
 	if len(fields) > 0 {
 		form.Fields = fields
 	}
+	form.Public = public
 	form.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 
 	if err := s.forms.Update(id, form); err != nil {
 		return nil, err
 	}
+	s.index.IndexForm(form)
 	return form, nil
 }
 
@@ -111,7 +141,12 @@ func (s *FormService) Delete(id string) error {
 	if form == nil {
 		return errors.New("form not found")
 	}
-	return s.forms.Delete(id)
+	if err := s.forms.Delete(id); err != nil {
+		return err
+	}
+	s.index.DeleteForm(id)
+	s.metrics.DecForms()
+	return nil
 }
 
 var nonAlphaNum = regexp.MustCompile(`[^a-z0-9]+`)
@@ -2,52 +2,189 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
 	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
 type SearchService struct {
-	pool *db.Pool
-	subs *repository.SubmissionRepo
+	pool    *db.Pool
+	subs    *repository.SubmissionRepo
+	index   *search.Indexer
+	metrics *metrics.Registry
 }
 
-func NewSearchService(pool *db.Pool, subs *repository.SubmissionRepo) *SearchService {
-	return &SearchService{pool: pool, subs: subs}
+func NewSearchService(pool *db.Pool, subs *repository.SubmissionRepo, index *search.Indexer, reg *metrics.Registry) *SearchService {
+	return &SearchService{pool: pool, subs: subs, index: index, metrics: reg}
 }
 
 type SearchRequest struct {
-	FormID    string                       `json:"formId"`
-	Filters   map[string]FilterDescriptor  `json:"filters,omitempty"`
-	TextQuery string                       `json:"textQuery,omitempty"`
-	Skip      int                          `json:"skip"`
-	Limit     int                          `json:"limit"`
+	FormID    string                      `json:"formId"`
+	Filters   map[string]FilterDescriptor `json:"filters,omitempty"`
+	TextQuery string                      `json:"textQuery,omitempty"`
+	// Query is a structured query-DSL alternative to TextQuery, for callers
+	// building a query programmatically (e.g. a UI filter builder) instead
+	// of assembling a query string. When set, it takes priority over
+	// TextQuery and Filters.
+	Query *ClauseSpec `json:"query,omitempty"`
+	Skip  int         `json:"skip"`
+	Limit int         `json:"limit"`
+
+	// Highlight, when set, asks the FTS/Query path to return matched-term
+	// fragments alongside each doc.
+	Highlight *search.Highlight `json:"highlight,omitempty"`
+	// Aggregations, when set, asks the FTS/Query path to compute facet
+	// buckets over the full set of matches.
+	Aggregations []search.Aggregation `json:"aggregations,omitempty"`
+}
+
+// ClauseSpec is the JSON shape of a search.Clause: exactly one field should
+// be set, mirroring how search.Clause's implementations are a closed set of
+// structs. Bool's Must/Should/MustNot/Filter nest further ClauseSpecs, the
+// same way search.BoolClause nests further search.Clause values.
+type ClauseSpec struct {
+	Match      *MatchSpec      `json:"match,omitempty"`
+	MultiMatch *MultiMatchSpec `json:"multiMatch,omitempty"`
+	Phrase     *PhraseSpec     `json:"phrase,omitempty"`
+	Prefix     *PrefixSpec     `json:"prefix,omitempty"`
+	Bool       *BoolSpec       `json:"bool,omitempty"`
+}
+
+type MatchSpec struct {
+	Field string `json:"field,omitempty"`
+	Text  string `json:"text"`
+}
+
+type MultiMatchSpec struct {
+	Fields []string `json:"fields"`
+	Text   string   `json:"text"`
+}
+
+type PhraseSpec struct {
+	Field string `json:"field,omitempty"`
+	Text  string `json:"text"`
+}
+
+type PrefixSpec struct {
+	Field string `json:"field,omitempty"`
+	Text  string `json:"text"`
+}
+
+type BoolSpec struct {
+	Must    []ClauseSpec `json:"must,omitempty"`
+	Should  []ClauseSpec `json:"should,omitempty"`
+	MustNot []ClauseSpec `json:"mustNot,omitempty"`
+	Filter  []ClauseSpec `json:"filter,omitempty"`
+}
+
+// toClause converts a ClauseSpec to the search.Clause it describes.
+func (s ClauseSpec) toClause() (search.Clause, error) {
+	switch {
+	case s.Match != nil:
+		return search.MatchClause{Field: s.Match.Field, Text: s.Match.Text}, nil
+	case s.MultiMatch != nil:
+		return search.MultiMatchClause{Fields: s.MultiMatch.Fields, Text: s.MultiMatch.Text}, nil
+	case s.Phrase != nil:
+		return search.PhraseClause{Field: s.Phrase.Field, Text: s.Phrase.Text}, nil
+	case s.Prefix != nil:
+		return search.PrefixClause{Field: s.Prefix.Field, Text: s.Prefix.Text}, nil
+	case s.Bool != nil:
+		must, err := toClauses(s.Bool.Must)
+		if err != nil {
+			return nil, err
+		}
+		should, err := toClauses(s.Bool.Should)
+		if err != nil {
+			return nil, err
+		}
+		mustNot, err := toClauses(s.Bool.MustNot)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := toClauses(s.Bool.Filter)
+		if err != nil {
+			return nil, err
+		}
+		return search.BoolClause{Must: must, Should: should, MustNot: mustNot, Filter: filter}, nil
+	default:
+		return nil, errors.New("search: query clause has no match/multiMatch/phrase/prefix/bool set")
+	}
+}
+
+func toClauses(specs []ClauseSpec) ([]search.Clause, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	clauses := make([]search.Clause, len(specs))
+	for i, spec := range specs {
+		clause, err := spec.toClause()
+		if err != nil {
+			return nil, err
+		}
+		clauses[i] = clause
+	}
+	return clauses, nil
 }
 
 type FilterDescriptor struct {
-	Value any     `json:"value,omitempty"`
-	Min   any     `json:"min,omitempty"`
-	Max   any     `json:"max,omitempty"`
-	Op    string  `json:"op,omitempty"` // eq, ne, gt, gte, lt, lte, in
+	Value any    `json:"value,omitempty"`
+	Min   any    `json:"min,omitempty"`
+	Max   any    `json:"max,omitempty"`
+	Op    string `json:"op,omitempty"` // eq, ne, gt, gte, lt, lte, in
+	// Type, when set to "date", tells buildQuery to parse Min/Max as
+	// dates (RFC3339 string or epoch millis) and filter with oxidb.DateTime
+	// instead of comparing them as plain strings or numbers.
+	Type string `json:"type,omitempty"`
 }
 
 type SearchResult struct {
-	Docs  []map[string]any `json:"docs"`
-	Total int              `json:"total"`
-	Mode  string           `json:"mode"`
+	Docs         []map[string]any    `json:"docs"`
+	Total        int                 `json:"total"`
+	Mode         string              `json:"mode"`
+	Highlights   map[string][]string `json:"highlights,omitempty"`
+	Aggregations []search.AggResult  `json:"aggregations,omitempty"`
 }
 
 func (s *SearchService) Search(req SearchRequest) (*SearchResult, error) {
-	c := s.pool.Get()
+	s.metrics.IncSearchRequests()
 
 	if req.Limit == 0 {
 		req.Limit = 20
 	}
 
+	// Mode 0: Structured query-DSL — bypasses Filters/TextQuery entirely.
+	if req.Query != nil {
+		clause, err := req.Query.toClause()
+		if err != nil {
+			return nil, err
+		}
+		outcome, err := s.index.SearchWithOptions(search.SearchOptions{
+			Clause:       clause,
+			FormID:       req.FormID,
+			Limit:        req.Limit,
+			Highlight:    req.Highlight,
+			Aggregations: req.Aggregations,
+		})
+		if err != nil {
+			return nil, err
+		}
+		docs, err := s.hitsToDocs(outcome.Hits)
+		if err != nil {
+			return nil, err
+		}
+		return &SearchResult{Docs: docs, Total: outcome.Total, Mode: "query", Highlights: outcome.Highlights, Aggregations: outcome.Aggregations}, nil
+	}
+
 	hasFilters := len(req.Filters) > 0
 	hasText := req.TextQuery != ""
 
@@ -61,17 +198,23 @@ func (s *SearchService) Search(req SearchRequest) (*SearchResult, error) {
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			c1 := s.pool.Get()
-			docs, findErr = c1.Find(repository.SubmissionsCollection, query, &oxidb.FindOptions{
-				Skip:  &req.Skip,
-				Limit: &req.Limit,
-				Sort:  map[string]any{"createdAt": -1},
+			findErr = s.pool.Do(func(c *oxidb.Client) error {
+				var err error
+				docs, err = c.Find(repository.SubmissionsCollection, query, &oxidb.FindOptions{
+					Skip:  &req.Skip,
+					Limit: &req.Limit,
+					Sort:  map[string]any{"createdAt": -1},
+				})
+				return err
 			})
 		}()
 		go func() {
 			defer wg.Done()
-			c2 := s.pool.Get()
-			total, countErr = c2.Count(repository.SubmissionsCollection, query)
+			countErr = s.pool.Do(func(c *oxidb.Client) error {
+				var err error
+				total, err = c.Count(repository.SubmissionsCollection, query)
+				return err
+			})
 		}()
 		wg.Wait()
 		if findErr != nil {
@@ -83,67 +226,105 @@ func (s *SearchService) Search(req SearchRequest) (*SearchResult, error) {
 		return &SearchResult{Docs: docs, Total: total, Mode: "structured"}, nil
 	}
 
-	// Mode 2: FTS only — use TextSearch directly on submissions collection
+	// Mode 2: FTS only — rank with the inverted index's BM25 scorer
 	if !hasFilters && hasText {
-		subs, err := s.subs.TextSearch(req.TextQuery, req.Skip+req.Limit)
-		if err != nil {
-			return nil, err
+		if req.Highlight != nil || len(req.Aggregations) > 0 {
+			outcome, err := s.index.SearchTextWithOptions(req.TextQuery, req.FormID, req.Skip, req.Limit, req.Highlight, req.Aggregations)
+			if err != nil {
+				return nil, err
+			}
+			docs, err := s.hitsToDocs(outcome.Hits)
+			if err != nil {
+				return nil, err
+			}
+			return &SearchResult{Docs: docs, Total: outcome.Total, Mode: "fts", Highlights: outcome.Highlights, Aggregations: outcome.Aggregations}, nil
 		}
 
-		total := len(subs)
-		// Apply pagination
-		end := req.Skip + req.Limit
-		if end > total {
-			end = total
+		hits, total, err := s.index.Search(req.TextQuery, req.FormID, req.Skip, req.Limit)
+		if err != nil {
+			return nil, err
 		}
-		docs := make([]map[string]any, 0)
-		if req.Skip < total {
-			for _, sub := range subs[req.Skip:end] {
-				doc := submissionToMap(sub)
-				docs = append(docs, doc)
-			}
+		docs, err := s.hitsToDocs(hits)
+		if err != nil {
+			return nil, err
 		}
 		return &SearchResult{Docs: docs, Total: total, Mode: "fts"}, nil
 	}
 
-	// Mode 3: Combined — TextSearch + structured filter intersection
+	// Mode 3: Combined — FTS ranking to get the candidate ID set, then a
+	// single Find (with a matching Count) over `{"_id": {"$in": ids}, ...}`
+	// instead of one FindOne per hit, so Total reflects the real match
+	// count and the structured filter's index can be used by the query
+	// planner. Large hit sets are chunked into $in batches to keep each
+	// request's payload bounded.
 	if hasFilters && hasText {
-		subs, err := s.subs.TextSearch(req.TextQuery, 500)
+		allHits, _, err := s.index.Search(req.TextQuery, req.FormID, 0, 0)
 		if err != nil {
 			return nil, err
 		}
+		if len(allHits) == 0 {
+			return &SearchResult{Docs: []map[string]any{}, Total: 0, Mode: "combined"}, nil
+		}
+
+		scores := make(map[string]float64, len(allHits))
+		ids := make([]any, len(allHits))
+		for i, hit := range allHits {
+			ids[i] = toNumericID(hit.SubmissionID)
+			scores[hit.SubmissionID] = hit.Score
+		}
 
 		structuredQuery := buildQuery(req.FormID, req.Filters)
-		docs := make([]map[string]any, 0)
-		for _, sub := range subs {
-			// Check if this submission matches the structured filters
-			combined := map[string]any{
-				"$and": []any{
-					map[string]any{"_id": toNumericID(sub.ID)},
-					structuredQuery,
-				},
-			}
-			match, err := c.FindOne(repository.SubmissionsCollection, combined)
-			if err != nil || match == nil {
-				continue
-			}
-			docs = append(docs, match)
-			if len(docs) >= req.Skip+req.Limit {
-				break
+		matched := make([]map[string]any, 0, len(ids))
+		total := 0
+		err = s.pool.Do(func(c *oxidb.Client) error {
+			for _, batch := range chunkIDs(ids, combinedSearchBatchSize) {
+				combined := map[string]any{
+					"$and": []any{
+						map[string]any{"_id": map[string]any{"$in": batch}},
+						structuredQuery,
+					},
+				}
+				count, err := c.Count(repository.SubmissionsCollection, combined)
+				if err != nil {
+					return err
+				}
+				total += count
+
+				docs, err := c.Find(repository.SubmissionsCollection, combined, nil)
+				if err != nil {
+					return err
+				}
+				matched = append(matched, docs...)
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Re-sort by BM25 score (the $in batches don't preserve hit order)
+		// and re-apply Skip/Limit over the full matched set.
+		sort.Slice(matched, func(i, j int) bool {
+			return scores[docID(matched[i])] > scores[docID(matched[j])]
+		})
+		for _, doc := range matched {
+			doc["_score"] = scores[docID(doc)]
 		}
 
-		paged := docs
-		if req.Skip < len(docs) {
-			end := req.Skip + req.Limit
-			if end > len(docs) {
-				end = len(docs)
+		end := req.Skip + req.Limit
+		if end > total {
+			end = total
+		}
+		paged := matched
+		if req.Skip < len(matched) {
+			if end > len(matched) {
+				end = len(matched)
 			}
-			paged = docs[req.Skip:end]
+			paged = matched[req.Skip:end]
 		} else {
 			paged = nil
 		}
-		return &SearchResult{Docs: paged, Total: len(docs), Mode: "combined"}, nil
+		return &SearchResult{Docs: paged, Total: total, Mode: "combined"}, nil
 	}
 
 	// No filters, no text — return all for form (parallel Find + Count)
@@ -158,17 +339,23 @@ func (s *SearchService) Search(req SearchRequest) (*SearchResult, error) {
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		c1 := s.pool.Get()
-		docs, findErr = c1.Find(repository.SubmissionsCollection, query, &oxidb.FindOptions{
-			Skip:  &req.Skip,
-			Limit: &req.Limit,
-			Sort:  map[string]any{"createdAt": -1},
+		findErr = s.pool.Do(func(c *oxidb.Client) error {
+			var err error
+			docs, err = c.Find(repository.SubmissionsCollection, query, &oxidb.FindOptions{
+				Skip:  &req.Skip,
+				Limit: &req.Limit,
+				Sort:  map[string]any{"createdAt": -1},
+			})
+			return err
 		})
 	}()
 	go func() {
 		defer wg.Done()
-		c2 := s.pool.Get()
-		total, countErr = c2.Count(repository.SubmissionsCollection, query)
+		countErr = s.pool.Do(func(c *oxidb.Client) error {
+			var err error
+			total, err = c.Count(repository.SubmissionsCollection, query)
+			return err
+		})
 	}()
 	wg.Wait()
 	if findErr != nil {
@@ -180,6 +367,16 @@ func (s *SearchService) Search(req SearchRequest) (*SearchResult, error) {
 	return &SearchResult{Docs: docs, Total: total, Mode: "all"}, nil
 }
 
+// Suggest returns up to limit typeahead completions for prefix, sourced
+// from form names, document titles, and recent submission field values;
+// see search.Indexer.Suggest.
+func (s *SearchService) Suggest(prefix string, limit int) []string {
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.index.Suggest(prefix, limit)
+}
+
 func buildQuery(formID string, filters map[string]FilterDescriptor) map[string]any {
 	conditions := []any{}
 
@@ -192,11 +389,20 @@ func buildQuery(formID string, filters map[string]FilterDescriptor) map[string]a
 
 		// Range filter
 		if filter.Min != nil || filter.Max != nil {
+			min, max := filter.Min, filter.Max
+			if filter.Type == "date" {
+				if t, ok := parseFilterDate(filter.Min); ok {
+					min = oxidb.Date(t)
+				}
+				if t, ok := parseFilterDate(filter.Max); ok {
+					max = oxidb.Date(t)
+				}
+			}
 			if filter.Min != nil && filter.Min != "" {
-				conditions = append(conditions, map[string]any{dataField: map[string]any{"$gte": filter.Min}})
+				conditions = append(conditions, map[string]any{dataField: map[string]any{"$gte": min}})
 			}
 			if filter.Max != nil && filter.Max != "" {
-				conditions = append(conditions, map[string]any{dataField: map[string]any{"$lte": filter.Max}})
+				conditions = append(conditions, map[string]any{dataField: map[string]any{"$lte": max}})
 			}
 			continue
 		}
@@ -216,6 +422,25 @@ func buildQuery(formID string, filters map[string]FilterDescriptor) map[string]a
 	return map[string]any{"$and": conditions}
 }
 
+// hitsToDocs fetches each hit's submission and annotates it with its BM25
+// score, preserving the hits' rank order.
+func (s *SearchService) hitsToDocs(hits []search.Hit) ([]map[string]any, error) {
+	docs := make([]map[string]any, 0, len(hits))
+	for _, hit := range hits {
+		sub, err := s.subs.FindByID(hit.SubmissionID)
+		if err != nil {
+			return nil, err
+		}
+		if sub == nil {
+			continue
+		}
+		doc := submissionToMap(*sub)
+		doc["_score"] = hit.Score
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
 func submissionToMap(s models.Submission) map[string]any {
 	data, _ := json.Marshal(s)
 	var m map[string]any
@@ -223,9 +448,64 @@ func submissionToMap(s models.Submission) map[string]any {
 	return m
 }
 
+// parseFilterDate converts a FilterDescriptor's Min/Max value — either an
+// RFC3339 string or epoch milliseconds, as sent by different frontend
+// widgets — to a time.Time, once at the service boundary so the rest of
+// the query-building path only ever deals with oxidb.DateTime.
+func parseFilterDate(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339, val)
+		return t, err == nil
+	case float64:
+		return time.UnixMilli(int64(val)).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
 func toNumericID(id string) any {
 	if n, err := strconv.ParseFloat(id, 64); err == nil {
 		return n
 	}
 	return id
 }
+
+// combinedSearchBatchSize bounds how many FTS hit IDs go into a single
+// $in query, so a large result set doesn't build one unbounded payload.
+const combinedSearchBatchSize = 500
+
+// chunkIDs splits ids into batches of at most size elements.
+func chunkIDs(ids []any, size int) [][]any {
+	batches := make([][]any, 0, (len(ids)+size-1)/size)
+	for len(ids) > 0 {
+		n := size
+		if n > len(ids) {
+			n = len(ids)
+		}
+		batches = append(batches, ids[:n])
+		ids = ids[n:]
+	}
+	return batches
+}
+
+// docID extracts the string submission ID a Find result was built from,
+// matching the format search.Hit.SubmissionID uses. The combined-search
+// path above reads _id straight off a raw c.Find result rather than
+// through repository.docToSubmission, so _id still comes back as the
+// float64/int OxiDB itself uses, not the string normalizeID would have
+// converted it to; this mirrors normalizeID's own float64/int formatting
+// so the lookup into scores (keyed by search.Hit.SubmissionID) actually
+// hits instead of silently scoring everything 0.
+func docID(doc map[string]any) string {
+	switch v := doc["_id"].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case int:
+		return fmt.Sprintf("%d", v)
+	default:
+		return ""
+	}
+}
@@ -2,19 +2,25 @@ package service
 
 import (
 	"errors"
+	"log"
 	"time"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/models"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
+	"github.com/parisxmas/OxiDB/go/oxidb"
 )
 
 type SubmissionService struct {
-	subs  *repository.SubmissionRepo
-	forms *repository.FormRepo
+	subs    *repository.SubmissionRepo
+	forms   *repository.FormRepo
+	index   *search.Indexer
+	metrics *metrics.Registry
 }
 
-func NewSubmissionService(subs *repository.SubmissionRepo, forms *repository.FormRepo) *SubmissionService {
-	return &SubmissionService{subs: subs, forms: forms}
+func NewSubmissionService(subs *repository.SubmissionRepo, forms *repository.FormRepo, index *search.Indexer, reg *metrics.Registry) *SubmissionService {
+	return &SubmissionService{subs: subs, forms: forms, index: index, metrics: reg}
 }
 
 func (s *SubmissionService) Create(formID string, data map[string]any, fileIDs []string, createdBy string) (*models.Submission, error) {
@@ -26,15 +32,22 @@ func (s *SubmissionService) Create(formID string, data map[string]any, fileIDs [
 		return nil, errors.New("form not found")
 	}
 
-	// Validate required fields
+	// Validate required fields, collecting every missing one into a single
+	// oxidb.Error so the HTTP layer can render all of them as 4xx JSON in
+	// one response instead of the caller fixing and resubmitting field by
+	// field.
+	var details []oxidb.Detail
 	for _, f := range form.TypedFields() {
 		if f.Required && f.Type != "file" {
 			val, exists := data[f.Name]
 			if !exists || val == nil || val == "" {
-				return nil, errors.New("required field missing: " + f.Label)
+				details = append(details, oxidb.Detail{Field: f.Name, Reason: "required field missing: " + f.Label})
 			}
 		}
 	}
+	if len(details) > 0 {
+		return nil, &oxidb.Error{Code: oxidb.CodeValidation, Message: "validation failed", Details: details}
+	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	sub := &models.Submission{
@@ -51,6 +64,14 @@ func (s *SubmissionService) Create(formID string, data map[string]any, fileIDs [
 		return nil, err
 	}
 	sub.ID = id
+	s.metrics.IncSubmissions(formID)
+
+	// Indexing is best-effort and asynchronous in effect: a failure here is
+	// logged, not returned, since the op log lets a later Reindex (or
+	// ReplayPending at startup) catch this submission up.
+	if err := s.index.IndexSubmission(form, sub); err != nil {
+		log.Printf("search: index submission %s: %v", sub.ID, err)
+	}
 	return sub, nil
 }
 
@@ -84,6 +105,37 @@ func (s *SubmissionService) Update(id string, data map[string]any) (*models.Subm
 	if err := s.subs.Update(id, sub); err != nil {
 		return nil, err
 	}
+
+	if form, err := s.forms.FindByID(sub.FormID); err == nil && form != nil {
+		if err := s.index.IndexSubmission(form, sub); err != nil {
+			log.Printf("search: index submission %s: %v", sub.ID, err)
+		}
+	}
+	return sub, nil
+}
+
+// UpdateField sets a single path under a submission's Data (e.g.
+// "items.$[elem].discount") without replacing the whole Data blob,
+// applying filters as ArrayFilters so a positional operator in path only
+// touches the array elements they match.
+func (s *SubmissionService) UpdateField(id, path string, value any, filters []map[string]any) (*models.Submission, error) {
+	if err := s.subs.UpdateDataField(id, path, value, filters); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.subs.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, errors.New("submission not found")
+	}
+
+	if form, err := s.forms.FindByID(sub.FormID); err == nil && form != nil {
+		if err := s.index.IndexSubmission(form, sub); err != nil {
+			log.Printf("search: index submission %s: %v", sub.ID, err)
+		}
+	}
 	return sub, nil
 }
 
@@ -95,7 +147,14 @@ func (s *SubmissionService) Delete(id string) error {
 	if sub == nil {
 		return errors.New("submission not found")
 	}
-	return s.subs.Delete(id)
+	if err := s.subs.Delete(id); err != nil {
+		return err
+	}
+	if err := s.index.DeleteSubmission(sub); err != nil {
+		log.Printf("search: unindex submission %s: %v", sub.ID, err)
+	}
+	s.metrics.DecSubmissions(sub.FormID)
+	return nil
 }
 
 func (s *SubmissionService) CountByForm(formID string) (int, error) {
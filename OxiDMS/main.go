@@ -1,79 +1,196 @@
 package main
 
 import (
+	"context"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/audit"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/auth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/authz"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/blobstore"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/config"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/db"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/gelf"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/handler"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/logging"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/metrics"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/oauth"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/operations"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/repository"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/router"
+	"github.com/parisxmas/OxiDB/OxiDMS/internal/search"
 	"github.com/parisxmas/OxiDB/OxiDMS/internal/service"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+	watcher := config.NewWatcher(cfg)
+
+	// Role/permission policy: built-in defaults unless DMS_POLICY_FILE
+	// points at a JSON file of "role:resource:action" rules.
+	if err := authz.LoadPolicyFile(cfg.PolicyFile); err != nil {
+		log.Fatalf("Failed to load policy file: %v", err)
+	}
+
+	// GELF UDP logging: log.Printf output (used throughout the rest of the
+	// app) keeps going to the flat gelf.Writer below, while appLogger is
+	// the structured log/slog path (see internal/logging) that the router's
+	// request logging, auth.Middleware's failure logs, FormHandler's
+	// create/update/delete, and this file's background init goroutine use,
+	// so those carry request IDs, user IDs, and other GELF custom fields
+	// instead of flat strings. gelfLogger is a lighter-weight alternative
+	// for the plain log.Printf call sites that don't go through slog: it
+	// rides the same gelf.Writer log.SetOutput points at, but adds "_key"
+	// GELF fields (request ID, user email — see gelf.WithFields,
+	// auth.Middleware, and middleware.Logger) instead of flattening
+	// everything into short_message text.
+	// logLevel backs appLogger's minimum level with a *slog.LevelVar rather
+	// than a plain slog.Level, so the watcher goroutine below can move it
+	// on a SIGHUP reload without rebuilding the handler underneath it.
+	logLevel := &slog.LevelVar{}
+	logLevel.Set(slogLevel(cfg.Logging.Level))
 
-	// GELF UDP logging
-	if cfg.GelfAddr != "" {
-		gelfWriter, err := gelf.New(cfg.GelfAddr)
+	var appLogger *slog.Logger
+	var gelfLogger *gelf.Logger
+	if cfg.Logging.GelfAddr != "" {
+		gelfOpts := []gelf.Option{WithGelfCompression(cfg.Logging.Compression)}
+		if cfg.Logging.ChunkSize > 0 {
+			gelfOpts = append(gelfOpts, gelf.WithChunkSize(cfg.Logging.ChunkSize))
+		}
+		gelfWriter, err := gelf.New(cfg.Logging.GelfAddr, gelfOpts...)
 		if err != nil {
 			log.Printf("Warning: GELF init failed: %v", err)
 		} else {
 			log.SetOutput(io.MultiWriter(os.Stderr, gelfWriter))
-			log.Printf("GELF logging: enabled (%s)", cfg.GelfAddr)
+			log.Printf("GELF logging: enabled (%s)", cfg.Logging.GelfAddr)
+			gelfLogger = gelf.NewLogger(gelfWriter)
+		}
+		gelfHandler, err := logging.NewHandler(cfg.Logging.GelfAddr, &slog.HandlerOptions{Level: logLevel})
+		if err != nil {
+			log.Printf("Warning: structured GELF logging init failed: %v", err)
+		} else {
+			appLogger = slog.New(gelfHandler)
 		}
 	}
+	if appLogger == nil {
+		appLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+
+	// Pick up log-level changes pushed by config.Watcher on SIGHUP without
+	// restarting the process. GelfAddr/Compression/ChunkSize are part of
+	// the same reloaded LoggingConfig but nothing redials the GELF writer
+	// on them yet, so those three still require a restart.
+	go func() {
+		for reloaded := range watcher.Changes {
+			logLevel.Set(slogLevel(reloaded.Level))
+			log.Printf("config: log level reloaded (level=%s)", logLevel.Level())
+		}
+	}()
 
 	// Connect to OxiDB
-	pool, err := db.NewPool(cfg.OxiDBHost, cfg.OxiDBPort, cfg.PoolSize)
+	pool, err := db.NewPool(cfg.OxiDBHost, cfg.OxiDBPort, cfg.PoolSize, cfg.HandoffDir, nil)
 	if err != nil {
 		log.Fatalf("Failed to connect to OxiDB: %v", err)
 	}
 	defer pool.Close()
 	log.Printf("Connected to OxiDB at %s:%d (pool size: %d)", cfg.OxiDBHost, cfg.OxiDBPort, cfg.PoolSize)
 
+	// Blob storage for DocumentService (OxiDB bucket by default; DMS_BLOB_BACKEND
+	// switches to a local filesystem or S3-compatible driver).
+	blobBackend, err := blobstore.New(blobstore.Config{
+		Backend:     cfg.BlobBackend,
+		OxiDBBucket: blobstore.DefaultOxiDBBucket,
+		FSDir:       cfg.BlobFSDir,
+		S3: blobstore.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		},
+	}, pool)
+	if err != nil {
+		log.Fatalf("Failed to configure blob backend: %v", err)
+	}
+
 	// Repositories
 	userRepo := repository.NewUserRepo(pool)
 	formRepo := repository.NewFormRepo(pool)
 	subRepo := repository.NewSubmissionRepo(pool)
-	docRepo := repository.NewDocumentRepo(pool)
+	docRepo := repository.NewDocumentRepo(pool, blobBackend)
+	blobRefRepo := repository.NewBlobRefRepo(pool)
+	clientRepo := repository.NewClientRepo(pool)
+	oauthTokenRepo := repository.NewOAuthTokenRepo(pool)
+	formACLRepo := repository.NewFormACLRepo(pool)
+	apiTokenRepo := repository.NewAPITokenRepo(pool)
+	auditRepo := repository.NewAuditRepo(pool)
+	opRepo := repository.NewOperationRepo(pool)
+	sessionRepo := repository.NewSessionRepo(pool)
+	searchIndex := search.NewIndexer(pool, formRepo, subRepo, cfg.SearchStemming)
+	opRegistry := operations.NewRegistry(opRepo)
+
+	// Metrics: one process-wide registry backing /metrics and the
+	// dashboard, populated inline by the service layer below as requests
+	// happen rather than recomputed from the database on every scrape.
+	metricsReg := metrics.NewRegistry()
+	metricsReg.WithPoolGauge(pool.HealthyCount)
 
 	// Services
-	authSvc := service.NewAuthService(userRepo, cfg.JWTSecret)
-	formSvc := service.NewFormService(formRepo, pool)
-	subSvc := service.NewSubmissionService(subRepo, formRepo)
-	docSvc := service.NewDocumentService(docRepo)
-	searchSvc := service.NewSearchService(pool, subRepo)
+	jwtKeys := auth.KeySet{Active: cfg.JWTActiveKID, Keys: cfg.JWTKeys}
+	sessionMgr := auth.NewSessionManager(sessionRepo, jwtKeys)
+	authSvc := service.NewAuthService(userRepo, sessionMgr)
+	formSvc := service.NewFormService(formRepo, formACLRepo, pool, searchIndex, metricsReg)
+	subSvc := service.NewSubmissionService(subRepo, formRepo, searchIndex, metricsReg)
+	docSvc := service.NewDocumentService(docRepo, blobRefRepo, searchIndex, metricsReg)
+	searchSvc := service.NewSearchService(pool, subRepo, searchIndex, metricsReg)
+	oauthSrv := oauth.NewServer(clientRepo, oauthTokenRepo, userRepo, cfg.OAuthIssuer)
+	apiTokens := authz.NewAPITokens(apiTokenRepo, userRepo)
+	auditRec := audit.NewRecorder(auditRepo)
 
 	// Handlers
-	authH := handler.NewAuthHandler(authSvc)
-	formH := handler.NewFormHandler(formSvc)
-	subH := handler.NewSubmissionHandler(subSvc, docSvc)
-	docH := handler.NewDocumentHandler(docSvc)
-	searchH := handler.NewSearchHandler(searchSvc)
-	dashH := handler.NewDashboardHandler(formSvc, subSvc, docSvc, formRepo)
-	adminH := handler.NewAdminHandler(subRepo)
+	authH := handler.NewAuthHandler(authSvc, apiTokens, auditRepo, auditRec)
+	formH := handler.NewFormHandler(formSvc, formACLRepo, auditRec)
+	subH := handler.NewSubmissionHandler(subSvc, docSvc, auditRec)
+	docH := handler.NewDocumentHandler(docSvc, auditRec)
+	searchH := handler.NewSearchHandler(searchSvc, gelfLogger, watcher.SuggestLimit)
+	dashH := handler.NewDashboardHandler(formSvc, subSvc, docSvc, formRepo, metricsReg, gelfLogger)
+	adminH := handler.NewAdminHandler(subRepo, clientRepo, searchIndex, auditRepo, auditRec, opRegistry, cfg.PolicyFile)
+	opH := handler.NewOperationHandler(opRegistry, opRepo)
+	oauthH := handler.NewOAuthHandler(oauthSrv)
+	metricsH := handler.NewMetricsHandler(metricsReg)
+	sitemapH := handler.NewSitemapHandler(formSvc, cfg.SiteBaseURL)
 
 	// Router
-	r := router.New(cfg.JWTSecret, authH, formH, subH, docH, searchH, dashH, adminH)
+	r := router.New(appLogger, jwtKeys, sessionMgr, authH, formH, subH, docH, searchH, dashH, adminH, opH, oauthH, metricsH, sitemapH, metricsReg, auth.ChainLookup{oauthSrv, apiTokens}, formACLRepo)
 
 	// Start HTTP server immediately, run all index creation and admin seeding
 	// in background on a DEDICATED connection so long-running index builds
-	// (16M+ docs) don't block the HTTP handler pool.
-	go func() {
-		log.Printf("Background init: starting")
-		initPool, err := db.NewPool(cfg.OxiDBHost, cfg.OxiDBPort, 1)
+	// (16M+ docs) don't block the HTTP handler pool. Wrapped as an
+	// operations.Operation so GET /api/v1/operations/{id} can show progress
+	// instead of only the log.Printf lines below.
+	opRegistry.Start(operations.TypeInit, func(ctx context.Context, op *operations.Operation) error {
+		ctx = logging.WithLogger(ctx, appLogger.With("operation", "init"))
+		logger := logging.FromContext(ctx)
+
+		logger.Info("background init: starting")
+		initPool, err := db.NewPool(cfg.OxiDBHost, cfg.OxiDBPort, 1, cfg.HandoffDir, nil)
 		if err != nil {
-			log.Printf("Warning: init pool connect failed, using main pool: %v", err)
+			logger.Warn("background init: dedicated pool connect failed, using main pool", "error", err)
 			initPool = pool
 		} else {
-			log.Printf("Background init: dedicated connection ready")
+			logger.Info("background init: dedicated connection ready")
 		}
 		defer func() {
 			if initPool != pool {
@@ -83,47 +200,117 @@ func main() {
 
 		initUserRepo := repository.NewUserRepo(initPool)
 		initFormRepo := repository.NewFormRepo(initPool)
-		initDocRepo := repository.NewDocumentRepo(initPool)
+		initDocRepo := repository.NewDocumentRepo(initPool, blobBackend)
+		initBlobRefRepo := repository.NewBlobRefRepo(initPool)
 		initSubRepo := repository.NewSubmissionRepo(initPool)
-		initAuthSvc := service.NewAuthService(initUserRepo, cfg.JWTSecret)
+		initClientRepo := repository.NewClientRepo(initPool)
+		initOAuthTokenRepo := repository.NewOAuthTokenRepo(initPool)
+		initFormACLRepo := repository.NewFormACLRepo(initPool)
+		initAPITokenRepo := repository.NewAPITokenRepo(initPool)
+		initAuditRepo := repository.NewAuditRepo(initPool)
+		initOpRepo := repository.NewOperationRepo(initPool)
+		initSessionRepo := repository.NewSessionRepo(initPool)
+		initSearchIndex := search.NewIndexer(initPool, initFormRepo, initSubRepo, cfg.SearchStemming)
+		// SeedAdmin below only touches initUserRepo; sessionMgr (built off the
+		// main pool) is reused here rather than spinning up a second
+		// SessionManager reload goroutine against initPool, which gets closed
+		// once this background init finishes.
+		initAuthSvc := service.NewAuthService(initUserRepo, sessionMgr)
 
 		// Small collections first (instant)
-		log.Printf("Background init: creating user indexes...")
+		op.SetProgress(5)
+		logger.Info("background init: creating user indexes")
 		initUserRepo.EnsureIndexes()
-		log.Printf("Background init: creating form indexes...")
+		logger.Info("background init: creating form indexes")
 		initFormRepo.EnsureIndexes()
-		log.Printf("Background init: creating document indexes...")
+		op.SetProgress(15)
+		logger.Info("background init: creating document indexes")
 		initDocRepo.EnsureIndexes()
-		log.Printf("Background init: ensuring blob bucket...")
+		logger.Info("background init: ensuring blob bucket")
 		initDocRepo.EnsureBucket()
+		logger.Info("background init: creating blob ref-count index")
+		initBlobRefRepo.EnsureIndexes()
+		op.SetProgress(30)
+		logger.Info("background init: creating search index indexes")
+		initSearchIndex.EnsureIndexes()
+		logger.Info("background init: creating oauth indexes")
+		initClientRepo.EnsureIndexes()
+		initOAuthTokenRepo.EnsureIndexes()
+		op.SetProgress(40)
+		logger.Info("background init: creating authz indexes")
+		initFormACLRepo.EnsureIndexes()
+		initAPITokenRepo.EnsureIndexes()
+		logger.Info("background init: creating audit indexes")
+		initAuditRepo.EnsureIndexes()
+		logger.Info("background init: creating operations indexes")
+		initOpRepo.EnsureIndexes()
+		logger.Info("background init: creating session indexes")
+		initSessionRepo.EnsureIndexes()
+		op.SetProgress(50)
 
 		// Seed admin (needs _dms_users index)
-		log.Printf("Background init: seeding admin user...")
+		logger.Info("background init: seeding admin user")
 		if err := initAuthSvc.SeedAdmin(cfg.AdminEmail, cfg.AdminPass); err != nil {
-			log.Printf("Warning: failed to seed admin: %v", err)
+			logger.Warn("background init: failed to seed admin", "error", err)
+		}
+		logger.Info("background init: admin seeded, small indexes ready")
+		op.SetProgress(60)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		log.Printf("Background init: admin seeded, small indexes ready")
 
 		// Large collection indexes (can take minutes)
-		log.Printf("Background init: creating submission indexes (may take minutes on large datasets)...")
+		logger.Info("background init: creating submission indexes, may take minutes on large datasets")
 		start := time.Now()
 		if err := initSubRepo.EnsureIndexes(); err != nil {
-			log.Printf("Warning: submission index creation failed: %v", err)
+			logger.Warn("background init: submission index creation failed", "error", err)
 		} else {
-			log.Printf("Background init: submission indexes ready (%s)", time.Since(start).Round(time.Second))
+			logger.Info("background init: submission indexes ready", "elapsed", time.Since(start).Round(time.Second).String())
 		}
-		log.Printf("Background init: creating text index on submissions (may take minutes)...")
-		start = time.Now()
-		if err := initSubRepo.EnsureTextIndex([]string{"data"}); err != nil {
-			log.Printf("Warning: text index creation failed: %v", err)
-		} else {
-			log.Printf("Background init: text index ready (%s)", time.Since(start).Round(time.Second))
+		op.SetProgress(90)
+		logger.Info("background init: replaying pending search index ops")
+		if replayed, err := initSearchIndex.ReplayPending(); err != nil {
+			logger.Warn("background init: search index op-log replay failed", "error", err)
+		} else if replayed > 0 {
+			logger.Info("background init: replayed pending search index ops", "count", replayed)
 		}
-		log.Printf("Background init: all done")
-	}()
+		logger.Info("background init: all done")
+		return nil
+	})
 
 	log.Printf("OxiDMS server starting on %s", cfg.HTTPAddr)
 	if err := http.ListenAndServe(cfg.HTTPAddr, r); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// WithGelfCompression maps a LoggingConfig.Compression string ("gzip",
+// "zlib", or "" for none) to the gelf.Option it configures, defaulting
+// unrecognized values to no compression rather than failing startup over
+// a typo in a log-shipping knob.
+func WithGelfCompression(name string) gelf.Option {
+	switch strings.ToLower(name) {
+	case "gzip":
+		return gelf.WithCompression(gelf.Gzip)
+	case "zlib":
+		return gelf.WithCompression(gelf.Zlib)
+	default:
+		return gelf.WithCompression(gelf.NoCompression)
+	}
+}
+
+// slogLevel maps LoggingConfig.Level ("debug", "info", "warn", "error") to
+// a slog.Level, defaulting to Info for "" or anything unrecognized.
+func slogLevel(name string) slog.Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
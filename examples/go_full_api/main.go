@@ -254,7 +254,7 @@ func main() {
 	})
 	fmt.Println("  Inserted 5 articles")
 
-	must(client.CreateTextIndex("articles", []string{"title", "body"}))
+	must(client.CreateTextIndex("articles", []string{"title", "body"}, nil))
 	fmt.Println("  Created text index on [title, body]")
 
 	results, _ := client.TextSearch("articles", "Rust", 10)
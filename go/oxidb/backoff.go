@@ -0,0 +1,93 @@
+package oxidb
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// Backoff policies
+// ------------------------------------------------------------------
+//
+// These are additional BackoffPolicy implementations (see bulk.go for the
+// interface and SimpleBackoff), ported from the backoff strategies the
+// Elasticsearch Go client offers for its own retries. WithTransactionRetry
+// and Pool's retry-on-fresh-connection path both take a BackoffPolicy, so
+// any of these — or SimpleBackoff, or a caller's own — works in either
+// place.
+
+// ConstantBackoff waits the same Delay before every retry. MaxRetries caps
+// the number of retries; 0 means unlimited.
+type ConstantBackoff struct {
+	Delay      time.Duration
+	MaxRetries int
+}
+
+// Next implements BackoffPolicy.
+func (b ConstantBackoff) Next(attempt int) (time.Duration, bool) {
+	if b.MaxRetries > 0 && attempt >= b.MaxRetries {
+		return 0, false
+	}
+	return b.Delay, true
+}
+
+// ExponentialBackoff waits Initial*Multiplier^attempt before each retry,
+// capped at Max. Multiplier <= 0 defaults to 2. MaxRetries caps the number
+// of retries; 0 means unlimited.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	MaxRetries int
+}
+
+// Next implements BackoffPolicy.
+func (b ExponentialBackoff) Next(attempt int) (time.Duration, bool) {
+	if b.MaxRetries > 0 && attempt >= b.MaxRetries {
+		return 0, false
+	}
+	mult := b.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+	delay := float64(b.Initial) * math.Pow(mult, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	return time.Duration(delay), true
+}
+
+// DecorrelatedJitterBackoff is AWS's "decorrelated jitter" strategy: each
+// delay is a random duration between Base and 3x the previous delay
+// (starting from Base), capped at Cap. Unlike ConstantBackoff and
+// ExponentialBackoff, which are pure functions of attempt, this needs to
+// remember the previous delay, so Next has a pointer receiver — use
+// &DecorrelatedJitterBackoff{...}, not a bare value, wherever a
+// BackoffPolicy is expected. MaxRetries caps the number of retries; 0 means
+// unlimited.
+type DecorrelatedJitterBackoff struct {
+	Base       time.Duration
+	Cap        time.Duration
+	MaxRetries int
+
+	prev time.Duration
+}
+
+// Next implements BackoffPolicy.
+func (b *DecorrelatedJitterBackoff) Next(attempt int) (time.Duration, bool) {
+	if b.MaxRetries > 0 && attempt >= b.MaxRetries {
+		return 0, false
+	}
+	prev := b.prev
+	if prev < b.Base {
+		prev = b.Base
+	}
+	hi := prev * 3
+	delay := b.Base + time.Duration(rand.Int63n(int64(hi-b.Base)+1))
+	if b.Cap > 0 && delay > b.Cap {
+		delay = b.Cap
+	}
+	b.prev = delay
+	return delay, true
+}
@@ -0,0 +1,70 @@
+package oxidb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := oxidb.ConstantBackoff{Delay: 50 * time.Millisecond, MaxRetries: 3}
+	for i := 0; i < 3; i++ {
+		delay, ok := b.Next(i)
+		if !ok {
+			t.Fatalf("attempt %d: expected ok=true", i)
+		}
+		if delay != 50*time.Millisecond {
+			t.Fatalf("attempt %d: expected 50ms, got %v", i, delay)
+		}
+	}
+	if _, ok := b.Next(3); ok {
+		t.Fatal("expected ok=false once MaxRetries is reached")
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := oxidb.ExponentialBackoff{Initial: 10 * time.Millisecond, Max: 100 * time.Millisecond, Multiplier: 2}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond, 100 * time.Millisecond}
+	for i, w := range want {
+		delay, ok := b.Next(i)
+		if !ok {
+			t.Fatalf("attempt %d: expected ok=true", i)
+		}
+		if delay != w {
+			t.Fatalf("attempt %d: expected %v, got %v", i, w, delay)
+		}
+	}
+}
+
+func TestExponentialBackoff_MaxRetries(t *testing.T) {
+	b := oxidb.ExponentialBackoff{Initial: time.Millisecond, MaxRetries: 2}
+	if _, ok := b.Next(0); !ok {
+		t.Fatal("attempt 0 should be allowed")
+	}
+	if _, ok := b.Next(1); !ok {
+		t.Fatal("attempt 1 should be allowed")
+	}
+	if _, ok := b.Next(2); ok {
+		t.Fatal("attempt 2 should exceed MaxRetries")
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := &oxidb.DecorrelatedJitterBackoff{Base: 10 * time.Millisecond, Cap: 200 * time.Millisecond, MaxRetries: 10}
+	for i := 0; i < 10; i++ {
+		delay, ok := b.Next(i)
+		if !ok {
+			t.Fatalf("attempt %d: expected ok=true", i)
+		}
+		if delay < b.Base {
+			t.Fatalf("attempt %d: delay %v below Base %v", i, delay, b.Base)
+		}
+		if delay > b.Cap {
+			t.Fatalf("attempt %d: delay %v above Cap %v", i, delay, b.Cap)
+		}
+	}
+	if _, ok := b.Next(10); ok {
+		t.Fatal("expected ok=false once MaxRetries is reached")
+	}
+}
@@ -0,0 +1,706 @@
+package oxidb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// Bulk writes
+// ------------------------------------------------------------------
+
+// BulkOpType identifies the kind of operation a BulkOp carries.
+type BulkOpType string
+
+const (
+	BulkInsert    BulkOpType = "insert"
+	BulkUpdate    BulkOpType = "update"
+	BulkDelete    BulkOpType = "delete"
+	BulkUpsert    BulkOpType = "upsert"
+	BulkUpdateOne BulkOpType = "update_one"
+	BulkDeleteOne BulkOpType = "delete_one"
+)
+
+// BulkOp is a single operation within a Bulk call, modeled on the
+// Elasticsearch bulk API: a tagged union carrying only the fields its Type
+// needs (Insert/Upsert use Doc; Update/Delete/Upsert use Query; Update and
+// Upsert use Update). ClientID is opaque to the server and echoed back on
+// the matching BulkResult so callers can correlate partial failures with
+// the op that caused them without relying on response ordering. Collection
+// is only set by BulkRequest, whose ops can span collections; Bulk/
+// BulkWrite/BulkProcessor leave it empty and rely on the call's own
+// collection argument instead.
+type BulkOp struct {
+	Type       BulkOpType
+	Collection string
+	ClientID   string
+	Doc        map[string]any
+	Query      map[string]any
+	Update     map[string]any
+}
+
+func (op BulkOp) toWire() map[string]any {
+	wire := map[string]any{"type": string(op.Type), "client_id": op.ClientID}
+	if op.Collection != "" {
+		wire["collection"] = op.Collection
+	}
+	if op.Doc != nil {
+		wire["doc"] = op.Doc
+	}
+	if op.Query != nil {
+		wire["query"] = op.Query
+	}
+	if op.Update != nil {
+		wire["update"] = op.Update
+	}
+	return wire
+}
+
+// opSize estimates the wire size of op, used by BulkProcessor to honor
+// FlushBytes without marshaling the whole pending batch on every Add.
+func opSize(op BulkOp) int {
+	n := len(op.ClientID) + 16
+	if b, err := json.Marshal(op.Doc); err == nil {
+		n += len(b)
+	}
+	if b, err := json.Marshal(op.Query); err == nil {
+		n += len(b)
+	}
+	if b, err := json.Marshal(op.Update); err == nil {
+		n += len(b)
+	}
+	return n
+}
+
+// BulkResult is the per-operation outcome of a Bulk call, returned in the
+// same order as the request's ops so partial failures are visible without
+// aborting the rest of the batch.
+type BulkResult struct {
+	ClientID string
+	Status   string // "ok" or "error"
+	Error    string
+	ID       string
+	Modified int
+}
+
+func bulkResultFromWire(raw map[string]any) BulkResult {
+	r := BulkResult{}
+	r.ClientID, _ = raw["client_id"].(string)
+	r.Status, _ = raw["status"].(string)
+	r.Error, _ = raw["error"].(string)
+	r.ID, _ = raw["id"].(string)
+	if m, ok := raw["modified"].(float64); ok {
+		r.Modified = int(m)
+	}
+	return r
+}
+
+// Bulk sends ops to collection in a single round-trip and returns one
+// BulkResult per op, in request order. A failing op doesn't abort the rest
+// of the batch — check each result's Status. The whole round-trip is
+// retried with DefaultBackoff on a transient error (see isTransient); an
+// op-level failure reported in a BulkResult is not retried.
+func (c *Conn) Bulk(collection string, ops []BulkOp) ([]BulkResult, error) {
+	return c.BulkContext(context.Background(), collection, ops)
+}
+
+// BulkContext is Bulk with a context for cancellation, deadlines, and
+// bounding the retry loop.
+func (c *Conn) BulkContext(ctx context.Context, collection string, ops []BulkOp) ([]BulkResult, error) {
+	var results []BulkResult
+	err := Retry(ctx, DefaultBackoff, func() error {
+		var rerr error
+		results, rerr = c.bulkOnce(ctx, collection, ops)
+		return rerr
+	})
+	return results, err
+}
+
+// bulkOnce is a single, non-retrying attempt at a Bulk call. BulkProcessor
+// calls this directly so it can drive retries with its own backoff policy
+// instead of the fixed DefaultBackoff used by BulkContext.
+func (c *Conn) bulkOnce(ctx context.Context, collection string, ops []BulkOp) ([]BulkResult, error) {
+	wireOps := make([]map[string]any, len(ops))
+	for i, op := range ops {
+		wireOps[i] = op.toWire()
+	}
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "bulk", "collection": collection, "ops": wireOps})
+	if err != nil {
+		return nil, err
+	}
+	raw := toMapSlice(data)
+	results := make([]BulkResult, len(raw))
+	for i, r := range raw {
+		results[i] = bulkResultFromWire(r)
+	}
+	return results, nil
+}
+
+// BulkOptions configures BulkWrite.
+type BulkOptions struct {
+	// Ordered, when true, has the server stop at the first op error instead
+	// of continuing to apply the rest of the batch (the ecosystem's usual
+	// "ordered bulk write" semantics); when false, independent ops are free
+	// to run out of order and every op is attempted regardless of earlier
+	// failures.
+	Ordered bool
+}
+
+// BulkWriteError is one op's failure within a BulkWrite call, identified by
+// its index into the ops slice that was passed in.
+type BulkWriteError struct {
+	Index   int
+	Message string
+}
+
+// BulkWriteResult is BulkWrite's outcome, aggregated into ecosystem-standard
+// summary counts (mirroring mongo's BulkWriteResult) rather than Bulk's
+// per-op BulkResult list — the shape higher-level code wants when it just
+// needs to know what happened, not which specific op did it.
+type BulkWriteResult struct {
+	InsertedIDs   []string
+	MatchedCount  int
+	ModifiedCount int
+	UpsertedIDs   []string
+	DeletedCount  int
+	WriteErrors   []BulkWriteError
+}
+
+// BulkWrite sends ops to collection in a single round-trip, the same as
+// Bulk, but returns an aggregated BulkWriteResult instead of a BulkResult
+// per op — for callers that only need summary counts
+// and don't want to walk per-op results themselves. Unlike Bulk/BulkContext,
+// BulkWrite does not retry on a transient error; opts.Ordered controls
+// whether the server stops at the first op error or applies every op
+// regardless.
+func (c *Conn) BulkWrite(collection string, ops []BulkOp, opts *BulkOptions) (*BulkWriteResult, error) {
+	return c.BulkWriteContext(context.Background(), collection, ops, opts)
+}
+
+// BulkWriteContext is BulkWrite with a context for cancellation and
+// deadlines.
+func (c *Conn) BulkWriteContext(ctx context.Context, collection string, ops []BulkOp, opts *BulkOptions) (*BulkWriteResult, error) {
+	ordered := opts != nil && opts.Ordered
+	wireOps := make([]map[string]any, len(ops))
+	for i, op := range ops {
+		wireOps[i] = op.toWire()
+	}
+	data, err := c.checkedContext(ctx, map[string]any{
+		"cmd":        "bulk",
+		"collection": collection,
+		"ops":        wireOps,
+		"ordered":    ordered,
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw := toMapSlice(data)
+	results := make([]BulkResult, len(raw))
+	for i, r := range raw {
+		results[i] = bulkResultFromWire(r)
+	}
+	return aggregateBulkResults(ops, results), nil
+}
+
+// aggregateBulkResults rolls up one BulkResult per op into the summary
+// counts BulkWriteResult reports.
+func aggregateBulkResults(ops []BulkOp, results []BulkResult) *BulkWriteResult {
+	out := &BulkWriteResult{}
+	for i, r := range results {
+		if r.Status != "ok" {
+			out.WriteErrors = append(out.WriteErrors, BulkWriteError{Index: i, Message: r.Error})
+			continue
+		}
+		op := BulkOp{}
+		if i < len(ops) {
+			op = ops[i]
+		}
+		switch op.Type {
+		case BulkInsert:
+			out.InsertedIDs = append(out.InsertedIDs, r.ID)
+		case BulkUpdate:
+			out.MatchedCount++
+			out.ModifiedCount += r.Modified
+		case BulkUpsert:
+			if r.ID != "" {
+				out.UpsertedIDs = append(out.UpsertedIDs, r.ID)
+			} else {
+				out.MatchedCount++
+				out.ModifiedCount += r.Modified
+			}
+		case BulkDelete:
+			n := r.Modified
+			if n == 0 {
+				n = 1
+			}
+			out.DeletedCount += n
+		}
+	}
+	return out
+}
+
+// ------------------------------------------------------------------
+// Retry with backoff
+// ------------------------------------------------------------------
+
+// BackoffPolicy computes how long to wait before the (0-indexed) attempt-th
+// retry of an operation, or reports ok=false once it's given up.
+type BackoffPolicy interface {
+	Next(attempt int) (delay time.Duration, ok bool)
+}
+
+// SimpleBackoff is exponential backoff with full jitter: the delay before
+// attempt n is a random duration in [0, min(Base*2^n, Max)). MaxRetries
+// caps the number of retries; 0 means unlimited.
+type SimpleBackoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// DefaultBackoff doubles from 100ms up to a 30s cap and gives up after 5
+// retries; it's what Bulk and BulkContext use for whole-batch retries.
+var DefaultBackoff = SimpleBackoff{Base: 100 * time.Millisecond, Max: 30 * time.Second, MaxRetries: 5}
+
+// Next implements BackoffPolicy.
+func (b SimpleBackoff) Next(attempt int) (time.Duration, bool) {
+	if b.MaxRetries > 0 && attempt >= b.MaxRetries {
+		return 0, false
+	}
+	delay := b.Max
+	if shifted := b.Base << uint(attempt); shifted > 0 && shifted < b.Max {
+		delay = shifted
+	}
+	return time.Duration(rand.Int63n(int64(delay))), true
+}
+
+// Retry runs fn, retrying with backoff while fn's error is transient (see
+// isTransient) and the policy still allows another attempt. It returns as
+// soon as fn succeeds, fn fails with a non-transient error, the policy is
+// exhausted, or ctx is done.
+func Retry(ctx context.Context, backoff BackoffPolicy, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+		delay, ok := backoff.Next(attempt)
+		if !ok {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// isTransient reports whether err looks like a temporary condition worth
+// retrying — a dropped connection or the server reporting itself busy — as
+// opposed to a permanent rejection like a validation error or a
+// TransactionConflictError, which the caller needs to see immediately.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var oxErr *Error
+	if errors.As(err, &oxErr) {
+		return oxErr.Retryable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "busy")
+}
+
+// ------------------------------------------------------------------
+// BulkProcessor
+// ------------------------------------------------------------------
+
+// BulkProcessorConfig configures a BulkProcessor.
+type BulkProcessorConfig struct {
+	Collection string
+
+	// FlushBytes, FlushCount, and FlushInterval each independently trigger
+	// a flush of the currently buffered ops; whichever fires first wins.
+	// A zero value disables that trigger. FlushCount defaults to 500 if
+	// left at zero, since leaving every trigger disabled would buffer
+	// forever.
+	FlushBytes    int
+	FlushCount    int
+	FlushInterval time.Duration
+
+	// Workers is the number of concurrent flushes run against Client.
+	// Defaults to 1.
+	Workers int
+	// Backoff is the retry policy used for each flush. Defaults to
+	// DefaultBackoff.
+	Backoff BackoffPolicy
+
+	// BeforeFlush, if set, is called synchronously with a batch's ops
+	// right before it's sent.
+	BeforeFlush func(ops []BulkOp)
+	// AfterFlush, if set, is called with a batch's ops, the server's
+	// per-op results (nil if the round-trip failed outright), and any
+	// error from the round-trip itself once retries are exhausted.
+	AfterFlush func(ops []BulkOp, results []BulkResult, err error)
+}
+
+// BulkProcessor batches Add calls and flushes them to a Client by size,
+// count, or time, spreading flushes across Config.Workers concurrent
+// goroutines. Safe for concurrent use.
+type BulkProcessor struct {
+	client *Client
+	cfg    BulkProcessorConfig
+
+	mu      sync.Mutex
+	pending []BulkOp
+	bytes   int
+
+	flushCh chan []BulkOp
+	wg      sync.WaitGroup
+	stop    chan struct{}
+	closed  sync.Once
+}
+
+// NewBulkProcessor starts a BulkProcessor against client with cfg. Call
+// Close to flush any remainder and stop its worker goroutines.
+func NewBulkProcessor(client *Client, cfg BulkProcessorConfig) *BulkProcessor {
+	if cfg.FlushCount <= 0 {
+		cfg.FlushCount = 500
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = DefaultBackoff
+	}
+
+	p := &BulkProcessor{
+		client:  client,
+		cfg:     cfg,
+		flushCh: make(chan []BulkOp, cfg.Workers),
+		stop:    make(chan struct{}),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	if cfg.FlushInterval > 0 {
+		go p.tick()
+	}
+	return p
+}
+
+// Add buffers op, flushing the current batch first if adding it would push
+// the batch past FlushBytes or FlushCount.
+func (p *BulkProcessor) Add(op BulkOp) {
+	size := opSize(op)
+
+	p.mu.Lock()
+	if len(p.pending) > 0 && ((p.cfg.FlushBytes > 0 && p.bytes+size > p.cfg.FlushBytes) ||
+		(p.cfg.FlushCount > 0 && len(p.pending) >= p.cfg.FlushCount)) {
+		p.flushLocked()
+	}
+	p.pending = append(p.pending, op)
+	p.bytes += size
+	p.mu.Unlock()
+}
+
+// Flush sends any buffered ops immediately, without waiting for a size or
+// time trigger.
+func (p *BulkProcessor) Flush() {
+	p.mu.Lock()
+	p.flushLocked()
+	p.mu.Unlock()
+}
+
+// flushLocked must be called with p.mu held. It hands the current batch to
+// a worker and resets the buffer.
+func (p *BulkProcessor) flushLocked() {
+	if len(p.pending) == 0 {
+		return
+	}
+	batch := p.pending
+	p.pending = nil
+	p.bytes = 0
+	p.flushCh <- batch
+}
+
+func (p *BulkProcessor) tick() {
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.Flush()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *BulkProcessor) worker() {
+	defer p.wg.Done()
+	for batch := range p.flushCh {
+		if p.cfg.BeforeFlush != nil {
+			p.cfg.BeforeFlush(batch)
+		}
+		var results []BulkResult
+		err := Retry(context.Background(), p.cfg.Backoff, func() error {
+			var rerr error
+			results, rerr = p.client.bulkOnce(context.Background(), p.cfg.Collection, batch)
+			return rerr
+		})
+		if p.cfg.AfterFlush != nil {
+			p.cfg.AfterFlush(batch, results, err)
+		}
+	}
+}
+
+// Close flushes any remaining buffered ops and waits for all in-flight
+// flushes to finish. Safe to call more than once.
+func (p *BulkProcessor) Close() {
+	p.closed.Do(func() {
+		close(p.stop)
+		p.Flush()
+		close(p.flushCh)
+	})
+	p.wg.Wait()
+}
+
+// ------------------------------------------------------------------
+// BulkRequest
+// ------------------------------------------------------------------
+
+// BulkItem is one op's outcome within a BulkResponse, aligned by Index with
+// the BulkRequest that produced it. Collection is the op's own collection,
+// since a BulkRequest's ops can span many.
+type BulkItem struct {
+	Index      int
+	Collection string
+	OK         bool
+	Error      string
+	Data       map[string]any
+}
+
+func bulkItemFromWire(index int, collection string, raw map[string]any) BulkItem {
+	ok, _ := raw["ok"].(bool)
+	if !ok {
+		if status, has := raw["status"].(string); has {
+			ok = status == "ok"
+		}
+	}
+	errMsg, _ := raw["error"].(string)
+	data, _ := raw["data"].(map[string]any)
+	return BulkItem{Index: index, Collection: collection, OK: ok, Error: errMsg, Data: data}
+}
+
+// BulkResponse is a BulkRequest's outcome: one BulkItem per queued op, in
+// request order, across every flush Do triggered.
+type BulkResponse struct {
+	Items []BulkItem
+}
+
+// HasErrors reports whether any item failed.
+func (r *BulkResponse) HasErrors() bool {
+	for _, item := range r.Items {
+		if !item.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed returns an iterator over only the failed items, in request order.
+func (r *BulkResponse) Failed() *FailedBulkItems {
+	return &FailedBulkItems{items: r.Items, idx: -1}
+}
+
+// FailedBulkItems iterates a BulkResponse's failed items. Not safe for
+// concurrent use.
+type FailedBulkItems struct {
+	items []BulkItem
+	idx   int
+}
+
+// Next advances to the next failed item and reports whether one was found.
+func (it *FailedBulkItems) Next() bool {
+	for it.idx++; it.idx < len(it.items); it.idx++ {
+		if !it.items[it.idx].OK {
+			return true
+		}
+	}
+	return false
+}
+
+// Item returns the failed item Next most recently advanced to.
+func (it *FailedBulkItems) Item() BulkItem {
+	return it.items[it.idx]
+}
+
+// BulkRequest is a builder for heterogeneous, cross-collection bulk writes,
+// modeled on Elasticsearch's bulk API: queue Insert/Update/UpdateOne/Delete/
+// DeleteOne calls against any number of collections, then flush them in a
+// single round trip with Do. Unlike Bulk/BulkWrite, which send an
+// already-built []BulkOp against one collection, BulkRequest accumulates ops
+// itself (each tagged with its own collection) and optionally auto-flushes
+// by size. Issued from within a WithTransaction/WithTransactionContext
+// callback, a BulkRequest's flushes ride the same connection and therefore
+// the same transaction as everything else fn does. Not safe for concurrent
+// use.
+type BulkRequest struct {
+	client *Conn
+	ctx    context.Context
+
+	flushEvery int
+	flushBytes int
+
+	ops   []BulkOp
+	bytes int
+	items []BulkItem
+	err   error
+}
+
+// NewBulkRequest starts an empty BulkRequest against c. Call FlushEvery
+// and/or FlushBytes to auto-flush long streams of queued ops without the
+// caller having to count them; with neither set, everything stays buffered
+// until Do.
+func (c *Conn) NewBulkRequest() *BulkRequest {
+	return &BulkRequest{client: c, ctx: context.Background()}
+}
+
+// FlushEvery auto-flushes once n ops are queued. n <= 0 disables this
+// trigger.
+func (b *BulkRequest) FlushEvery(n int) *BulkRequest {
+	b.flushEvery = n
+	return b
+}
+
+// FlushBytes auto-flushes once the queued ops' estimated wire size would
+// exceed n bytes. n <= 0 disables this trigger.
+func (b *BulkRequest) FlushBytes(n int) *BulkRequest {
+	b.flushBytes = n
+	return b
+}
+
+// Insert queues an insert of doc into collection.
+func (b *BulkRequest) Insert(collection string, doc map[string]any) *BulkRequest {
+	return b.add(BulkOp{Type: BulkInsert, Collection: collection, Doc: doc})
+}
+
+// Update queues an update of every document in collection matching query.
+func (b *BulkRequest) Update(collection string, query, update map[string]any) *BulkRequest {
+	return b.add(BulkOp{Type: BulkUpdate, Collection: collection, Query: query, Update: update})
+}
+
+// UpdateOne queues an update of the first document in collection matching
+// query.
+func (b *BulkRequest) UpdateOne(collection string, query, update map[string]any) *BulkRequest {
+	return b.add(BulkOp{Type: BulkUpdateOne, Collection: collection, Query: query, Update: update})
+}
+
+// Delete queues deletion of every document in collection matching query.
+func (b *BulkRequest) Delete(collection string, query map[string]any) *BulkRequest {
+	return b.add(BulkOp{Type: BulkDelete, Collection: collection, Query: query})
+}
+
+// DeleteOne queues deletion of the first document in collection matching
+// query.
+func (b *BulkRequest) DeleteOne(collection string, query map[string]any) *BulkRequest {
+	return b.add(BulkOp{Type: BulkDeleteOne, Collection: collection, Query: query})
+}
+
+// add queues op, auto-flushing the current batch first if adding it would
+// push the batch past FlushBytes or FlushEvery. Once a flush fails, add is
+// a no-op — the error surfaces from Do instead of a builder method that
+// can't return one.
+func (b *BulkRequest) add(op BulkOp) *BulkRequest {
+	if b.err != nil {
+		return b
+	}
+	size := opSize(op)
+	if len(b.ops) > 0 && ((b.flushBytes > 0 && b.bytes+size > b.flushBytes) ||
+		(b.flushEvery > 0 && len(b.ops) >= b.flushEvery)) {
+		b.flush(context.Background())
+	}
+	b.ops = append(b.ops, op)
+	b.bytes += size
+	return b
+}
+
+// flush sends the currently queued ops, if any, and appends their items to
+// b.items. A failure is sticky: b.err short-circuits every later add and
+// flush so Do reports the first failure instead of retrying into it.
+func (b *BulkRequest) flush(ctx context.Context) {
+	if b.err != nil || len(b.ops) == 0 {
+		return
+	}
+	items, err := b.client.bulkRequestOnce(ctx, b.ops)
+	if err != nil {
+		b.err = err
+		return
+	}
+	// bulkRequestOnce numbers items within this flush; shift them so Index
+	// counts from the first op queued on b, across every flush.
+	for i := range items {
+		items[i].Index += len(b.items)
+	}
+	b.items = append(b.items, items...)
+	b.ops = nil
+	b.bytes = 0
+}
+
+// Do flushes any remaining queued ops and returns the aggregated
+// BulkResponse across every flush this BulkRequest triggered (auto-flushes
+// included).
+func (b *BulkRequest) Do() (*BulkResponse, error) {
+	return b.DoContext(b.ctx)
+}
+
+// DoContext is Do with a context for cancellation and deadlines on the
+// final flush.
+func (b *BulkRequest) DoContext(ctx context.Context) (*BulkResponse, error) {
+	b.flush(ctx)
+	return &BulkResponse{Items: b.items}, b.err
+}
+
+// bulkRequestOnce sends ops — each carrying its own collection — as a
+// single "bulk" command with no top-level collection, and parses the
+// response as BulkItems rather than the status-string BulkResults that
+// Bulk/BulkWrite use.
+func (c *Conn) bulkRequestOnce(ctx context.Context, ops []BulkOp) ([]BulkItem, error) {
+	wireOps := make([]map[string]any, len(ops))
+	for i, op := range ops {
+		wireOps[i] = op.toWire()
+	}
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "bulk", "ops": wireOps})
+	if err != nil {
+		return nil, err
+	}
+	raw := toMapSlice(data)
+	items := make([]BulkItem, len(raw))
+	for i, r := range raw {
+		collection := ""
+		if i < len(ops) {
+			collection = ops[i].Collection
+		}
+		items[i] = bulkItemFromWire(i, collection, r)
+	}
+	return items, nil
+}
@@ -7,32 +7,89 @@
 package oxidb
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 )
 
-// Client is a TCP client for oxidb-server. Thread-safe via mutex.
-type Client struct {
+// Conn is a single TCP connection to oxidb-server and the full RPC surface
+// of the package — every Find/Insert/Bulk/... method is defined on it.
+// Thread-safe via mutex, but only in the sense that concurrent calls
+// serialize rather than corrupt the stream; a Conn checked out of a Pool is
+// meant to be used by one owner at a time (see Pool.Checkout). pool,
+// createdAt, idleSince, and bad are the Pool's own bookkeeping on a Conn it
+// handed out — zero-valued and unused on a Conn created outside a Pool.
+//
+// readerOnce, respCh, readDone, readErr, subsMu, and subs back the
+// demultiplexer described on startReader: a Watch subscription pushes
+// change/heartbeat frames on the same socket a normal request's response
+// arrives on, so reading is centralized in one background goroutine that
+// sorts each frame to the right place instead of every caller reading for
+// itself the way requestContext once did.
+type Conn struct {
 	conn net.Conn
 	mu   sync.Mutex
+
+	pool      *Pool
+	createdAt time.Time
+	idleSince time.Time
+	bad       bool
+
+	readerOnce sync.Once
+	respCh     chan map[string]any
+	readDone   chan struct{}
+	readErr    error
+
+	subsMu sync.Mutex
+	subs   map[string]chan map[string]any
+}
+
+// Client is the original single-connection API, preserved for backward
+// compatibility: it pins one Conn, checked out once from a private size-1
+// Pool and never returned, so existing callers keep today's "one socket,
+// calls serialize on c.mu" behavior. New code that wants several requests
+// in flight at once should talk to a Pool directly instead.
+type Client struct {
+	*Conn
+	pool *Pool
 }
 
 // Connect creates a new client connected to oxidb-server.
 func Connect(host string, port int, timeout time.Duration) (*Client, error) {
-	addr := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", addr, timeout)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return ConnectContext(ctx, host, port)
+}
+
+// ConnectContext is Connect with a context for cancellation and deadlines on
+// the dial itself, so connecting to an unreachable or slow server can be
+// bounded by the caller's own context instead of only a fixed timeout.
+func ConnectContext(ctx context.Context, host string, port int) (*Client, error) {
+	pool, err := NewPool(PoolConfig{Host: host, Port: port, MinIdle: 1, MaxOpen: 1})
 	if err != nil {
-		return nil, fmt.Errorf("oxidb: connect to %s: %w", addr, err)
+		return nil, err
+	}
+	conn, err := pool.Checkout(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, err
 	}
-	conn.SetDeadline(time.Time{})
-	return &Client{conn: conn}, nil
+	return &Client{Conn: conn, pool: pool}, nil
 }
 
 // ConnectDefault connects to localhost:4444 with a 5-second timeout.
@@ -40,16 +97,48 @@ func ConnectDefault() (*Client, error) {
 	return Connect("127.0.0.1", 4444, 5*time.Second)
 }
 
+// dialConn opens a raw Conn against host:port, bounded by ctx. It has no
+// Pool of its own — callers that want pooling set the returned Conn's pool
+// field themselves (see Pool.Checkout/topUp).
+func dialConn(ctx context.Context, host string, port int) (*Conn, error) {
+	addr := fmt.Sprintf("%s:%d", host, port)
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("oxidb: connect to %s: %w", addr, err)
+	}
+	nc.SetDeadline(time.Time{})
+	return &Conn{conn: nc, createdAt: time.Now()}, nil
+}
+
 // Close closes the TCP connection.
-func (c *Client) Close() error {
+func (c *Conn) Close() error {
 	return c.conn.Close()
 }
 
+// Release returns c to the Pool it was checked out from, for reuse by the
+// next Checkout, or closes it outright if it was marked bad by a failed
+// request or has outlived the Pool's MaxLifetime. Safe to call on a Conn
+// with no Pool (a no-op that leaves the Conn open).
+func (c *Conn) Release() {
+	if c.pool == nil {
+		return
+	}
+	c.pool.release(c)
+}
+
+// Close closes Client's pinned Conn and the private Pool behind it.
+func (cl *Client) Close() error {
+	err := cl.Conn.Close()
+	cl.pool.Close()
+	return err
+}
+
 // ------------------------------------------------------------------
 // Low-level protocol
 // ------------------------------------------------------------------
 
-func (c *Client) sendRaw(data []byte) error {
+func (c *Conn) sendRaw(data []byte) error {
 	lenBuf := make([]byte, 4)
 	binary.LittleEndian.PutUint32(lenBuf, uint32(len(data)))
 	if _, err := c.conn.Write(lenBuf); err != nil {
@@ -59,7 +148,7 @@ func (c *Client) sendRaw(data []byte) error {
 	return err
 }
 
-func (c *Client) recvRaw() ([]byte, error) {
+func (c *Conn) recvRaw() ([]byte, error) {
 	lenBuf := make([]byte, 4)
 	if _, err := io.ReadFull(c.conn, lenBuf); err != nil {
 		return nil, fmt.Errorf("oxidb: read length: %w", err)
@@ -72,43 +161,174 @@ func (c *Client) recvRaw() ([]byte, error) {
 	return payload, nil
 }
 
-func (c *Client) request(payload map[string]any) (map[string]any, error) {
+func (c *Conn) request(payload map[string]any) (map[string]any, error) {
+	return c.requestContext(context.Background(), payload)
+}
+
+// startReader launches the Conn's single background reader, once per Conn:
+// a loop that reads frames off the socket and sorts each one to wherever
+// it's wanted, since a Watch subscription means oxidb's normally strict
+// one-response-per-request socket can have change/heartbeat frames show up
+// between (or instead of) a normal request's response. requestContext reads
+// its response from respCh; Watch/WatchBucket read theirs from the channel
+// registered under their watch ID by subscribe. Safe to call more than
+// once; only the first call does anything.
+func (c *Conn) startReader() {
+	c.readerOnce.Do(func() {
+		c.respCh = make(chan map[string]any, 1)
+		c.readDone = make(chan struct{})
+		go c.readLoop()
+	})
+}
+
+// readLoop is startReader's background goroutine. It runs until recvRaw or
+// json.Unmarshal fails — a closed or broken socket — at which point it
+// records the error in readErr and closes readDone so every current and
+// future reader (requestContext's respCh wait, any open subscription) wakes
+// up with it instead of blocking forever.
+func (c *Conn) readLoop() {
+	for {
+		raw, err := c.recvRaw()
+		if err != nil {
+			c.failReaders(err)
+			return
+		}
+		var frame map[string]any
+		if err := json.Unmarshal(raw, &frame); err != nil {
+			c.failReaders(fmt.Errorf("oxidb: unmarshal response: %w", err))
+			return
+		}
+		if event, _ := frame["event"].(string); event != "" {
+			c.dispatchEvent(frame)
+			continue
+		}
+		select {
+		case c.respCh <- frame:
+		case <-c.readDone:
+			return
+		}
+	}
+}
+
+// dispatchEvent routes a change/heartbeat frame to the subscription it
+// belongs to by watch_id, dropping it if that subscription has already
+// unsubscribed (a cancel racing the next frame in flight).
+func (c *Conn) dispatchEvent(frame map[string]any) {
+	watchID, _ := frame["watch_id"].(string)
+	c.subsMu.Lock()
+	ch := c.subs[watchID]
+	c.subsMu.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- frame:
+	case <-c.readDone:
+	}
+}
+
+// subscribe registers a channel for frames carrying watchID, returning an
+// unsubscribe func. Called by Watch/WatchBucket right after the server
+// acknowledges the subscription, so no frame for it can arrive unrouted.
+func (c *Conn) subscribe(watchID string) (<-chan map[string]any, func()) {
+	ch := make(chan map[string]any, 32)
+	c.subsMu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[string]chan map[string]any)
+	}
+	c.subs[watchID] = ch
+	c.subsMu.Unlock()
+	return ch, func() {
+		c.subsMu.Lock()
+		delete(c.subs, watchID)
+		c.subsMu.Unlock()
+	}
+}
+
+// failReaders delivers err to every waiter blocked on this Conn's reader:
+// requestContext's respCh wait and any open subscription. Called once, by
+// readLoop, on its way out after a fatal read error.
+func (c *Conn) failReaders(err error) {
+	c.readErr = err
+	close(c.readDone)
+}
+
+// requestContext is request's context-aware form: it sets a socket
+// deadline from ctx.Deadline() (similar to how net/http layers deadlines
+// onto a raw net.Conn) and races the blocking send against ctx.Done(),
+// reading its response from the Conn's background reader (see startReader)
+// rather than reading the socket itself, so a frame meant for an open Watch
+// subscription doesn't get mistaken for this call's response. oxidb's wire
+// protocol has no per-request framing, so there's no way to abort only the
+// in-flight call: on cancellation the connection is closed, which fails
+// every other call sharing it too, and c is marked bad so a Pool won't hand
+// it out again (see Conn.Release). A transient error from the call itself
+// (see isTransient) marks c bad the same way without closing it outright —
+// Release is what decides whether a bad Conn gets closed.
+func (c *Conn) requestContext(ctx context.Context, payload map[string]any) (map[string]any, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.startReader()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Time{})
+	}
+	defer c.conn.SetDeadline(time.Time{})
 
 	jsonBytes, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("oxidb: marshal request: %w", err)
 	}
-	if err := c.sendRaw(jsonBytes); err != nil {
-		return nil, fmt.Errorf("oxidb: send: %w", err)
+
+	sendErr := make(chan error, 1)
+	go func() { sendErr <- c.sendRaw(jsonBytes) }()
+
+	select {
+	case err := <-sendErr:
+		if err != nil {
+			err = fmt.Errorf("oxidb: send: %w", err)
+			if isTransient(err) {
+				c.bad = true
+			}
+			return nil, err
+		}
+	case <-ctx.Done():
+		c.bad = true
+		c.conn.Close()
+		<-sendErr
+		return nil, ctx.Err()
 	}
-	respBytes, err := c.recvRaw()
-	if err != nil {
+
+	select {
+	case resp := <-c.respCh:
+		return resp, nil
+	case <-c.readDone:
+		err := c.readErr
+		if isTransient(err) {
+			c.bad = true
+		}
 		return nil, err
+	case <-ctx.Done():
+		c.bad = true
+		c.conn.Close()
+		return nil, ctx.Err()
 	}
-	var resp map[string]any
-	if err := json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, fmt.Errorf("oxidb: unmarshal response: %w", err)
-	}
-	return resp, nil
 }
 
-func (c *Client) checked(payload map[string]any) (any, error) {
-	resp, err := c.request(payload)
+func (c *Conn) checked(payload map[string]any) (any, error) {
+	return c.checkedContext(context.Background(), payload)
+}
+
+func (c *Conn) checkedContext(ctx context.Context, payload map[string]any) (any, error) {
+	resp, err := c.requestContext(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
 	ok, _ := resp["ok"].(bool)
 	if !ok {
-		errMsg, _ := resp["error"].(string)
-		if errMsg == "" {
-			errMsg = "unknown error"
-		}
-		if strings.Contains(strings.ToLower(errMsg), "conflict") {
-			return nil, &TransactionConflictError{Msg: errMsg}
-		}
-		return nil, &Error{Msg: errMsg}
+		return nil, parseError(resp["error"])
 	}
 	return resp["data"], nil
 }
@@ -118,8 +338,15 @@ func (c *Client) checked(payload map[string]any) (any, error) {
 // ------------------------------------------------------------------
 
 // Ping sends a ping to the server. Returns "pong".
-func (c *Client) Ping() (string, error) {
-	data, err := c.checked(map[string]any{"cmd": "ping"})
+func (c *Conn) Ping() (string, error) {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is Ping with a context for cancellation and deadlines, so
+// a health check against a hung connection doesn't block its caller
+// indefinitely.
+func (c *Conn) PingContext(ctx context.Context) (string, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "ping"})
 	if err != nil {
 		return "", err
 	}
@@ -132,13 +359,13 @@ func (c *Client) Ping() (string, error) {
 // ------------------------------------------------------------------
 
 // CreateCollection explicitly creates a collection.
-func (c *Client) CreateCollection(name string) error {
+func (c *Conn) CreateCollection(name string) error {
 	_, err := c.checked(map[string]any{"cmd": "create_collection", "collection": name})
 	return err
 }
 
 // ListCollections returns a list of collection names.
-func (c *Client) ListCollections() ([]string, error) {
+func (c *Conn) ListCollections() ([]string, error) {
 	data, err := c.checked(map[string]any{"cmd": "list_collections"})
 	if err != nil {
 		return nil, err
@@ -152,7 +379,7 @@ func (c *Client) ListCollections() ([]string, error) {
 }
 
 // DropCollection drops a collection and its data.
-func (c *Client) DropCollection(name string) error {
+func (c *Conn) DropCollection(name string) error {
 	_, err := c.checked(map[string]any{"cmd": "drop_collection", "collection": name})
 	return err
 }
@@ -162,8 +389,13 @@ func (c *Client) DropCollection(name string) error {
 // ------------------------------------------------------------------
 
 // Insert inserts a single document. Returns the raw response data.
-func (c *Client) Insert(collection string, doc map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{"cmd": "insert", "collection": collection, "doc": doc})
+func (c *Conn) Insert(collection string, doc map[string]any) (map[string]any, error) {
+	return c.InsertContext(context.Background(), collection, doc)
+}
+
+// InsertContext is Insert with a context for cancellation and deadlines.
+func (c *Conn) InsertContext(ctx context.Context, collection string, doc map[string]any) (map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "insert", "collection": collection, "doc": doc})
 	if err != nil {
 		return nil, err
 	}
@@ -174,9 +406,28 @@ func (c *Client) Insert(collection string, doc map[string]any) (map[string]any,
 	return map[string]any{"status": data}, nil
 }
 
-// InsertMany inserts multiple documents.
-func (c *Client) InsertMany(collection string, docs []map[string]any) (any, error) {
-	return c.checked(map[string]any{"cmd": "insert_many", "collection": collection, "docs": docs})
+// InsertMany inserts multiple documents, returning their IDs as []any (for
+// historical compatibility with callers written against the old
+// insert_many command). It's built on Bulk, so it gets the same
+// automatic retry of transient failures; use Bulk directly for per-doc
+// results instead of an all-or-nothing error.
+func (c *Conn) InsertMany(collection string, docs []map[string]any) (any, error) {
+	ops := make([]BulkOp, len(docs))
+	for i, doc := range docs {
+		ops[i] = BulkOp{Type: BulkInsert, ClientID: strconv.Itoa(i), Doc: doc}
+	}
+	results, err := c.Bulk(collection, ops)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]any, len(results))
+	for i, r := range results {
+		if r.Status != "ok" {
+			return nil, fmt.Errorf("oxidb: insert_many: doc %d: %s", i, r.Error)
+		}
+		ids[i] = r.ID
+	}
+	return ids, nil
 }
 
 // FindOptions holds optional parameters for Find.
@@ -187,7 +438,12 @@ type FindOptions struct {
 }
 
 // Find returns documents matching a query.
-func (c *Client) Find(collection string, query map[string]any, opts *FindOptions) ([]map[string]any, error) {
+func (c *Conn) Find(collection string, query map[string]any, opts *FindOptions) ([]map[string]any, error) {
+	return c.FindContext(context.Background(), collection, query, opts)
+}
+
+// FindContext is Find with a context for cancellation and deadlines.
+func (c *Conn) FindContext(ctx context.Context, collection string, query map[string]any, opts *FindOptions) ([]map[string]any, error) {
 	payload := map[string]any{"cmd": "find", "collection": collection, "query": query}
 	if opts != nil {
 		if opts.Sort != nil {
@@ -200,16 +456,223 @@ func (c *Client) Find(collection string, query map[string]any, opts *FindOptions
 			payload["limit"] = *opts.Limit
 		}
 	}
-	data, err := c.checked(payload)
+	data, err := c.checkedContext(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
 	return toMapSlice(data), nil
 }
 
+// FindResult is a single item delivered by FindStream: either a decoded
+// document or a terminal error.
+type FindResult struct {
+	Doc map[string]any
+	Err error
+}
+
+// defaultStreamPageSize is the Skip/Limit page size FindStream pages by
+// when the caller doesn't request a smaller overall Limit.
+const defaultStreamPageSize = 500
+
+// FindStream pages through query results using Skip/Limit and delivers them
+// over a buffered channel, so large result sets (exports, reports, the
+// 1M-doc benchmark's Phase-3 queries) can be consumed incrementally instead
+// of materialized into a slice by Find. The channel is closed on EOF,
+// cancellation, or a terminal error (sent as the last FindResult). Callers
+// must always invoke the returned cancel func, even after draining the
+// channel, to stop the background goroutine and release its resources.
+func (c *Conn) FindStream(collection string, query map[string]any, opts *FindOptions) (<-chan FindResult, func(), error) {
+	pageSize := defaultStreamPageSize
+	skip := 0
+	remaining := -1
+	var sort map[string]any
+	if opts != nil {
+		if opts.Skip != nil {
+			skip = *opts.Skip
+		}
+		if opts.Limit != nil {
+			remaining = *opts.Limit
+			if remaining >= 0 && remaining < pageSize {
+				pageSize = remaining
+			}
+		}
+		sort = opts.Sort
+	}
+
+	out := make(chan FindResult, pageSize)
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	cancel := func() { closeOnce.Do(func() { close(done) }) }
+
+	go func() {
+		defer close(out)
+		for {
+			limit := pageSize
+			if remaining >= 0 {
+				if remaining <= 0 {
+					return
+				}
+				if remaining < limit {
+					limit = remaining
+				}
+			}
+			pageOpts := &FindOptions{Sort: sort, Skip: &skip, Limit: &limit}
+			docs, err := c.Find(collection, query, pageOpts)
+			if err != nil {
+				select {
+				case out <- FindResult{Err: err}:
+				case <-done:
+				}
+				return
+			}
+			for _, d := range docs {
+				select {
+				case out <- FindResult{Doc: d}:
+				case <-done:
+					return
+				}
+			}
+			if remaining >= 0 {
+				remaining -= len(docs)
+			}
+			if len(docs) < limit {
+				return
+			}
+			skip += len(docs)
+		}
+	}()
+
+	return out, cancel, nil
+}
+
+// defaultCursorBatchSize is the batch size FindCursor requests from the
+// server when opts doesn't set a smaller overall Limit.
+const defaultCursorBatchSize = 500
+
+// Cursor iterates a Find's results one document at a time, fetching
+// further batches from the server's cursor registry on demand instead of
+// paging with Skip/Limit (FindStream) or materializing the whole result
+// set (Find). Unlike FindStream, the server — not the client — tracks
+// position, so a Cursor survives being handed off or resumed across
+// reconnects within the registry's TTL. Not safe for concurrent use.
+type Cursor struct {
+	client    *Conn
+	id        string
+	batch     []map[string]any
+	idx       int
+	exhausted bool
+	err       error
+	closed    bool
+}
+
+// FindCursor opens a server-side cursor over a query's results, fetched in
+// batches of batchSize (defaultCursorBatchSize if opts doesn't set a
+// smaller Limit). Call Close when done with it, even after draining it via
+// Next, to release its slot in the server's cursor registry before its TTL
+// would do so anyway.
+func (c *Conn) FindCursor(collection string, query map[string]any, opts *FindOptions) (*Cursor, error) {
+	return c.FindCursorContext(context.Background(), collection, query, opts)
+}
+
+// FindCursorContext is FindCursor with a context for cancellation and
+// deadlines on the opening request; Next takes its own context for each
+// subsequent batch fetch.
+func (c *Conn) FindCursorContext(ctx context.Context, collection string, query map[string]any, opts *FindOptions) (*Cursor, error) {
+	batchSize := defaultCursorBatchSize
+	payload := map[string]any{"cmd": "find_cursor", "collection": collection, "query": query, "batch_size": batchSize}
+	if opts != nil {
+		if opts.Sort != nil {
+			payload["sort"] = opts.Sort
+		}
+		if opts.Skip != nil {
+			payload["skip"] = *opts.Skip
+		}
+		if opts.Limit != nil {
+			payload["limit"] = *opts.Limit
+			if *opts.Limit < batchSize {
+				payload["batch_size"] = *opts.Limit
+			}
+		}
+	}
+	data, err := c.checkedContext(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := data.(map[string]any)
+	id, _ := m["cursorId"].(string)
+	exhausted, _ := m["exhausted"].(bool)
+	return &Cursor{client: c, id: id, batch: toMapSlice(m["batch"]), idx: -1, exhausted: exhausted || id == ""}, nil
+}
+
+// Next advances the cursor to the next document, fetching a further batch
+// from the server if the current one is exhausted, and reports whether one
+// was found. Once Next returns false, check Err to distinguish end-of-
+// results from a failed fetch.
+func (cur *Cursor) Next(ctx context.Context) bool {
+	if cur.closed || cur.err != nil {
+		return false
+	}
+	cur.idx++
+	if cur.idx < len(cur.batch) {
+		return true
+	}
+	if cur.exhausted {
+		return false
+	}
+
+	data, err := cur.client.checkedContext(ctx, map[string]any{"cmd": "cursor_next", "cursor_id": cur.id})
+	if err != nil {
+		cur.err = err
+		return false
+	}
+	m, _ := data.(map[string]any)
+	cur.batch = toMapSlice(m["batch"])
+	cur.exhausted, _ = m["exhausted"].(bool)
+	cur.idx = 0
+	return len(cur.batch) > 0
+}
+
+// Decode unmarshals the document Next most recently advanced to into out.
+func (cur *Cursor) Decode(out any) error {
+	if cur.idx < 0 || cur.idx >= len(cur.batch) {
+		return fmt.Errorf("oxidb: Decode called without a successful Next")
+	}
+	data, err := json.Marshal(cur.batch[cur.idx])
+	if err != nil {
+		return fmt.Errorf("oxidb: marshal cursor doc: %w", err)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Err returns the first error encountered by Next, if any.
+func (cur *Cursor) Err() error {
+	return cur.err
+}
+
+// Close releases the cursor's slot in the server's cursor registry. Safe
+// to call more than once, and safe to call on a cursor already exhausted
+// by Next (the server retires an exhausted cursor's ID on its own, so this
+// is then a no-op round-trip).
+func (cur *Cursor) Close() error {
+	if cur.closed {
+		return nil
+	}
+	cur.closed = true
+	if cur.id == "" {
+		return nil
+	}
+	_, err := cur.client.checked(map[string]any{"cmd": "cursor_close", "cursor_id": cur.id})
+	return err
+}
+
 // FindOne returns a single document matching a query, or nil.
-func (c *Client) FindOne(collection string, query map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{"cmd": "find_one", "collection": collection, "query": query})
+func (c *Conn) FindOne(collection string, query map[string]any) (map[string]any, error) {
+	return c.FindOneContext(context.Background(), collection, query)
+}
+
+// FindOneContext is FindOne with a context for cancellation and deadlines.
+func (c *Conn) FindOneContext(ctx context.Context, collection string, query map[string]any) (map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "find_one", "collection": collection, "query": query})
 	if err != nil {
 		return nil, err
 	}
@@ -220,12 +683,44 @@ func (c *Client) FindOne(collection string, query map[string]any) (map[string]an
 	return m, nil
 }
 
+// UpdateOptions holds optional parameters for Update and UpdateOne.
+type UpdateOptions struct {
+	// ArrayFilters resolves which elements of an array field a positional
+	// operator in update applies to. Each filter is a query matched
+	// against one candidate element at a time, and its key identifies the
+	// positional token it binds: "elem.qty" in the filter pairs with
+	// "items.$[elem].discount" in update, "$[]" applies to every element
+	// with no filter needed, and a bare "$" resolves against query itself.
+	ArrayFilters []map[string]any
+}
+
 // Update updates documents matching a query.
-func (c *Client) Update(collection string, query, update map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) Update(collection string, query, update map[string]any) (map[string]any, error) {
+	return c.UpdateContext(context.Background(), collection, query, update)
+}
+
+// UpdateContext is Update with a context for cancellation and deadlines.
+func (c *Conn) UpdateContext(ctx context.Context, collection string, query, update map[string]any) (map[string]any, error) {
+	return c.UpdateWithOptionsContext(ctx, collection, query, update, nil)
+}
+
+// UpdateWithOptions is Update with UpdateOptions, e.g. ArrayFilters for
+// positional operators ($, $[], $[<identifier>]) in update.
+func (c *Conn) UpdateWithOptions(collection string, query, update map[string]any, opts *UpdateOptions) (map[string]any, error) {
+	return c.UpdateWithOptionsContext(context.Background(), collection, query, update, opts)
+}
+
+// UpdateWithOptionsContext is UpdateWithOptions with a context for
+// cancellation and deadlines.
+func (c *Conn) UpdateWithOptionsContext(ctx context.Context, collection string, query, update map[string]any, opts *UpdateOptions) (map[string]any, error) {
+	payload := map[string]any{
 		"cmd": "update", "collection": collection,
 		"query": query, "update": update,
-	})
+	}
+	if opts != nil && opts.ArrayFilters != nil {
+		payload["array_filters"] = opts.ArrayFilters
+	}
+	data, err := c.checkedContext(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -236,11 +731,32 @@ func (c *Client) Update(collection string, query, update map[string]any) (map[st
 }
 
 // UpdateOne updates at most one document matching a query.
-func (c *Client) UpdateOne(collection string, query, update map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) UpdateOne(collection string, query, update map[string]any) (map[string]any, error) {
+	return c.UpdateOneContext(context.Background(), collection, query, update)
+}
+
+// UpdateOneContext is UpdateOne with a context for cancellation and deadlines.
+func (c *Conn) UpdateOneContext(ctx context.Context, collection string, query, update map[string]any) (map[string]any, error) {
+	return c.UpdateOneWithOptionsContext(ctx, collection, query, update, nil)
+}
+
+// UpdateOneWithOptions is UpdateOne with UpdateOptions, e.g. ArrayFilters
+// for positional operators ($, $[], $[<identifier>]) in update.
+func (c *Conn) UpdateOneWithOptions(collection string, query, update map[string]any, opts *UpdateOptions) (map[string]any, error) {
+	return c.UpdateOneWithOptionsContext(context.Background(), collection, query, update, opts)
+}
+
+// UpdateOneWithOptionsContext is UpdateOneWithOptions with a context for
+// cancellation and deadlines.
+func (c *Conn) UpdateOneWithOptionsContext(ctx context.Context, collection string, query, update map[string]any, opts *UpdateOptions) (map[string]any, error) {
+	payload := map[string]any{
 		"cmd": "update_one", "collection": collection,
 		"query": query, "update": update,
-	})
+	}
+	if opts != nil && opts.ArrayFilters != nil {
+		payload["array_filters"] = opts.ArrayFilters
+	}
+	data, err := c.checkedContext(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -251,8 +767,13 @@ func (c *Client) UpdateOne(collection string, query, update map[string]any) (map
 }
 
 // Delete deletes documents matching a query.
-func (c *Client) Delete(collection string, query map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) Delete(collection string, query map[string]any) (map[string]any, error) {
+	return c.DeleteContext(context.Background(), collection, query)
+}
+
+// DeleteContext is Delete with a context for cancellation and deadlines.
+func (c *Conn) DeleteContext(ctx context.Context, collection string, query map[string]any) (map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{
 		"cmd": "delete", "collection": collection, "query": query,
 	})
 	if err != nil {
@@ -265,8 +786,13 @@ func (c *Client) Delete(collection string, query map[string]any) (map[string]any
 }
 
 // DeleteOne deletes at most one document matching a query.
-func (c *Client) DeleteOne(collection string, query map[string]any) (map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) DeleteOne(collection string, query map[string]any) (map[string]any, error) {
+	return c.DeleteOneContext(context.Background(), collection, query)
+}
+
+// DeleteOneContext is DeleteOne with a context for cancellation and deadlines.
+func (c *Conn) DeleteOneContext(ctx context.Context, collection string, query map[string]any) (map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{
 		"cmd": "delete_one", "collection": collection, "query": query,
 	})
 	if err != nil {
@@ -279,8 +805,13 @@ func (c *Client) DeleteOne(collection string, query map[string]any) (map[string]
 }
 
 // Count returns the number of documents matching a query.
-func (c *Client) Count(collection string, query map[string]any) (int, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) Count(collection string, query map[string]any) (int, error) {
+	return c.CountContext(context.Background(), collection, query)
+}
+
+// CountContext is Count with a context for cancellation and deadlines.
+func (c *Conn) CountContext(ctx context.Context, collection string, query map[string]any) (int, error) {
+	data, err := c.checkedContext(ctx, map[string]any{
 		"cmd": "count", "collection": collection, "query": query,
 	})
 	if err != nil {
@@ -291,38 +822,119 @@ func (c *Client) Count(collection string, query map[string]any) (int, error) {
 	return int(count), nil
 }
 
+// FindText runs a full-text query intersected with a structured filter in
+// a single server-side pass (the server's query planner is expected to
+// evaluate the $text operator against its inverted index and intersect
+// the candidate set with filter's index scan, rather than a client having
+// to fetch text-search hit IDs and re-query them with an $in filter).
+func (c *Conn) FindText(collection, textQuery string, filter map[string]any, opts *FindOptions) ([]map[string]any, error) {
+	return c.FindTextContext(context.Background(), collection, textQuery, filter, opts)
+}
+
+// FindTextContext is FindText with a context for cancellation and deadlines.
+func (c *Conn) FindTextContext(ctx context.Context, collection, textQuery string, filter map[string]any, opts *FindOptions) ([]map[string]any, error) {
+	var query map[string]any
+	if len(filter) > 0 {
+		query = map[string]any{"$and": []any{map[string]any{"$text": textQuery}, filter}}
+	} else {
+		query = map[string]any{"$text": textQuery}
+	}
+	payload := map[string]any{"cmd": "find", "collection": collection, "query": query}
+	if opts != nil {
+		if opts.Sort != nil {
+			payload["sort"] = opts.Sort
+		}
+		if opts.Skip != nil {
+			payload["skip"] = *opts.Skip
+		}
+		if opts.Limit != nil {
+			payload["limit"] = *opts.Limit
+		}
+	}
+	data, err := c.checkedContext(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return toMapSlice(data), nil
+}
+
 // ------------------------------------------------------------------
 // Indexes
 // ------------------------------------------------------------------
 
 // CreateIndex creates a non-unique index on a field.
-func (c *Client) CreateIndex(collection, field string) error {
+func (c *Conn) CreateIndex(collection, field string) error {
 	_, err := c.checked(map[string]any{"cmd": "create_index", "collection": collection, "field": field})
 	return err
 }
 
 // CreateUniqueIndex creates a unique index on a field.
-func (c *Client) CreateUniqueIndex(collection, field string) error {
+func (c *Conn) CreateUniqueIndex(collection, field string) error {
 	_, err := c.checked(map[string]any{"cmd": "create_unique_index", "collection": collection, "field": field})
 	return err
 }
 
 // CreateCompositeIndex creates a composite index on multiple fields.
-func (c *Client) CreateCompositeIndex(collection string, fields []string) error {
+func (c *Conn) CreateCompositeIndex(collection string, fields []string) error {
 	_, err := c.checked(map[string]any{"cmd": "create_composite_index", "collection": collection, "fields": fields})
 	return err
 }
 
+// TextIndexOptions selects the analyzer pipeline a text index applies to
+// every field at index time, and to the query string at search time, so
+// e.g. a Russian snowball stemmer lets a query for "программирование" match
+// a document containing "программированию". The chosen pipeline is
+// persisted with the index's own metadata and returned by ListIndexes.
+type TextIndexOptions struct {
+	// Language is an ISO 639-1 code ("en", "ru", "de", "fr", …) selecting
+	// the stopword list and default stemmer for the index; "" defaults to
+	// "en".
+	Language string
+	// Stemmer picks the stemming algorithm: "snowball", "porter", or "none"
+	// to index terms unstemmed. "" defaults to "snowball".
+	Stemmer string
+	// StopWords overrides the language's default stopword list; nil keeps
+	// the default, and an empty (non-nil) slice disables stopword removal.
+	StopWords []string
+	// CaseFold and DiacriticFold enable case-insensitive and accent-
+	// insensitive matching respectively.
+	CaseFold      bool
+	DiacriticFold bool
+}
+
+func (o *TextIndexOptions) toWire() map[string]any {
+	if o == nil {
+		return nil
+	}
+	wire := map[string]any{
+		"language":      o.Language,
+		"stemmer":       o.Stemmer,
+		"caseFold":      o.CaseFold,
+		"diacriticFold": o.DiacriticFold,
+	}
+	if o.StopWords != nil {
+		wire["stopWords"] = o.StopWords
+	}
+	return wire
+}
+
 // CreateTextIndex creates a full-text search index on the specified fields.
-func (c *Client) CreateTextIndex(collection string, fields []string) error {
-	_, err := c.checked(map[string]any{
+// opts selects the analyzer pipeline applied at both index and query time;
+// nil uses the server's defaults (English, snowball stemming, case and
+// diacritic folding).
+func (c *Conn) CreateTextIndex(collection string, fields []string, opts *TextIndexOptions) error {
+	payload := map[string]any{
 		"cmd": "create_text_index", "collection": collection, "fields": fields,
-	})
+	}
+	if wire := opts.toWire(); wire != nil {
+		payload["analyzer"] = wire
+	}
+	_, err := c.checked(payload)
 	return err
 }
 
 // ListIndexes returns metadata for all indexes on a collection.
-func (c *Client) ListIndexes(collection string) ([]map[string]any, error) {
+func (c *Conn) ListIndexes(collection string) ([]map[string]any, error) {
 	data, err := c.checked(map[string]any{"cmd": "list_indexes", "collection": collection})
 	if err != nil {
 		return nil, err
@@ -331,7 +943,7 @@ func (c *Client) ListIndexes(collection string) ([]map[string]any, error) {
 }
 
 // DropIndex drops an index by name.
-func (c *Client) DropIndex(collection, index string) error {
+func (c *Conn) DropIndex(collection, index string) error {
 	_, err := c.checked(map[string]any{
 		"cmd": "drop_index", "collection": collection, "index": index,
 	})
@@ -339,8 +951,14 @@ func (c *Client) DropIndex(collection, index string) error {
 }
 
 // TextSearch performs full-text search on a collection's text index.
-func (c *Client) TextSearch(collection, query string, limit int) ([]map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) TextSearch(collection, query string, limit int) ([]map[string]any, error) {
+	return c.TextSearchContext(context.Background(), collection, query, limit)
+}
+
+// TextSearchContext is TextSearch with a context for cancellation and
+// deadlines.
+func (c *Conn) TextSearchContext(ctx context.Context, collection, query string, limit int) ([]map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{
 		"cmd": "text_search", "collection": collection, "query": query, "limit": limit,
 	})
 	if err != nil {
@@ -354,8 +972,15 @@ func (c *Client) TextSearch(collection, query string, limit int) ([]map[string]a
 // ------------------------------------------------------------------
 
 // Aggregate runs an aggregation pipeline.
-func (c *Client) Aggregate(collection string, pipeline []map[string]any) ([]map[string]any, error) {
-	data, err := c.checked(map[string]any{
+func (c *Conn) Aggregate(collection string, pipeline []map[string]any) ([]map[string]any, error) {
+	return c.AggregateContext(context.Background(), collection, pipeline)
+}
+
+// AggregateContext is Aggregate with a context for cancellation and
+// deadlines, so a long-running pipeline can be bounded by a per-call
+// timeout instead of blocking indefinitely.
+func (c *Conn) AggregateContext(ctx context.Context, collection string, pipeline []map[string]any) ([]map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{
 		"cmd": "aggregate", "collection": collection, "pipeline": pipeline,
 	})
 	if err != nil {
@@ -369,7 +994,7 @@ func (c *Client) Aggregate(collection string, pipeline []map[string]any) ([]map[
 // ------------------------------------------------------------------
 
 // Compact compacts a collection. Returns stats with old_size, new_size, docs_kept.
-func (c *Client) Compact(collection string) (map[string]any, error) {
+func (c *Conn) Compact(collection string) (map[string]any, error) {
 	data, err := c.checked(map[string]any{"cmd": "compact", "collection": collection})
 	if err != nil {
 		return nil, err
@@ -383,8 +1008,13 @@ func (c *Client) Compact(collection string) (map[string]any, error) {
 // ------------------------------------------------------------------
 
 // BeginTx starts a transaction on this connection.
-func (c *Client) BeginTx() (map[string]any, error) {
-	data, err := c.checked(map[string]any{"cmd": "begin_tx"})
+func (c *Conn) BeginTx() (map[string]any, error) {
+	return c.BeginTxContext(context.Background())
+}
+
+// BeginTxContext is BeginTx with a context for cancellation and deadlines.
+func (c *Conn) BeginTxContext(ctx context.Context) (map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "begin_tx"})
 	if err != nil {
 		return nil, err
 	}
@@ -393,28 +1023,95 @@ func (c *Client) BeginTx() (map[string]any, error) {
 }
 
 // CommitTx commits the active transaction.
-func (c *Client) CommitTx() error {
-	_, err := c.checked(map[string]any{"cmd": "commit_tx"})
+func (c *Conn) CommitTx() error {
+	return c.CommitTxContext(context.Background())
+}
+
+// CommitTxContext is CommitTx with a context for cancellation and deadlines.
+func (c *Conn) CommitTxContext(ctx context.Context) error {
+	_, err := c.checkedContext(ctx, map[string]any{"cmd": "commit_tx"})
 	return err
 }
 
 // RollbackTx rolls back the active transaction.
-func (c *Client) RollbackTx() error {
-	_, err := c.checked(map[string]any{"cmd": "rollback_tx"})
+func (c *Conn) RollbackTx() error {
+	return c.RollbackTxContext(context.Background())
+}
+
+// RollbackTxContext is RollbackTx with a context for cancellation and
+// deadlines.
+func (c *Conn) RollbackTxContext(ctx context.Context) error {
+	_, err := c.checkedContext(ctx, map[string]any{"cmd": "rollback_tx"})
 	return err
 }
 
 // WithTransaction executes fn within a transaction.
 // Auto-commits on success, auto-rolls back on error.
-func (c *Client) WithTransaction(fn func() error) error {
-	if _, err := c.BeginTx(); err != nil {
+func (c *Conn) WithTransaction(fn func() error) error {
+	return c.WithTransactionContext(context.Background(), func(context.Context) error { return fn() })
+}
+
+// WithTransactionContext is WithTransaction with a context threaded into
+// every statement fn issues, plus the begin/commit/rollback themselves. If
+// ctx is cancelled or its deadline passes before the commit is attempted —
+// whether mid-fn or in the gap right after fn returns — the transaction is
+// rolled back (using a fresh, uncancelled context, since a send on the
+// already-done ctx would race requestContext's own cancellation check) and
+// ctx.Err() is returned, so the caller never mistakes a cancelled
+// transaction for a committed one.
+//
+// Cancellation during the commit request itself is different: the send may
+// have already reached the server before the connection was torn down, so
+// whether the OCC commit landed is unknown. That case surfaces as a
+// *TransactionAbortedError wrapping ctx.Err() instead of plain ctx.Err(),
+// so callers don't conflate "definitely rolled back" with "outcome
+// unknown". A commit that lands but loses the OCC race still comes back as
+// *TransactionConflictError either way.
+func (c *Conn) WithTransactionContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, err := c.BeginTxContext(ctx); err != nil {
+		return err
+	}
+	err := fn(ctx)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = c.RollbackTxContext(context.Background())
 		return err
 	}
-	if err := fn(); err != nil {
-		_ = c.RollbackTx()
+	if err := c.CommitTxContext(ctx); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return &TransactionAbortedError{Cause: err}
+		}
 		return err
 	}
-	return c.CommitTx()
+	return nil
+}
+
+// WithTransactionRetry is WithTransactionContext, re-run from the top (a
+// fresh BeginTx) whenever fn fails with an error that unwraps to
+// *TransactionConflictError — the signal that another transaction won the
+// OCC race on commit — honoring ctx.Done() between attempts. Any other
+// error, including *TransactionAbortedError, returns immediately without a
+// retry. Once policy's Next reports it's done, the last conflict is
+// returned wrapped with how many attempts were made.
+func (c *Conn) WithTransactionRetry(ctx context.Context, policy BackoffPolicy, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := c.WithTransactionContext(ctx, fn)
+		var conflict *TransactionConflictError
+		if err == nil || !errors.As(err, &conflict) {
+			return err
+		}
+		delay, ok := policy.Next(attempt)
+		if !ok {
+			return fmt.Errorf("oxidb: transaction still conflicting after %d attempts: %w", attempt+1, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 // ------------------------------------------------------------------
@@ -422,13 +1119,13 @@ func (c *Client) WithTransaction(fn func() error) error {
 // ------------------------------------------------------------------
 
 // CreateBucket creates a blob storage bucket.
-func (c *Client) CreateBucket(bucket string) error {
+func (c *Conn) CreateBucket(bucket string) error {
 	_, err := c.checked(map[string]any{"cmd": "create_bucket", "bucket": bucket})
 	return err
 }
 
 // ListBuckets lists all blob storage buckets.
-func (c *Client) ListBuckets() ([]string, error) {
+func (c *Conn) ListBuckets() ([]string, error) {
 	data, err := c.checked(map[string]any{"cmd": "list_buckets"})
 	if err != nil {
 		return nil, err
@@ -442,13 +1139,19 @@ func (c *Client) ListBuckets() ([]string, error) {
 }
 
 // DeleteBucket deletes a blob storage bucket.
-func (c *Client) DeleteBucket(bucket string) error {
+func (c *Conn) DeleteBucket(bucket string) error {
 	_, err := c.checked(map[string]any{"cmd": "delete_bucket", "bucket": bucket})
 	return err
 }
 
 // PutObject uploads a blob object. Data is base64-encoded automatically.
-func (c *Client) PutObject(bucket, key string, data []byte, contentType string, metadata map[string]string) (map[string]any, error) {
+func (c *Conn) PutObject(bucket, key string, data []byte, contentType string, metadata map[string]string) (map[string]any, error) {
+	return c.PutObjectContext(context.Background(), bucket, key, data, contentType, metadata)
+}
+
+// PutObjectContext is PutObject with a context for cancellation and
+// deadlines, so a large upload can be bounded by a per-call timeout.
+func (c *Conn) PutObjectContext(ctx context.Context, bucket, key string, data []byte, contentType string, metadata map[string]string) (map[string]any, error) {
 	payload := map[string]any{
 		"cmd":          "put_object",
 		"bucket":       bucket,
@@ -462,7 +1165,7 @@ func (c *Client) PutObject(bucket, key string, data []byte, contentType string,
 	if len(metadata) > 0 {
 		payload["metadata"] = metadata
 	}
-	result, err := c.checked(payload)
+	result, err := c.checkedContext(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -471,8 +1174,14 @@ func (c *Client) PutObject(bucket, key string, data []byte, contentType string,
 }
 
 // GetObject downloads a blob object. Returns (data, metadata).
-func (c *Client) GetObject(bucket, key string) ([]byte, map[string]any, error) {
-	result, err := c.checked(map[string]any{"cmd": "get_object", "bucket": bucket, "key": key})
+func (c *Conn) GetObject(bucket, key string) ([]byte, map[string]any, error) {
+	return c.GetObjectContext(context.Background(), bucket, key)
+}
+
+// GetObjectContext is GetObject with a context for cancellation and
+// deadlines, so a large download can be bounded by a per-call timeout.
+func (c *Conn) GetObjectContext(ctx context.Context, bucket, key string) ([]byte, map[string]any, error) {
+	result, err := c.checkedContext(ctx, map[string]any{"cmd": "get_object", "bucket": bucket, "key": key})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -487,7 +1196,7 @@ func (c *Client) GetObject(bucket, key string) ([]byte, map[string]any, error) {
 }
 
 // HeadObject gets blob object metadata without downloading content.
-func (c *Client) HeadObject(bucket, key string) (map[string]any, error) {
+func (c *Conn) HeadObject(bucket, key string) (map[string]any, error) {
 	data, err := c.checked(map[string]any{"cmd": "head_object", "bucket": bucket, "key": key})
 	if err != nil {
 		return nil, err
@@ -497,13 +1206,13 @@ func (c *Client) HeadObject(bucket, key string) (map[string]any, error) {
 }
 
 // DeleteObject deletes a blob object.
-func (c *Client) DeleteObject(bucket, key string) error {
+func (c *Conn) DeleteObject(bucket, key string) error {
 	_, err := c.checked(map[string]any{"cmd": "delete_object", "bucket": bucket, "key": key})
 	return err
 }
 
 // ListObjects lists objects in a bucket.
-func (c *Client) ListObjects(bucket string, prefix *string, limit *int) ([]map[string]any, error) {
+func (c *Conn) ListObjects(bucket string, prefix *string, limit *int) ([]map[string]any, error) {
 	payload := map[string]any{"cmd": "list_objects", "bucket": bucket}
 	if prefix != nil {
 		payload["prefix"] = *prefix
@@ -518,12 +1227,505 @@ func (c *Client) ListObjects(bucket string, prefix *string, limit *int) ([]map[s
 	return toMapSlice(data), nil
 }
 
+// DefaultBlobChunkSize is the chunk size PutBlob splits content into, and
+// the size GetBlob's reader fetches at a time — modeled after GridFS'
+// default 255 KiB chunk size, rounded up to 256 KiB. Unlike PutObject,
+// which base64-encodes an entire payload into one JSON command and so
+// must hold it fully in memory, PutBlob/GetBlob never hold more than one
+// chunk at a time, at the cost of one round trip per chunk.
+const DefaultBlobChunkSize = 256 * 1024
+
+// BlobMeta describes a blob stored via PutBlob.
+type BlobMeta struct {
+	ID          string
+	Name        string
+	Size        int64
+	ContentType string
+	ChunkSize   int
+	Metadata    map[string]any
+}
+
+// PutBlob streams r into chunked storage under name, returning the new
+// blob's ID. Chunks are uploaded as they're read, so content never sits
+// fully in memory regardless of its size.
+func (c *Conn) PutBlob(name string, r io.Reader, meta map[string]any) (string, error) {
+	return c.PutBlobContext(context.Background(), name, r, meta)
+}
+
+// PutBlobContext is PutBlob with a context for cancellation and deadlines,
+// checked once per chunk so a slow or stuck upload can be aborted midway.
+func (c *Conn) PutBlobContext(ctx context.Context, name string, r io.Reader, meta map[string]any) (string, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "put_blob_init", "name": name, "metadata": meta})
+	if err != nil {
+		return "", err
+	}
+	m, _ := data.(map[string]any)
+	id, _ := m["id"].(string)
+
+	buf := make([]byte, DefaultBlobChunkSize)
+	var size int64
+	n := 0
+	for {
+		read, rerr := io.ReadFull(r, buf)
+		if read > 0 {
+			_, err := c.checkedContext(ctx, map[string]any{
+				"cmd": "put_blob_chunk", "blob_id": id, "n": n,
+				"data": base64.StdEncoding.EncodeToString(buf[:read]),
+			})
+			if err != nil {
+				return "", err
+			}
+			size += int64(read)
+			n++
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return "", fmt.Errorf("oxidb: read blob content: %w", rerr)
+		}
+	}
+
+	if _, err := c.checkedContext(ctx, map[string]any{"cmd": "put_blob_commit", "blob_id": id, "size": size, "chunks": n}); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// GetBlob opens a streaming reader over a blob's content, alongside its
+// metadata. The reader fetches chunks from the server lazily as it's
+// read, so downloading a large blob doesn't require buffering it whole;
+// the caller must Close it.
+func (c *Conn) GetBlob(id string) (io.ReadCloser, BlobMeta, error) {
+	return c.GetBlobContext(context.Background(), id)
+}
+
+// GetBlobContext is GetBlob with a context for cancellation and deadlines,
+// also used by the returned reader's chunk fetches.
+func (c *Conn) GetBlobContext(ctx context.Context, id string) (io.ReadCloser, BlobMeta, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "get_blob_meta", "blob_id": id})
+	if err != nil {
+		return nil, BlobMeta{}, err
+	}
+	m, _ := data.(map[string]any)
+	meta := blobMetaFromWire(id, m)
+	return &blobReader{client: c, ctx: ctx, id: id}, meta, nil
+}
+
+// DeleteBlob deletes a blob's metadata document. The server is expected
+// to garbage-collect its now-orphaned chunks out of band rather than
+// deleting them inline with this call.
+func (c *Conn) DeleteBlob(id string) error {
+	_, err := c.checked(map[string]any{"cmd": "delete_blob", "blob_id": id})
+	return err
+}
+
+func blobMetaFromWire(id string, m map[string]any) BlobMeta {
+	name, _ := m["name"].(string)
+	size, _ := m["size"].(float64)
+	contentType, _ := m["contentType"].(string)
+	chunkSize, _ := m["chunkSize"].(float64)
+	metadata, _ := m["metadata"].(map[string]any)
+	return BlobMeta{ID: id, Name: name, Size: int64(size), ContentType: contentType, ChunkSize: int(chunkSize), Metadata: metadata}
+}
+
+// blobReader is the io.ReadCloser GetBlob returns. It fetches one chunk at
+// a time from the server's chunk store, keyed by blob ID and chunk
+// number, and hands out its bytes via Read before fetching the next.
+type blobReader struct {
+	client    *Conn
+	ctx       context.Context
+	id        string
+	n         int
+	pending   []byte
+	exhausted bool
+	closed    bool
+}
+
+func (br *blobReader) Read(p []byte) (int, error) {
+	if br.closed {
+		return 0, fmt.Errorf("oxidb: read from closed blob reader")
+	}
+	for len(br.pending) == 0 {
+		if br.exhausted {
+			return 0, io.EOF
+		}
+		data, err := br.client.checkedContext(br.ctx, map[string]any{"cmd": "get_blob_chunk", "blob_id": br.id, "n": br.n})
+		if err != nil {
+			return 0, err
+		}
+		m, _ := data.(map[string]any)
+		encoded, _ := m["data"].(string)
+		chunk, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return 0, fmt.Errorf("oxidb: decode blob chunk: %w", err)
+		}
+		last, _ := m["last"].(bool)
+		br.pending = chunk
+		br.exhausted = last
+		br.n++
+	}
+	n := copy(p, br.pending)
+	br.pending = br.pending[n:]
+	return n, nil
+}
+
+func (br *blobReader) Close() error {
+	br.closed = true
+	return nil
+}
+
+// ------------------------------------------------------------------
+// Multipart object upload/download (raw binary framing)
+// ------------------------------------------------------------------
+
+// DefaultMultipartPartSize is the part size PutObjectStream splits content
+// into, and the range size GetObjectStream's reader fetches at a time —
+// 8 MiB, matching S3's common minimum part size.
+const DefaultMultipartPartSize = 8 * 1024 * 1024
+
+// Part describes one uploaded part of a multipart upload, as returned by
+// UploadPart and passed to CompleteMultipart in ascending PartNumber order.
+type Part struct {
+	PartNumber int
+	ETag       string
+}
+
+// InitiateMultipart starts a multipart upload of an object, returning an
+// upload ID that UploadPart, CompleteMultipart, and AbortMultipart all key
+// off. Unlike PutObject, which base64-encodes an entire object into one
+// JSON command and so must hold it fully in memory, a multipart upload
+// never holds more than one part at a time on the wire.
+func (c *Conn) InitiateMultipart(bucket, key, contentType string, metadata map[string]string) (string, error) {
+	return c.InitiateMultipartContext(context.Background(), bucket, key, contentType, metadata)
+}
+
+// InitiateMultipartContext is InitiateMultipart with a context for
+// cancellation and deadlines.
+func (c *Conn) InitiateMultipartContext(ctx context.Context, bucket, key, contentType string, metadata map[string]string) (string, error) {
+	payload := map[string]any{"cmd": "multipart_init", "bucket": bucket, "key": key, "content_type": contentType}
+	if contentType == "" {
+		payload["content_type"] = "application/octet-stream"
+	}
+	if len(metadata) > 0 {
+		payload["metadata"] = metadata
+	}
+	data, err := c.checkedContext(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	m, _ := data.(map[string]any)
+	id, _ := m["uploadId"].(string)
+	return id, nil
+}
+
+// UploadPart uploads part partNum (1-based, matching S3's convention),
+// reading exactly size bytes from r. The part's bytes are sent as raw
+// binary frames directly after the multipart_put_part JSON header rather
+// than base64-encoded into it, avoiding both the encoding overhead and
+// holding the whole part in memory as a string. Returns the part's ETag
+// (an MD5 digest of its bytes); CompleteMultipart compares these against
+// what the server received to catch a part that arrived corrupted or
+// reordered in transit.
+func (c *Conn) UploadPart(uploadID string, partNum int, r io.Reader, size int64) (string, error) {
+	return c.UploadPartContext(context.Background(), uploadID, partNum, r, size)
+}
+
+// UploadPartContext is UploadPart with a context for cancellation and
+// deadlines. The whole part upload is raced against ctx.Done(), so a slow
+// or stuck upload can be aborted midway; cancelling closes c's connection.
+func (c *Conn) UploadPartContext(ctx context.Context, uploadID string, partNum int, r io.Reader, size int64) (string, error) {
+	type result struct {
+		etag string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		etag, err := c.uploadPart(ctx, uploadID, partNum, r, size)
+		done <- result{etag: etag, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.etag, res.err
+	case <-ctx.Done():
+		c.conn.Close()
+		<-done // wait for the goroutine so it has stopped using conn before returning
+		return "", ctx.Err()
+	}
+}
+
+// uploadPart does UploadPartContext's actual work: it's run on its own
+// goroutine, raced against ctx.Done() the same way requestContext races its
+// send/recv, since sendRaw/io.ReadFull below block on the connection
+// across many frames and a single ctx.Err() check per frame wouldn't
+// unblock a stall mid-frame. Its response is read from respCh, like
+// requestContext's, since the background reader owns the socket's reads.
+func (c *Conn) uploadPart(ctx context.Context, uploadID string, partNum int, r io.Reader, size int64) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Time{})
+	}
+	defer c.conn.SetDeadline(time.Time{})
+
+	header, err := json.Marshal(map[string]any{"cmd": "multipart_put_part", "upload_id": uploadID, "part": partNum, "size": size})
+	if err != nil {
+		return "", fmt.Errorf("oxidb: marshal request: %w", err)
+	}
+	if err := c.sendRaw(header); err != nil {
+		return "", fmt.Errorf("oxidb: send: %w", err)
+	}
+
+	sum := md5.New()
+	buf := make([]byte, DefaultBlobChunkSize)
+	remaining := size
+	for remaining > 0 {
+		n := int64(len(buf))
+		if remaining < n {
+			n = remaining
+		}
+		read, rerr := io.ReadFull(r, buf[:n])
+		if read > 0 {
+			if err := c.sendRaw(buf[:read]); err != nil {
+				return "", fmt.Errorf("oxidb: send part frame: %w", err)
+			}
+			sum.Write(buf[:read])
+			remaining -= int64(read)
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			return "", fmt.Errorf("oxidb: part %d: read %d of %d bytes: %w", partNum, size-remaining, size, io.ErrUnexpectedEOF)
+		}
+		if rerr != nil {
+			return "", fmt.Errorf("oxidb: read part content: %w", rerr)
+		}
+	}
+
+	c.startReader()
+	var resp map[string]any
+	select {
+	case resp = <-c.respCh:
+	case <-c.readDone:
+		return "", c.readErr
+	}
+	ok, _ := resp["ok"].(bool)
+	if !ok {
+		return "", parseError(resp["error"])
+	}
+	etag := hex.EncodeToString(sum.Sum(nil))
+	m, _ := resp["data"].(map[string]any)
+	if serverETag, _ := m["etag"].(string); serverETag != "" && serverETag != etag {
+		return "", fmt.Errorf("oxidb: part %d: etag mismatch, corrupted in transit (client=%s, server=%s)", partNum, etag, serverETag)
+	}
+	return etag, nil
+}
+
+// CompleteMultipart finalizes a multipart upload, assembling parts into the
+// stored object in ascending PartNumber order. Each Part's ETag must match
+// what UploadPart returned for it, so the server can refuse to assemble an
+// object from a part it never received correctly.
+func (c *Conn) CompleteMultipart(uploadID string, parts []Part) (map[string]any, error) {
+	return c.CompleteMultipartContext(context.Background(), uploadID, parts)
+}
+
+// CompleteMultipartContext is CompleteMultipart with a context for
+// cancellation and deadlines.
+func (c *Conn) CompleteMultipartContext(ctx context.Context, uploadID string, parts []Part) (map[string]any, error) {
+	wireParts := make([]map[string]any, len(parts))
+	for i, p := range parts {
+		wireParts[i] = map[string]any{"partNumber": p.PartNumber, "etag": p.ETag}
+	}
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "multipart_complete", "upload_id": uploadID, "parts": wireParts})
+	if err != nil {
+		return nil, err
+	}
+	m, _ := data.(map[string]any)
+	return m, nil
+}
+
+// AbortMultipart cancels a multipart upload, releasing any parts already
+// uploaded for it. Safe to call after a partial failure to avoid leaving
+// orphaned parts on the server.
+func (c *Conn) AbortMultipart(uploadID string) error {
+	return c.AbortMultipartContext(context.Background(), uploadID)
+}
+
+// AbortMultipartContext is AbortMultipart with a context for cancellation
+// and deadlines.
+func (c *Conn) AbortMultipartContext(ctx context.Context, uploadID string) error {
+	_, err := c.checkedContext(ctx, map[string]any{"cmd": "multipart_abort", "upload_id": uploadID})
+	return err
+}
+
+// PutObjectStream uploads r as a multipart object, splitting it into parts
+// of partSize bytes (DefaultMultipartPartSize if partSize <= 0), so a large
+// upload never holds more than one part in memory. Aborts the multipart
+// upload on any error; a failed part is not retried. Returns
+// CompleteMultipart's result.
+func (c *Conn) PutObjectStream(bucket, key string, r io.Reader, contentType string, metadata map[string]string, partSize int64) (map[string]any, error) {
+	return c.PutObjectStreamContext(context.Background(), bucket, key, r, contentType, metadata, partSize)
+}
+
+// PutObjectStreamContext is PutObjectStream with a context for cancellation
+// and deadlines on the initiate, each part upload, and the completion.
+func (c *Conn) PutObjectStreamContext(ctx context.Context, bucket, key string, r io.Reader, contentType string, metadata map[string]string, partSize int64) (map[string]any, error) {
+	if partSize <= 0 {
+		partSize = DefaultMultipartPartSize
+	}
+	uploadID, err := c.InitiateMultipartContext(ctx, bucket, key, contentType, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []Part
+	for partNum := 1; ; partNum++ {
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, io.LimitReader(r, partSize))
+		if err != nil {
+			_ = c.AbortMultipartContext(ctx, uploadID)
+			return nil, fmt.Errorf("oxidb: read part %d: %w", partNum, err)
+		}
+		if n == 0 {
+			break
+		}
+		etag, err := c.UploadPartContext(ctx, uploadID, partNum, &buf, n)
+		if err != nil {
+			_ = c.AbortMultipartContext(ctx, uploadID)
+			return nil, err
+		}
+		parts = append(parts, Part{PartNumber: partNum, ETag: etag})
+		if n < partSize {
+			break
+		}
+	}
+
+	return c.CompleteMultipartContext(ctx, uploadID, parts)
+}
+
+// GetObjectStream opens a streaming reader over an object's content,
+// alongside its metadata, fetching the body as ranged binary frames rather
+// than loading it whole into one base64-encoded response (see GetObject),
+// so downloading a large object doesn't require buffering it whole. The
+// caller must Close it.
+func (c *Conn) GetObjectStream(bucket, key string) (io.ReadCloser, map[string]any, error) {
+	return c.GetObjectStreamContext(context.Background(), bucket, key)
+}
+
+// GetObjectStreamContext is GetObjectStream with a context for cancellation
+// and deadlines, also used by the returned reader's range fetches.
+func (c *Conn) GetObjectStreamContext(ctx context.Context, bucket, key string) (io.ReadCloser, map[string]any, error) {
+	data, err := c.checkedContext(ctx, map[string]any{"cmd": "head_object", "bucket": bucket, "key": key})
+	if err != nil {
+		return nil, nil, err
+	}
+	m, _ := data.(map[string]any)
+	return &objectStreamReader{client: c, ctx: ctx, bucket: bucket, key: key}, m, nil
+}
+
+// objectStreamReader is the io.ReadCloser GetObjectStream returns. It
+// fetches the object's content as ranged binary frames instead of one
+// base64 JSON response, advancing its offset as it's read. Where blobReader
+// stops on the server's "last" flag, this reader trusts a short read (fewer
+// bytes than requested) rather than a size cached upfront from head_object
+// to detect the end, so it isn't thrown off
+// by the object being overwritten between GetObjectStream and a later Read.
+type objectStreamReader struct {
+	client  *Conn
+	ctx     context.Context
+	bucket  string
+	key     string
+	offset  int64
+	pending []byte
+	atEnd   bool
+	closed  bool
+}
+
+func (or *objectStreamReader) Read(p []byte) (int, error) {
+	if or.closed {
+		return 0, fmt.Errorf("oxidb: read from closed object stream")
+	}
+	for len(or.pending) == 0 {
+		if or.atEnd {
+			return 0, io.EOF
+		}
+		chunk, err := or.client.getObjectRange(or.ctx, or.bucket, or.key, or.offset, DefaultBlobChunkSize)
+		if err != nil {
+			return 0, err
+		}
+		if len(chunk) < DefaultBlobChunkSize {
+			or.atEnd = true
+		}
+		if len(chunk) == 0 {
+			return 0, io.EOF
+		}
+		or.pending = chunk
+		or.offset += int64(len(chunk))
+	}
+	n := copy(p, or.pending)
+	or.pending = or.pending[n:]
+	return n, nil
+}
+
+func (or *objectStreamReader) Close() error {
+	or.closed = true
+	return nil
+}
+
+// getObjectRange fetches [offset, offset+length) of an object as a raw
+// binary frame: the JSON range request is sent via sendRaw, then the
+// response is read as a single length-prefixed frame of raw bytes rather
+// than a checked()-style JSON envelope, avoiding a base64 round trip for
+// the body.
+func (c *Conn) getObjectRange(ctx context.Context, bucket, key string, offset, length int64) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := c.fetchObjectRange(bucket, key, offset, length)
+		done <- result{data: data, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.data, res.err
+	case <-ctx.Done():
+		c.conn.Close()
+		<-done // wait for the goroutine so it has stopped using conn before returning
+		return nil, ctx.Err()
+	}
+}
+
+// fetchObjectRange does getObjectRange's actual work, run on its own
+// goroutine and raced against ctx.Done() the same way requestContext races
+// its send/recv.
+func (c *Conn) fetchObjectRange(bucket, key string, offset, length int64) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header, err := json.Marshal(map[string]any{
+		"cmd": "get_object_range", "bucket": bucket, "key": key,
+		"offset": offset, "length": length,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oxidb: marshal request: %w", err)
+	}
+	if err := c.sendRaw(header); err != nil {
+		return nil, fmt.Errorf("oxidb: send: %w", err)
+	}
+	return c.recvRaw()
+}
+
 // ------------------------------------------------------------------
 // Full-text search
 // ------------------------------------------------------------------
 
 // Search performs full-text search across blobs.
-func (c *Client) Search(query string, bucket *string, limit int) ([]map[string]any, error) {
+func (c *Conn) Search(query string, bucket *string, limit int) ([]map[string]any, error) {
 	payload := map[string]any{"cmd": "search", "query": query, "limit": limit}
 	if bucket != nil {
 		payload["bucket"] = *bucket
@@ -541,7 +1743,7 @@ func (c *Client) Search(query string, bucket *string, limit int) ([]map[string]a
 
 // SQL executes a SQL query. Supports SELECT, INSERT, UPDATE, DELETE,
 // CREATE/DROP TABLE, CREATE INDEX, and SHOW TABLES.
-func (c *Client) SQL(query string) (any, error) {
+func (c *Conn) SQL(query string) (any, error) {
 	return c.checked(map[string]any{"cmd": "sql", "query": query})
 }
 
@@ -551,7 +1753,7 @@ func (c *Client) SQL(query string) (any, error) {
 
 // CreateSchedule creates or replaces a named schedule.
 // Pass a cron expression (e.g. "0 3 * * *") or an interval (e.g. "5m").
-func (c *Client) CreateSchedule(name, procedure string, opts map[string]any) (map[string]any, error) {
+func (c *Conn) CreateSchedule(name, procedure string, opts map[string]any) (map[string]any, error) {
 	payload := map[string]any{"cmd": "create_schedule", "name": name, "procedure": procedure}
 	for k, v := range opts {
 		payload[k] = v
@@ -565,7 +1767,7 @@ func (c *Client) CreateSchedule(name, procedure string, opts map[string]any) (ma
 }
 
 // ListSchedules lists all schedules with status.
-func (c *Client) ListSchedules() ([]map[string]any, error) {
+func (c *Conn) ListSchedules() ([]map[string]any, error) {
 	data, err := c.checked(map[string]any{"cmd": "list_schedules"})
 	if err != nil {
 		return nil, err
@@ -574,7 +1776,7 @@ func (c *Client) ListSchedules() ([]map[string]any, error) {
 }
 
 // GetSchedule gets a schedule by name.
-func (c *Client) GetSchedule(name string) (map[string]any, error) {
+func (c *Conn) GetSchedule(name string) (map[string]any, error) {
 	data, err := c.checked(map[string]any{"cmd": "get_schedule", "name": name})
 	if err != nil {
 		return nil, err
@@ -584,19 +1786,19 @@ func (c *Client) GetSchedule(name string) (map[string]any, error) {
 }
 
 // DeleteSchedule deletes a schedule.
-func (c *Client) DeleteSchedule(name string) error {
+func (c *Conn) DeleteSchedule(name string) error {
 	_, err := c.checked(map[string]any{"cmd": "delete_schedule", "name": name})
 	return err
 }
 
 // EnableSchedule enables a paused schedule.
-func (c *Client) EnableSchedule(name string) error {
+func (c *Conn) EnableSchedule(name string) error {
 	_, err := c.checked(map[string]any{"cmd": "enable_schedule", "name": name})
 	return err
 }
 
 // DisableSchedule pauses a schedule.
-func (c *Client) DisableSchedule(name string) error {
+func (c *Conn) DisableSchedule(name string) error {
 	_, err := c.checked(map[string]any{"cmd": "disable_schedule", "name": name})
 	return err
 }
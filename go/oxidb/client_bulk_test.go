@@ -1,6 +1,7 @@
 package oxidb_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -241,6 +242,189 @@ func setupQueryData(t *testing.T) *oxidb.Client {
 	return c
 }
 
+// ------------------------------------------------------------------
+// BulkWrite tests
+// ------------------------------------------------------------------
+
+func TestBulkWrite_InsertOnly(t *testing.T) {
+	c := setupBulk(t)
+	defer teardownBulk(t, c)
+
+	ops := []oxidb.BulkOp{
+		{Type: oxidb.BulkInsert, ClientID: "0", Doc: map[string]any{"idx": 0, "name": "a"}},
+		{Type: oxidb.BulkInsert, ClientID: "1", Doc: map[string]any{"idx": 1, "name": "b"}},
+	}
+	result, err := c.BulkWrite(bulkCollection, ops, nil)
+	if err != nil {
+		t.Fatalf("bulk_write: %v", err)
+	}
+	if len(result.InsertedIDs) != 2 {
+		t.Fatalf("expected 2 inserted ids, got %d", len(result.InsertedIDs))
+	}
+	if len(result.WriteErrors) != 0 {
+		t.Fatalf("expected no write errors, got %v", result.WriteErrors)
+	}
+}
+
+func TestBulkWrite_Mixed(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	ops := []oxidb.BulkOp{
+		{Type: oxidb.BulkUpdate, ClientID: "u", Query: map[string]any{"idx": 0}, Update: map[string]any{"$set": map[string]any{"name": "updated_user"}}},
+		{Type: oxidb.BulkUpsert, ClientID: "up", Query: map[string]any{"idx": 9999}, Update: map[string]any{"$set": map[string]any{"idx": 9999, "name": "new_user"}}},
+		{Type: oxidb.BulkDelete, ClientID: "d", Query: map[string]any{"idx": 1}},
+	}
+	result, err := c.BulkWrite(bulkCollection, ops, &oxidb.BulkOptions{Ordered: true})
+	if err != nil {
+		t.Fatalf("bulk_write: %v", err)
+	}
+	if result.MatchedCount != 1 {
+		t.Fatalf("expected 1 matched (the update), got %d", result.MatchedCount)
+	}
+	if len(result.UpsertedIDs) != 1 {
+		t.Fatalf("expected 1 upserted id, got %d", len(result.UpsertedIDs))
+	}
+	if result.DeletedCount != 1 {
+		t.Fatalf("expected 1 deleted, got %d", result.DeletedCount)
+	}
+
+	doc, _ := c.FindOne(bulkCollection, map[string]any{"idx": 0})
+	if name, _ := doc["name"].(string); name != "updated_user" {
+		t.Fatalf("expected updated_user, got %s", name)
+	}
+	if deleted, _ := c.FindOne(bulkCollection, map[string]any{"idx": 1}); deleted != nil {
+		t.Fatalf("expected idx=1 to be deleted")
+	}
+}
+
+func TestBulkWrite_PartialFailureUnordered(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	ops := []oxidb.BulkOp{
+		{Type: oxidb.BulkInsert, ClientID: "dup", Doc: map[string]any{"idx": 0, "name": "dup"}},
+		{Type: oxidb.BulkUpdate, ClientID: "ok", Query: map[string]any{"idx": 2}, Update: map[string]any{"$set": map[string]any{"name": "fixed"}}},
+	}
+	result, err := c.BulkWrite(bulkCollection, ops, &oxidb.BulkOptions{Ordered: false})
+	if err != nil {
+		t.Fatalf("bulk_write: %v", err)
+	}
+	if result.MatchedCount != 1 {
+		t.Fatalf("expected the second op to still apply unordered, got matched=%d", result.MatchedCount)
+	}
+}
+
+// ------------------------------------------------------------------
+// BulkRequest tests
+// ------------------------------------------------------------------
+
+func TestBulkRequest_CrossCollection(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+	const ordersCollection = "go_bulk_test_orders"
+	defer c.DropCollection(ordersCollection)
+
+	resp, err := c.NewBulkRequest().
+		Insert(ordersCollection, map[string]any{"idx": 0, "total": 10}).
+		UpdateOne(bulkCollection, map[string]any{"idx": 0}, map[string]any{"$set": map[string]any{"name": "cross_updated"}}).
+		Delete(bulkCollection, map[string]any{"idx": 1}).
+		Do()
+	if err != nil {
+		t.Fatalf("bulk request: %v", err)
+	}
+	if resp.HasErrors() {
+		t.Fatalf("expected no errors, got %+v", resp.Items)
+	}
+	if len(resp.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(resp.Items))
+	}
+	if resp.Items[0].Collection != ordersCollection {
+		t.Fatalf("expected item 0 tagged %q, got %q", ordersCollection, resp.Items[0].Collection)
+	}
+
+	doc, _ := c.FindOne(bulkCollection, map[string]any{"idx": 0})
+	if name, _ := doc["name"].(string); name != "cross_updated" {
+		t.Fatalf("expected cross_updated, got %s", name)
+	}
+	if deleted, _ := c.FindOne(bulkCollection, map[string]any{"idx": 1}); deleted != nil {
+		t.Fatalf("expected idx=1 to be deleted")
+	}
+	count, _ := c.Count(ordersCollection, map[string]any{})
+	if count != 1 {
+		t.Fatalf("expected 1 order, got %d", count)
+	}
+}
+
+func TestBulkRequest_FlushEvery(t *testing.T) {
+	c := setupBulk(t)
+	defer teardownBulk(t, c)
+
+	req := c.NewBulkRequest().FlushEvery(10)
+	for i := 0; i < 25; i++ {
+		req.Insert(bulkCollection, map[string]any{"idx": i})
+	}
+	resp, err := req.Do()
+	if err != nil {
+		t.Fatalf("bulk request: %v", err)
+	}
+	if len(resp.Items) != 25 {
+		t.Fatalf("expected 25 items across flushes, got %d", len(resp.Items))
+	}
+	count, _ := c.Count(bulkCollection, map[string]any{})
+	if count != 25 {
+		t.Fatalf("expected 25 docs, got %d", count)
+	}
+}
+
+func TestBulkRequest_FailedIterator(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	resp, err := c.NewBulkRequest().
+		Insert(bulkCollection, map[string]any{"idx": 0, "name": "dup"}).
+		UpdateOne(bulkCollection, map[string]any{"idx": 2}, map[string]any{"$set": map[string]any{"name": "fixed"}}).
+		Do()
+	if err != nil {
+		t.Fatalf("bulk request: %v", err)
+	}
+	if !resp.HasErrors() {
+		t.Fatal("expected the duplicate insert to fail")
+	}
+
+	failed := resp.Failed()
+	n := 0
+	for failed.Next() {
+		if failed.Item().Index != 0 {
+			t.Fatalf("expected only item 0 to fail, got index %d", failed.Item().Index)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("expected exactly 1 failed item, got %d", n)
+	}
+}
+
+func TestBulkRequest_WithTransaction(t *testing.T) {
+	c := setupBulk(t)
+	defer teardownBulk(t, c)
+
+	err := c.WithTransaction(func() error {
+		_, err := c.NewBulkRequest().
+			Insert(bulkCollection, map[string]any{"idx": 0}).
+			Insert(bulkCollection, map[string]any{"idx": 1}).
+			Do()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("with transaction: %v", err)
+	}
+	count, _ := c.Count(bulkCollection, map[string]any{})
+	if count != 2 {
+		t.Fatalf("expected 2 docs committed, got %d", count)
+	}
+}
+
 func TestQuery_ExactMatch(t *testing.T) {
 	c := setupQueryData(t)
 	defer teardownBulk(t, c)
@@ -280,6 +464,44 @@ func TestQuery_RangeFilter(t *testing.T) {
 	}
 }
 
+func TestQuery_DateRangeFilter(t *testing.T) {
+	c := setupBulk(t)
+	defer teardownBulk(t, c)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]map[string]any, 30)
+	for i := range docs {
+		docs[i] = map[string]any{
+			"idx":       i,
+			"createdAt": oxidb.Date(base.AddDate(0, 0, i)),
+		}
+	}
+	if _, err := c.InsertMany(bulkCollection, docs); err != nil {
+		t.Fatalf("setup data: %v", err)
+	}
+
+	from := oxidb.Date(base.AddDate(0, 0, 10))
+	to := oxidb.Date(base.AddDate(0, 0, 20))
+	found, err := c.Find(bulkCollection, map[string]any{
+		"createdAt": map[string]any{"$gte": from, "$lt": to},
+	}, nil)
+	if err != nil {
+		t.Fatalf("find date range: %v", err)
+	}
+	if len(found) != 10 {
+		t.Fatalf("expected 10 docs in range, got %d", len(found))
+	}
+	for _, doc := range found {
+		ts, ok := oxidb.ParseDate(doc["createdAt"])
+		if !ok {
+			t.Fatalf("createdAt did not decode as a date: %v", doc["createdAt"])
+		}
+		if ts.Before(from.T) || !ts.Before(to.T) {
+			t.Fatalf("doc outside range: createdAt=%v", ts)
+		}
+	}
+}
+
 func TestQuery_BooleanFilter(t *testing.T) {
 	c := setupQueryData(t)
 	defer teardownBulk(t, c)
@@ -520,7 +742,7 @@ func TestQuery_TextSearch(t *testing.T) {
 		t.Fatalf("insert: %v", err)
 	}
 
-	if err := c.CreateTextIndex(bulkCollection, []string{"title", "body"}); err != nil {
+	if err := c.CreateTextIndex(bulkCollection, []string{"title", "body"}, nil); err != nil {
 		t.Fatalf("create_text_index: %v", err)
 	}
 
@@ -533,6 +755,57 @@ func TestQuery_TextSearch(t *testing.T) {
 	}
 }
 
+func TestQuery_TextSearch_StemmedAnalyzer(t *testing.T) {
+	c := setupBulk(t)
+	defer teardownBulk(t, c)
+
+	docs := []map[string]any{
+		{"title": "Learning Go programs", "body": "Our programs run on small devices"},
+		{"title": "Rust systems", "body": "Rust guarantees memory safety"},
+	}
+	_, err := c.InsertMany(bulkCollection, docs)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	err = c.CreateTextIndex(bulkCollection, []string{"title", "body"}, &oxidb.TextIndexOptions{
+		Language:      "en",
+		Stemmer:       "snowball",
+		CaseFold:      true,
+		DiacriticFold: true,
+	})
+	if err != nil {
+		t.Fatalf("create_text_index: %v", err)
+	}
+
+	// "programming" should stem down to the same root as "programs", so it
+	// should match the first document even though the exact word never
+	// appears in it.
+	results, err := c.TextSearch(bulkCollection, "programming", 10)
+	if err != nil {
+		t.Fatalf("text_search: %v", err)
+	}
+	if len(results) < 1 {
+		t.Fatal("expected the stemmed query to match \"programs\"")
+	}
+
+	indexes, err := c.ListIndexes(bulkCollection)
+	if err != nil {
+		t.Fatalf("list_indexes: %v", err)
+	}
+	found := false
+	for _, idx := range indexes {
+		if analyzer, ok := idx["analyzer"].(map[string]any); ok {
+			if lang, _ := analyzer["language"].(string); lang == "en" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected the text index's analyzer metadata to be persisted and returned by ListIndexes")
+	}
+}
+
 func TestQuery_ListAndDropIndex(t *testing.T) {
 	c := setupBulk(t)
 	defer teardownBulk(t, c)
@@ -621,3 +894,119 @@ func TestQuery_CompactAfterBulkDelete(t *testing.T) {
 		t.Fatalf("expected 100 after compact, got %d", count)
 	}
 }
+
+// ------------------------------------------------------------------
+// Streaming find
+// ------------------------------------------------------------------
+
+func TestFindStream(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	limit := 37
+	ch, cancel, err := c.FindStream(bulkCollection, map[string]any{}, &oxidb.FindOptions{
+		Sort:  map[string]any{"idx": 1},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("find_stream: %v", err)
+	}
+	defer cancel()
+
+	seen := 0
+	for res := range ch {
+		if res.Err != nil {
+			t.Fatalf("find_stream result: %v", res.Err)
+		}
+		idx, _ := res.Doc["idx"].(float64)
+		if int(idx) != seen {
+			t.Fatalf("expected idx=%d, got %v", seen, idx)
+		}
+		seen++
+	}
+	if seen != limit {
+		t.Fatalf("expected %d streamed docs, got %d", limit, seen)
+	}
+}
+
+func TestFindStream_Cancel(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	ch, cancel, err := c.FindStream(bulkCollection, map[string]any{}, nil)
+	if err != nil {
+		t.Fatalf("find_stream: %v", err)
+	}
+
+	// Drain a single item then cancel — the goroutine must stop without
+	// leaking or blocking on a full channel.
+	res, ok := <-ch
+	if !ok {
+		t.Fatal("expected at least one result before cancel")
+	}
+	if res.Err != nil {
+		t.Fatalf("find_stream result: %v", res.Err)
+	}
+	cancel()
+}
+
+func TestFindCursor_Basic(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	limit := 37
+	cur, err := c.FindCursor(bulkCollection, map[string]any{}, &oxidb.FindOptions{
+		Sort:  map[string]any{"idx": 1},
+		Limit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("find_cursor: %v", err)
+	}
+	defer cur.Close()
+
+	seen := 0
+	for cur.Next(context.Background()) {
+		var doc map[string]any
+		if err := cur.Decode(&doc); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		idx, _ := doc["idx"].(float64)
+		if int(idx) != seen {
+			t.Fatalf("expected idx=%d, got %v", seen, idx)
+		}
+		seen++
+	}
+	if err := cur.Err(); err != nil {
+		t.Fatalf("cursor error: %v", err)
+	}
+	if seen != limit {
+		t.Fatalf("expected %d docs, got %d", limit, seen)
+	}
+}
+
+func TestFindCursor_MultipleBatches(t *testing.T) {
+	c := setupQueryData(t)
+	defer teardownBulk(t, c)
+
+	cur, err := c.FindCursor(bulkCollection, map[string]any{}, &oxidb.FindOptions{
+		Sort: map[string]any{"idx": 1},
+	})
+	if err != nil {
+		t.Fatalf("find_cursor: %v", err)
+	}
+	defer cur.Close()
+
+	seen := 0
+	for cur.Next(context.Background()) {
+		seen++
+	}
+	if err := cur.Err(); err != nil {
+		t.Fatalf("cursor error: %v", err)
+	}
+	if seen != 500 {
+		t.Fatalf("expected 500 docs across batches, got %d", seen)
+	}
+	if err := cur.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
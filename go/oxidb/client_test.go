@@ -1,9 +1,15 @@
 package oxidb_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/parisxmas/OxiDB/go/oxidb"
 )
@@ -231,6 +237,37 @@ func TestTransaction(t *testing.T) {
 	}
 }
 
+func TestTransactionRetry_NonConflictErrorNoRetry(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+
+	attempts := 0
+	boom := errors.New("boom")
+	err := c.WithTransactionRetry(context.Background(), oxidb.ConstantBackoff{Delay: time.Millisecond, MaxRetries: 5}, func(ctx context.Context) error {
+		attempts++
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom to pass through unwrapped, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt for a non-conflict error, got %d", attempts)
+	}
+}
+
+func TestTransactionRetry_Success(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+
+	err := c.WithTransactionRetry(context.Background(), oxidb.ConstantBackoff{Delay: time.Millisecond, MaxRetries: 5}, func(ctx context.Context) error {
+		_, err := c.Insert("go_tx_retry", map[string]any{"ok": true})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("transaction retry: %v", err)
+	}
+}
+
 func TestBlobStorage(t *testing.T) {
 	c := getClient(t)
 	defer c.Close()
@@ -287,6 +324,42 @@ func TestBlobStorage(t *testing.T) {
 	}
 }
 
+func TestBlobChunked(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+
+	content := []byte(strings.Repeat("go-blob-content", 50000)) // spans multiple chunks
+	id, err := c.PutBlob("big.bin", bytes.NewReader(content), map[string]any{"source": "test"})
+	if err != nil {
+		t.Fatalf("put_blob: %v", err)
+	}
+
+	r, meta, err := c.GetBlob(id)
+	if err != nil {
+		t.Fatalf("get_blob: %v", err)
+	}
+	defer r.Close()
+
+	if meta.Name != "big.bin" {
+		t.Fatalf("expected name big.bin, got %q", meta.Name)
+	}
+	if meta.Size != int64(len(content)) {
+		t.Fatalf("expected size %d, got %d", len(content), meta.Size)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read blob: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatal("downloaded blob content doesn't match what was uploaded")
+	}
+
+	if err := c.DeleteBlob(id); err != nil {
+		t.Fatalf("delete_blob: %v", err)
+	}
+}
+
 func TestSearch(t *testing.T) {
 	c := getClient(t)
 	defer c.Close()
@@ -0,0 +1,133 @@
+package oxidb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+const watchCollection = "go_watch_test"
+
+func TestWatch_ReceivesInsert(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+	_ = c.DropCollection(watchCollection)
+	defer c.DropCollection(watchCollection)
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	events, cancel, err := c.Watch(ctx, oxidb.WatchOptions{Collection: watchCollection})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer cancel()
+
+	if _, err := c.Insert(watchCollection, map[string]any{"name": "Dana"}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != "insert" {
+			t.Fatalf("expected insert event, got %q", ev.Type)
+		}
+		if ev.FullDocument["name"] != "Dana" {
+			t.Fatalf("expected Dana, got %v", ev.FullDocument["name"])
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("timed out waiting for change event")
+	}
+}
+
+func TestWatch_FilterExcludesNonMatching(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+	_ = c.DropCollection(watchCollection)
+	defer c.DropCollection(watchCollection)
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	events, cancel, err := c.Watch(ctx, oxidb.WatchOptions{
+		Collection: watchCollection,
+		Filter:     map[string]any{"status": "pending"},
+	})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer cancel()
+
+	if _, err := c.Insert(watchCollection, map[string]any{"status": "done"}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := c.Insert(watchCollection, map[string]any{"status": "pending"}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.FullDocument["status"] != "pending" {
+			t.Fatalf("expected only the pending doc to match the filter, got %v", ev.FullDocument["status"])
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("timed out waiting for change event")
+	}
+}
+
+func TestWatch_CancelStopsDelivery(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+	_ = c.DropCollection(watchCollection)
+	defer c.DropCollection(watchCollection)
+
+	events, cancel, err := c.Watch(context.Background(), oxidb.WatchOptions{Collection: watchCollection})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	cancel()
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected events channel to close after cancel")
+	}
+
+	// c must still be usable for ordinary requests after a Watch is
+	// cancelled.
+	if _, err := c.Ping(); err != nil {
+		t.Fatalf("ping after watch cancel: %v", err)
+	}
+}
+
+func TestWatchBucket_ReceivesPut(t *testing.T) {
+	c := getClient(t)
+	defer c.Close()
+	_ = c.DeleteBucket("go-watch-bucket")
+	if err := c.CreateBucket("go-watch-bucket"); err != nil {
+		t.Fatalf("create_bucket: %v", err)
+	}
+	defer c.DeleteBucket("go-watch-bucket")
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	events, cancel, err := c.WatchBucket(ctx, oxidb.WatchBucketOptions{Bucket: "go-watch-bucket"})
+	if err != nil {
+		t.Fatalf("watch bucket: %v", err)
+	}
+	defer cancel()
+
+	if _, err := c.PutObject("go-watch-bucket", "note.txt", []byte("hi"), "text/plain", nil); err != nil {
+		t.Fatalf("put_object: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != "insert" && ev.Type != "update" {
+			t.Fatalf("expected a put notification, got %q", ev.Type)
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("timed out waiting for bucket change event")
+	}
+}
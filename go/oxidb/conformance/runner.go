@@ -0,0 +1,201 @@
+package conformance
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+// Result is the outcome of running a single Vector.
+type Result struct {
+	Vector   Vector
+	Passed   bool
+	Error    error
+	Got      []map[string]any
+	Duration time.Duration
+}
+
+// Report summarizes a full conformance run: per-vector results plus a
+// coverage matrix of which feature tags passed vs. failed.
+type Report struct {
+	Results  []Result
+	Coverage map[string]Coverage
+}
+
+// Coverage counts pass/fail outcomes across every vector tagged with a
+// given feature.
+type Coverage struct {
+	Passed int
+	Failed int
+}
+
+// Passed reports whether every vector in the report passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders a per-vector pass/fail report followed by a coverage
+// matrix of feature tags, suitable for printing from a CLI or CI step.
+func (r Report) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "[%s] %-30s %v\n", status, res.Vector.Name, res.Duration.Round(time.Microsecond))
+		if res.Error != nil {
+			fmt.Fprintf(&b, "       %v\n", res.Error)
+		}
+	}
+
+	features := make([]string, 0, len(r.Coverage))
+	for f := range r.Coverage {
+		features = append(features, f)
+	}
+	sort.Strings(features)
+
+	b.WriteString("\ncoverage:\n")
+	for _, f := range features {
+		cov := r.Coverage[f]
+		fmt.Fprintf(&b, "  %-20s passed=%d failed=%d\n", f, cov.Passed, cov.Failed)
+	}
+	return b.String()
+}
+
+// collectionPrefix namespaces the scratch collections Run creates so a
+// conformance run never collides with real data on a shared backend.
+const collectionPrefix = "_conformance_"
+
+// Run seeds a fresh collection per vector, executes its Query or Pipeline,
+// normalizes both the actual and expected results, and diffs them. Each
+// vector's scratch collection is dropped afterward regardless of outcome.
+func Run(c *oxidb.Client, vectors []Vector) (Report, error) {
+	report := Report{Coverage: make(map[string]Coverage)}
+
+	for _, v := range vectors {
+		res := runOne(c, v)
+		report.Results = append(report.Results, res)
+		for _, feature := range v.Features {
+			cov := report.Coverage[feature]
+			if res.Passed {
+				cov.Passed++
+			} else {
+				cov.Failed++
+			}
+			report.Coverage[feature] = cov
+		}
+	}
+
+	return report, nil
+}
+
+func runOne(c *oxidb.Client, v Vector) Result {
+	start := time.Now()
+	collection := collectionPrefix + v.Name
+
+	defer c.DropCollection(collection)
+
+	if err := c.CreateCollection(collection); err != nil {
+		return Result{Vector: v, Error: fmt.Errorf("create collection: %w", err), Duration: time.Since(start)}
+	}
+	if len(v.Setup) > 0 {
+		if _, err := c.InsertMany(collection, v.Setup); err != nil {
+			return Result{Vector: v, Error: fmt.Errorf("setup insert: %w", err), Duration: time.Since(start)}
+		}
+	}
+
+	var got []map[string]any
+	var err error
+	switch {
+	case v.Pipeline != nil:
+		got, err = c.Aggregate(collection, v.Pipeline)
+	default:
+		got, err = c.Find(collection, v.Query, v.findOptions())
+	}
+	if err != nil {
+		return Result{Vector: v, Error: fmt.Errorf("query: %w", err), Duration: time.Since(start)}
+	}
+
+	expected := make([]map[string]any, len(v.Expected))
+	expectsID := false
+	for i, doc := range v.Expected {
+		clone := make(map[string]any, len(doc))
+		for k, val := range doc {
+			clone[k] = val
+		}
+		normalizeID(clone)
+		if _, ok := clone["_id"]; ok {
+			expectsID = true
+		}
+		expected[i] = clone
+	}
+
+	for _, doc := range got {
+		normalizeID(doc)
+		if !expectsID {
+			delete(doc, "_id")
+		}
+	}
+
+	passed := docsEqual(got, expected)
+	var resErr error
+	if !passed {
+		resErr = fmt.Errorf("result mismatch: got %d docs, expected %d", len(got), len(expected))
+	}
+	return Result{Vector: v, Passed: passed, Error: resErr, Got: got, Duration: time.Since(start)}
+}
+
+func (v Vector) findOptions() *oxidb.FindOptions {
+	if v.Options == nil {
+		return nil
+	}
+	return &oxidb.FindOptions{
+		Sort:  v.Options.Sort,
+		Skip:  v.Options.Skip,
+		Limit: v.Options.Limit,
+	}
+}
+
+// normalizeID converts the _id field from numeric (float64) to string so
+// vectors don't have to special-case OxiDB's auto-increment numeric IDs.
+// Mirrors OxiDMS's internal/repository helper of the same name.
+func normalizeID(doc map[string]any) {
+	if id, ok := doc["_id"]; ok {
+		switch idv := id.(type) {
+		case float64:
+			doc["_id"] = fmt.Sprintf("%.0f", idv)
+		case int:
+			doc["_id"] = fmt.Sprintf("%d", idv)
+		}
+	}
+}
+
+// docsEqual compares two document sets order-insensitively, since most
+// backends don't guarantee Find ordering without an explicit sort.
+func docsEqual(a, b []map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := make([]map[string]any, len(a))
+	bs := make([]map[string]any, len(b))
+	copy(as, a)
+	copy(bs, b)
+	sort.Slice(as, func(i, j int) bool { return fmt.Sprint(as[i]) < fmt.Sprint(as[j]) })
+	sort.Slice(bs, func(i, j int) bool { return fmt.Sprint(bs[i]) < fmt.Sprint(bs[j]) })
+	for i := range as {
+		if !reflect.DeepEqual(as[i], bs[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+// Package conformance runs a corpus of JSON test vectors against a live
+// oxidb.Client and reports which query/aggregate features the connected
+// backend actually honors. It is meant to be shared by this module and
+// server-side test suites as a single, versionable correctness gate,
+// replacing ad-hoc query lists hand-copied into benchmark mains.
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+//go:embed corpus/*.json
+var embeddedCorpus embed.FS
+
+// Vector is a single conformance test case: seed a fresh collection with
+// Setup, run Query (a filter passed to Client.Find) or Pipeline (passed to
+// Client.Aggregate), and compare the result against Expected.
+type Vector struct {
+	// Name uniquely identifies the vector within the corpus.
+	Name string `json:"name"`
+	// Features tags the operators/capabilities this vector exercises, e.g.
+	// "$or", "$regex", "$in", "nested_path", "composite_index", "$group".
+	Features []string `json:"features"`
+
+	Setup    []map[string]any `json:"setup"`
+	Query    map[string]any   `json:"query,omitempty"`
+	Pipeline []map[string]any `json:"pipeline,omitempty"`
+	Options  *QueryOptions    `json:"options,omitempty"`
+	Expected []map[string]any `json:"expected"`
+}
+
+// QueryOptions mirrors the subset of oxidb.FindOptions a vector can set.
+type QueryOptions struct {
+	Sort  map[string]any `json:"sort,omitempty"`
+	Skip  *int           `json:"skip,omitempty"`
+	Limit *int           `json:"limit,omitempty"`
+}
+
+// LoadEmbedded loads every vector bundled under corpus/*.json.
+func LoadEmbedded() ([]Vector, error) {
+	entries, err := embeddedCorpus.ReadDir("corpus")
+	if err != nil {
+		return nil, fmt.Errorf("conformance: read embedded corpus: %w", err)
+	}
+
+	var vectors []Vector
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		data, err := embeddedCorpus.ReadFile(path.Join("corpus", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("conformance: read %s: %w", e.Name(), err)
+		}
+		var v Vector
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("conformance: parse %s: %w", e.Name(), err)
+		}
+		if v.Name == "" {
+			v.Name = e.Name()
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
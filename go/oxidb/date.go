@@ -0,0 +1,79 @@
+package oxidb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateTime wraps a time.Time so it marshals to a tagged date value instead
+// of a plain RFC3339 string: encoding/json already renders time.Time as a
+// quoted RFC3339 string on its own, which round-trips fine between two Go
+// clients but is indistinguishable on the wire from an ordinary string
+// field, so a server can't tell "2020-01-15" (a date) from "2020-01-15"
+// (text someone typed) and has to fall back to lexicographic string
+// comparison for range queries. The {"$date": ...} tag lets the server's
+// query planner recognize the value, store it in its canonical date
+// encoding, and order a b-tree index on it chronologically rather than
+// lexicographically.
+type DateTime struct {
+	T time.Time
+}
+
+// Date wraps t as a DateTime for use in a query or document, e.g.
+// map[string]any{"createdAt": map[string]any{"$gte": oxidb.Date(t0)}}.
+func Date(t time.Time) DateTime {
+	return DateTime{T: t}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$date":"` + d.T.UTC().Format(time.RFC3339Nano) + `"}`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either the tagged
+// {"$date": "..."} form or a bare RFC3339 string.
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	var tagged struct {
+		Date string `json:"$date"`
+	}
+	if err := json.Unmarshal(data, &tagged); err == nil && tagged.Date != "" {
+		t, err := time.Parse(time.RFC3339Nano, tagged.Date)
+		if err != nil {
+			return fmt.Errorf("oxidb: parse $date: %w", err)
+		}
+		d.T = t
+		return nil
+	}
+	var plain string
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return fmt.Errorf("oxidb: decode date: %w", err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, plain)
+	if err != nil {
+		return fmt.Errorf("oxidb: parse date string: %w", err)
+	}
+	d.T = t
+	return nil
+}
+
+// ParseDate extracts a time.Time from a decoded document field's value,
+// recognizing both the tagged {"$date": "..."} shape a server round-trips
+// DateTime as, and a bare RFC3339 string for data written before this tag
+// existed. Returns false if v isn't a recognizable date.
+func ParseDate(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		s, ok := val["$date"].(string)
+		if !ok {
+			return time.Time{}, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
@@ -1,17 +1,81 @@
 package oxidb
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
-// Error is returned when the OxiDB server returns an error response.
+// Code is a stable, server-assigned identifier for a kind of failure,
+// checkable independent of Message (which may carry request-specific
+// detail, or change wording between server versions) via errors.Is against
+// the package's Err* sentinels.
+type Code string
+
+const (
+	CodeDuplicateKey Code = "duplicate_key"
+	CodeNotFound     Code = "not_found"
+	CodeValidation   Code = "validation"
+	CodeTxConflict   Code = "tx_conflict"
+	CodeIndexMissing Code = "index_missing"
+	CodeUnknown      Code = "unknown"
+)
+
+// Detail is one field-level problem within a validation error.
+type Detail struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	Value  any    `json:"value,omitempty"`
+}
+
+// Error is returned when the OxiDB server reports a request failure.
+// Collection and Field are set when the server attributes the failure to
+// one (e.g. a unique index violation names both); Details carries one
+// entry per offending field for a validation failure with several.
 type Error struct {
-	Msg string
+	Code       Code
+	Message    string
+	Collection string
+	Field      string
+	Details    []Detail
+	Retryable  bool
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("oxidb: %s", e.Msg)
+	switch {
+	case e.Collection != "" && e.Field != "":
+		return fmt.Sprintf("oxidb: %s: %s (collection=%s, field=%s)", e.Code, e.Message, e.Collection, e.Field)
+	case e.Collection != "":
+		return fmt.Sprintf("oxidb: %s: %s (collection=%s)", e.Code, e.Message, e.Collection)
+	default:
+		return fmt.Sprintf("oxidb: %s: %s", e.Code, e.Message)
+	}
+}
+
+// Is lets errors.Is(err, oxidb.ErrNotFound) (and the package's other Err*
+// sentinels) work by comparing Code rather than requiring the exact same
+// *Error instance or identical Message.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && e.Code == t.Code
 }
 
-// TransactionConflictError is returned on OCC version conflict during commit.
+// Err* are sentinel errors for the Code values a server commonly reports.
+// Check a returned error against one with errors.Is, e.g.:
+//
+//	if errors.Is(err, oxidb.ErrDuplicateKey) { ... }
+var (
+	ErrDuplicateKey = &Error{Code: CodeDuplicateKey, Message: "duplicate key"}
+	ErrNotFound     = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrValidation   = &Error{Code: CodeValidation, Message: "validation failed"}
+	ErrTxConflict   = &Error{Code: CodeTxConflict, Message: "transaction conflict"}
+	ErrIndexMissing = &Error{Code: CodeIndexMissing, Message: "index missing"}
+)
+
+// TransactionConflictError is returned on OCC version conflict during
+// commit. It stays a distinct type (rather than folding into *Error with
+// Code: CodeTxConflict) because callers written before structured errors
+// existed match on it directly; errors.Is against oxidb.ErrTxConflict also
+// works for it via Is below.
 type TransactionConflictError struct {
 	Msg string
 }
@@ -19,3 +83,107 @@ type TransactionConflictError struct {
 func (e *TransactionConflictError) Error() string {
 	return fmt.Sprintf("oxidb: transaction conflict: %s", e.Msg)
 }
+
+// Is lets errors.Is(err, oxidb.ErrTxConflict) succeed for the legacy
+// TransactionConflictError type too.
+func (e *TransactionConflictError) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == CodeTxConflict
+}
+
+// TransactionAbortedError means a transaction's commit request was still in
+// flight when its context was cancelled or its deadline passed, so its
+// outcome is unknown: the send may have reached the server and landed
+// before the connection tore down, or it may never have arrived. See
+// Client.WithTransactionContext.
+type TransactionAbortedError struct {
+	Cause error
+}
+
+func (e *TransactionAbortedError) Error() string {
+	return fmt.Sprintf("oxidb: transaction aborted, outcome unknown: %v", e.Cause)
+}
+
+func (e *TransactionAbortedError) Unwrap() error {
+	return e.Cause
+}
+
+// parseError turns a checked response's "error" field into an error.
+// Servers that speak the structured wire format send
+// {code, message, collection, field, details, retryable}; older ones send
+// a flat string, which is mapped to the closest Code by keyword so
+// errors.Is still works against it.
+func parseError(raw any) error {
+	switch v := raw.(type) {
+	case map[string]any:
+		e := &Error{
+			Code:       Code(stringField(v, "code")),
+			Message:    stringField(v, "message"),
+			Collection: stringField(v, "collection"),
+			Field:      stringField(v, "field"),
+			Retryable:  boolField(v, "retryable"),
+		}
+		if rawDetails, ok := v["details"].([]any); ok {
+			for _, d := range rawDetails {
+				dm, ok := d.(map[string]any)
+				if !ok {
+					continue
+				}
+				e.Details = append(e.Details, Detail{
+					Field:  stringField(dm, "field"),
+					Reason: stringField(dm, "reason"),
+					Value:  dm["value"],
+				})
+			}
+		}
+		if e.Code == "" {
+			e.Code = codeFromMessage(e.Message)
+		}
+		if e.Code == CodeTxConflict {
+			return &TransactionConflictError{Msg: e.Message}
+		}
+		return e
+	case string:
+		if v == "" {
+			v = "unknown error"
+		}
+		code := codeFromMessage(v)
+		if code == CodeTxConflict {
+			return &TransactionConflictError{Msg: v}
+		}
+		return &Error{Code: code, Message: v, Retryable: strings.Contains(strings.ToLower(v), "busy")}
+	default:
+		return &Error{Code: CodeUnknown, Message: "unknown error"}
+	}
+}
+
+// codeFromMessage maps a flat error string from a pre-structured-error
+// server to the closest Code, by the same keywords checked() used to
+// single out conflicts before Error carried a Code of its own.
+func codeFromMessage(msg string) Code {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "conflict"):
+		return CodeTxConflict
+	case strings.Contains(lower, "duplicate") || strings.Contains(lower, "unique"):
+		return CodeDuplicateKey
+	case strings.Contains(lower, "not found"):
+		return CodeNotFound
+	case strings.Contains(lower, "index"):
+		return CodeIndexMissing
+	case strings.Contains(lower, "validation") || strings.Contains(lower, "required"):
+		return CodeValidation
+	default:
+		return CodeUnknown
+	}
+}
+
+func stringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func boolField(m map[string]any, key string) bool {
+	b, _ := m[key].(bool)
+	return b
+}
@@ -0,0 +1,326 @@
+package oxidb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// Connection pool
+// ------------------------------------------------------------------
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	Host string
+	Port int
+
+	// MinIdle is how many idle Conns the pool tries to keep warm. Defaults
+	// to 1.
+	MinIdle int
+	// MaxOpen caps the total number of Conns (idle + checked out) the pool
+	// will ever hold at once. 0 means unbounded.
+	MaxOpen int
+	// MaxLifetime closes and replaces a Conn once it's been open this long,
+	// even if it's healthy. 0 means a Conn lives until a health check or
+	// request failure evicts it.
+	MaxLifetime time.Duration
+	// IdleTimeout closes an idle Conn that's sat unused this long. 0 means
+	// idle Conns are never evicted for age alone.
+	IdleTimeout time.Duration
+	// HealthCheckInterval is how often the keeper pings idle Conns and
+	// tries to top idle back up to MinIdle. Defaults to 30s.
+	HealthCheckInterval time.Duration
+	// DialTimeout bounds each dial the keeper makes in the background.
+	// Checkout is instead bounded by the ctx passed to it. Defaults to 5s.
+	DialTimeout time.Duration
+
+	// Backoff governs Do's retry-on-a-fresh-connection loop for idempotent
+	// calls. Defaults to DefaultBackoff.
+	Backoff BackoffPolicy
+}
+
+func (cfg PoolConfig) withDefaults() PoolConfig {
+	if cfg.MinIdle <= 0 {
+		cfg.MinIdle = 1
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 30 * time.Second
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = DefaultBackoff
+	}
+	return cfg
+}
+
+// Pool maintains a set of Conns to one oxidb-server, handed out with
+// Checkout and returned with Release the way database/sql manages
+// *sql.Conn: MinIdle Conns are kept warm, MaxOpen bounds the total open at
+// once, and a background keeper pings idle Conns every HealthCheckInterval,
+// evicting any that fail the ping or have exceeded MaxLifetime/IdleTimeout.
+//
+// A checked-out Conn is pinned to its caller until Release — that's also
+// how transactions stay safe: BeginTx/CommitTx/RollbackTx only make sense
+// issued against the same Conn, and holding one across a transaction's
+// lifetime (not releasing it until after the commit or rollback) is enough
+// to guarantee that without any extra locking.
+type Pool struct {
+	cfg PoolConfig
+
+	mu      sync.Mutex
+	idle    []*Conn
+	numOpen int
+	waiters []chan struct{}
+	closed  bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPool creates a Pool against cfg.Host:cfg.Port. It returns immediately;
+// MinIdle Conns are dialed in the background by the keeper (started here),
+// so a slow or unreachable server doesn't block NewPool's caller — the
+// first Checkout is what surfaces a dial failure.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("oxidb: PoolConfig.Host is required")
+	}
+	p := &Pool{cfg: cfg.withDefaults(), stop: make(chan struct{})}
+	p.wg.Add(1)
+	go p.keeper()
+	return p, nil
+}
+
+// Checkout hands out an idle Conn if one is available, dials a new one if
+// MaxOpen allows it, or blocks until one is returned or ctx is done.
+func (p *Pool) Checkout(ctx context.Context) (*Conn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errors.New("oxidb: pool is closed")
+		}
+
+		if n := len(p.idle); n > 0 {
+			c := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			p.mu.Unlock()
+			if p.expired(c) {
+				c.conn.Close()
+				p.mu.Lock()
+				p.numOpen--
+				p.mu.Unlock()
+				continue
+			}
+			return c, nil
+		}
+
+		if p.cfg.MaxOpen > 0 && p.numOpen >= p.cfg.MaxOpen {
+			ready := make(chan struct{})
+			p.waiters = append(p.waiters, ready)
+			p.mu.Unlock()
+			select {
+			case <-ready:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		p.numOpen++
+		p.mu.Unlock()
+		c, err := dialConn(ctx, p.cfg.Host, p.cfg.Port)
+		if err != nil {
+			p.mu.Lock()
+			p.numOpen--
+			p.mu.Unlock()
+			p.notifyWaiter()
+			return nil, err
+		}
+		c.pool = p
+		return c, nil
+	}
+}
+
+// release is Conn.Release's implementation: back to idle if c looks
+// reusable, otherwise closed and forgotten.
+func (p *Pool) release(c *Conn) {
+	p.mu.Lock()
+	if p.closed || c.bad || (p.cfg.MaxLifetime > 0 && time.Since(c.createdAt) > p.cfg.MaxLifetime) {
+		p.numOpen--
+		p.mu.Unlock()
+		c.conn.Close()
+		p.notifyWaiter()
+		return
+	}
+	c.idleSince = time.Now()
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+	p.notifyWaiter()
+}
+
+// notifyWaiter wakes the oldest Checkout blocked on MaxOpen, if any.
+func (p *Pool) notifyWaiter() {
+	p.mu.Lock()
+	if len(p.waiters) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	ready := p.waiters[0]
+	p.waiters = p.waiters[1:]
+	p.mu.Unlock()
+	close(ready)
+}
+
+// expired reports whether c has exceeded MaxLifetime or (if idle) IdleTimeout.
+func (p *Pool) expired(c *Conn) bool {
+	if p.cfg.MaxLifetime > 0 && time.Since(c.createdAt) > p.cfg.MaxLifetime {
+		return true
+	}
+	if p.cfg.IdleTimeout > 0 && !c.idleSince.IsZero() && time.Since(c.idleSince) > p.cfg.IdleTimeout {
+		return true
+	}
+	return false
+}
+
+// Do checks out a Conn, runs fn against it, and releases it. If fn's error
+// looks transient (see isTransient) and idempotent is true — safe for
+// reads, Count, TextSearch, and the like, never for a write that isn't
+// itself idempotent — the Conn is discarded and fn is retried on a fresh
+// Conn, following cfg.Backoff, until it stops being transient, succeeds, or
+// the backoff policy gives up.
+func (p *Pool) Do(ctx context.Context, idempotent bool, fn func(*Conn) error) error {
+	once := func() error {
+		c, err := p.Checkout(ctx)
+		if err != nil {
+			return err
+		}
+		err = fn(c)
+		if err != nil && isTransient(err) {
+			c.bad = true
+		}
+		c.Release()
+		return err
+	}
+	if !idempotent {
+		return once()
+	}
+	return Retry(ctx, p.cfg.Backoff, once)
+}
+
+// keeper pings idle Conns and tops idle back up to MinIdle on a fixed tick,
+// until Close stops it.
+func (p *Pool) keeper() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+	p.topUp()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.healthCheck()
+			p.topUp()
+		}
+	}
+}
+
+// healthCheck pings every idle Conn, evicting any that fail the ping or
+// have aged out, and keeps the rest idle.
+func (p *Pool) healthCheck() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	keep := idle[:0]
+	for _, c := range idle {
+		if p.expired(c) {
+			c.conn.Close()
+			p.mu.Lock()
+			p.numOpen--
+			p.mu.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.DialTimeout)
+		_, err := c.PingContext(ctx)
+		cancel()
+		if err != nil {
+			c.conn.Close()
+			p.mu.Lock()
+			p.numOpen--
+			p.mu.Unlock()
+			continue
+		}
+		keep = append(keep, c)
+	}
+
+	p.mu.Lock()
+	p.idle = append(p.idle, keep...)
+	p.mu.Unlock()
+	for range keep {
+		p.notifyWaiter()
+	}
+}
+
+// topUp dials new idle Conns until idle reaches MinIdle or MaxOpen is hit,
+// swallowing dial errors — a server that's briefly unreachable is retried
+// on the next tick rather than failing NewPool or the keeper outright.
+func (p *Pool) topUp() {
+	for {
+		p.mu.Lock()
+		need := p.cfg.MinIdle - len(p.idle)
+		if need <= 0 || p.closed || (p.cfg.MaxOpen > 0 && p.numOpen >= p.cfg.MaxOpen) {
+			p.mu.Unlock()
+			return
+		}
+		p.numOpen++
+		p.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.DialTimeout)
+		c, err := dialConn(ctx, p.cfg.Host, p.cfg.Port)
+		cancel()
+		if err != nil {
+			p.mu.Lock()
+			p.numOpen--
+			p.mu.Unlock()
+			return
+		}
+		c.pool = p
+		c.idleSince = time.Now()
+		p.mu.Lock()
+		p.idle = append(p.idle, c)
+		p.mu.Unlock()
+		p.notifyWaiter()
+	}
+}
+
+// Close stops the keeper and closes every idle Conn. Conns still checked
+// out are closed by their own Release once their caller is done with them.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	waiters := p.waiters
+	p.waiters = nil
+	p.mu.Unlock()
+
+	close(p.stop)
+	p.wg.Wait()
+	for _, c := range idle {
+		c.conn.Close()
+	}
+	for _, w := range waiters {
+		close(w)
+	}
+}
@@ -0,0 +1,147 @@
+package oxidb_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/parisxmas/OxiDB/go/oxidb"
+)
+
+func getHostPort(t *testing.T) (string, int) {
+	t.Helper()
+	host := "127.0.0.1"
+	port := 4444
+	if h := os.Getenv("OXIDB_HOST"); h != "" {
+		host = h
+	}
+	if p := os.Getenv("OXIDB_PORT"); p != "" {
+		port, _ = strconv.Atoi(p)
+	}
+	return host, port
+}
+
+func TestPool_CheckoutRelease(t *testing.T) {
+	host, port := getHostPort(t)
+	pool, err := oxidb.NewPool(oxidb.PoolConfig{Host: host, Port: port, MinIdle: 1, MaxOpen: 2})
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+	defer pool.Close()
+
+	conn, err := pool.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	if _, err := conn.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	conn.Release()
+
+	conn2, err := pool.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("checkout after release: %v", err)
+	}
+	defer conn2.Release()
+	if _, err := conn2.Ping(); err != nil {
+		t.Fatalf("ping reused conn: %v", err)
+	}
+}
+
+func TestPool_MaxOpenBlocksUntilRelease(t *testing.T) {
+	host, port := getHostPort(t)
+	pool, err := oxidb.NewPool(oxidb.PoolConfig{Host: host, Port: port, MinIdle: 0, MaxOpen: 1})
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+	defer pool.Close()
+
+	first, err := pool.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("first checkout: %v", err)
+	}
+
+	got := make(chan *oxidb.Conn, 1)
+	go func() {
+		c, err := pool.Checkout(context.Background())
+		if err != nil {
+			t.Errorf("second checkout: %v", err)
+			return
+		}
+		got <- c
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("second checkout should have blocked while MaxOpen=1 was exhausted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Release()
+
+	select {
+	case c := <-got:
+		c.Release()
+	case <-time.After(2 * time.Second):
+		t.Fatal("second checkout never unblocked after release")
+	}
+}
+
+func TestPool_CheckoutContextCanceled(t *testing.T) {
+	host, port := getHostPort(t)
+	pool, err := oxidb.NewPool(oxidb.PoolConfig{Host: host, Port: port, MinIdle: 0, MaxOpen: 1})
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+	defer pool.Close()
+
+	held, err := pool.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	defer held.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Checkout(ctx); err == nil {
+		t.Fatal("expected checkout to time out while the pool was exhausted")
+	}
+}
+
+func TestPool_DoIdempotentRetry(t *testing.T) {
+	host, port := getHostPort(t)
+	pool, err := oxidb.NewPool(oxidb.PoolConfig{Host: host, Port: port, MinIdle: 1, MaxOpen: 2})
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+	defer pool.Close()
+
+	err = pool.Do(context.Background(), true, func(c *oxidb.Conn) error {
+		_, err := c.Ping()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("pool.Do: %v", err)
+	}
+}
+
+func TestClient_IsStillAPool(t *testing.T) {
+	host, port := getHostPort(t)
+	c, err := oxidb.Connect(host, port, 5*time.Second)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if err := c.WithTransaction(func() error {
+		_, err := c.Ping()
+		return err
+	}); err != nil {
+		t.Fatalf("with transaction: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package oxidb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------
+// Change streams
+// ------------------------------------------------------------------
+
+// ChangeEvent is one mutation delivered by Watch or WatchBucket: an
+// insert, update, or delete on whatever collection or bucket the
+// subscription covers. ResumeToken is the position this event leaves the
+// stream at — pass it as WatchOptions.Since (or WatchBucketOptions.Since)
+// on a later Watch call to pick back up without missing events in between,
+// the way FindCursor's cursor ID lets a batch fetch resume server-side.
+type ChangeEvent struct {
+	Type          string
+	DocumentID    string
+	FullDocument  map[string]any
+	UpdatedFields map[string]any
+	ResumeToken   string
+	Timestamp     time.Time
+}
+
+// changeEventFromWire decodes a {"event": "change", ...} frame.
+func changeEventFromWire(frame map[string]any) ChangeEvent {
+	ev := ChangeEvent{
+		Type:        stringField(frame, "type"),
+		DocumentID:  stringField(frame, "document_id"),
+		ResumeToken: stringField(frame, "token"),
+	}
+	if fd, ok := frame["full_document"].(map[string]any); ok {
+		ev.FullDocument = fd
+	}
+	if uf, ok := frame["updated_fields"].(map[string]any); ok {
+		ev.UpdatedFields = uf
+	}
+	if ts, ok := ParseDate(frame["timestamp"]); ok {
+		ev.Timestamp = ts
+	}
+	return ev
+}
+
+// WatchOptions configures a Watch subscription.
+type WatchOptions struct {
+	// Collection is the collection to watch.
+	Collection string
+	// Since resumes from a previous ChangeEvent's ResumeToken instead of
+	// starting from the current moment.
+	Since string
+	// Filter, if set, limits delivered events to documents matching this
+	// query — the same shape Find's query takes.
+	Filter map[string]any
+}
+
+// WatchBucketOptions configures a WatchBucket subscription.
+type WatchBucketOptions struct {
+	// Bucket is the blob bucket to watch.
+	Bucket string
+	// Since resumes from a previous ChangeEvent's ResumeToken instead of
+	// starting from the current moment.
+	Since string
+}
+
+// watchCancelTimeout bounds how long Watch/WatchBucket's cancel func waits
+// for the server to acknowledge a watch_cancel before giving up on a clean
+// handshake and unsubscribing locally anyway.
+const watchCancelTimeout = 5 * time.Second
+
+// Watch subscribes to inserts, updates, and deletes on opts.Collection,
+// delivering them on the returned channel until the returned cancel func is
+// called or ctx is done — whichever comes first; callers must always call
+// cancel, even after ctx ends on its own, to release the subscription's
+// slot on c and its goroutine. This takes over c's socket for server push:
+// see startReader for how a Watch subscription's frames and an ordinary
+// request's response are told apart on the same connection, but c is still
+// usable for other requests while a Watch is open, since WithTransaction,
+// Bulk, and everything else funnel through the same demultiplexed reader.
+func (c *Conn) Watch(ctx context.Context, opts WatchOptions) (<-chan ChangeEvent, func(), error) {
+	payload := map[string]any{"cmd": "watch", "collection": opts.Collection}
+	if opts.Since != "" {
+		payload["since"] = opts.Since
+	}
+	if opts.Filter != nil {
+		payload["filter"] = opts.Filter
+	}
+	return c.watch(ctx, payload)
+}
+
+// WatchBucket subscribes to PUT/DELETE notifications on opts.Bucket, the
+// blob-storage analogue of Watch — useful for driving an external indexing
+// pipeline off object writes instead of polling ListObjects.
+func (c *Conn) WatchBucket(ctx context.Context, opts WatchBucketOptions) (<-chan ChangeEvent, func(), error) {
+	payload := map[string]any{"cmd": "watch", "bucket": opts.Bucket}
+	if opts.Since != "" {
+		payload["since"] = opts.Since
+	}
+	return c.watch(ctx, payload)
+}
+
+// watch is Watch and WatchBucket's shared implementation: it opens the
+// subscription, registers it with c's reader (see Conn.subscribe), and
+// starts a goroutine translating raw frames into ChangeEvents.
+func (c *Conn) watch(ctx context.Context, payload map[string]any) (<-chan ChangeEvent, func(), error) {
+	data, err := c.checkedContext(ctx, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	ack, _ := data.(map[string]any)
+	watchID := stringField(ack, "watch_id")
+	if watchID == "" {
+		return nil, nil, fmt.Errorf("oxidb: watch: server ack missing watch_id")
+	}
+
+	frames, unsubscribe := c.subscribe(watchID)
+	out := make(chan ChangeEvent, 32)
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	cancel := func() {
+		closeOnce.Do(func() {
+			cancelCtx, cancelFn := context.WithTimeout(context.Background(), watchCancelTimeout)
+			c.requestContext(cancelCtx, map[string]any{"cmd": "watch_cancel", "watch_id": watchID})
+			cancelFn()
+			unsubscribe()
+			close(done)
+		})
+	}
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case frame, ok := <-frames:
+				if !ok {
+					return
+				}
+				if frame["event"] == "heartbeat" {
+					continue
+				}
+				select {
+				case out <- changeEventFromWire(frame):
+				case <-done:
+					return
+				}
+			case <-c.readDone:
+				return
+			case <-ctx.Done():
+				cancel()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return out, cancel, nil
+}